@@ -32,6 +32,7 @@ func generateFeatureMatrix() error {
 			{"ALIAS", "Provider supports some kind of ALIAS, ANAME or flattened CNAME record type"},
 			{"CAA", "Provider can manage CAA records"},
 			{"PTR", "Provider supports adding PTR records for reverse lookup zones"},
+			{"SOA", "Provider can manage the SOA record declared with SOA()"},
 			{"SRV", "Driver has explicitly implemented SRV record management"},
 			{"TLSA", "Provider can manage TLSA records"},
 			{"TXTMulti", "Provider can manage TXT records with multiple strings"},
@@ -72,6 +73,7 @@ func generateFeatureMatrix() error {
 		setCap("ALIAS", providers.CanUseAlias)
 		setCap("CAA", providers.CanUseCAA)
 		setCap("PTR", providers.CanUsePTR)
+		setCap("SOA", providers.CanUseSOA)
 		setCap("SRV", providers.CanUseSRV)
 		setCap("TLSA", providers.CanUseTLSA)
 		setCap("TXTMulti", providers.CanUseTXTMulti)
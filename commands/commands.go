@@ -8,6 +8,9 @@ import (
 	"strings"
 
 	"github.com/StackExchange/dnscontrol/models"
+	"github.com/StackExchange/dnscontrol/pkg/normalize"
+	"github.com/StackExchange/dnscontrol/pkg/spflib"
+	"github.com/StackExchange/dnscontrol/providers"
 	"github.com/urfave/cli"
 )
 
@@ -58,7 +61,8 @@ func Run(v string) int {
 // Could come from parsing js, or from stored json
 type GetDNSConfigArgs struct {
 	ExecuteDSLArgs
-	JSONFile string
+	JSONFile   string
+	SPFOffline bool
 }
 
 func (args *GetDNSConfigArgs) flags() []cli.Flag {
@@ -74,11 +78,29 @@ func (args *GetDNSConfigArgs) flags() []cli.Flag {
 			Hidden:      true,
 			Usage:       "same as -ir. only here for backwards compatibility, hence hidden",
 		},
+		cli.BoolFlag{
+			Destination: &args.SPFOffline,
+			Name:        "spf-offline",
+			Usage:       "Flatten SPF records with spfcache.json only, without DNS lookups",
+		},
 	)
 }
 
 // GetDNSConfig reads the json-formatted IR file.
 func GetDNSConfig(args GetDNSConfigArgs) (*models.DNSConfig, error) {
+	cfg, err := readDNSConfig(args)
+	if err != nil {
+		return nil, err
+	}
+	// Split "example.com!tag" so that everything after this sees the bare domain name.
+	cfg.UpdateSplitHorizonNames()
+	if args.SPFOffline {
+		normalize.SPFCacheMode = spflib.CacheOffline
+	}
+	return cfg, nil
+}
+
+func readDNSConfig(args GetDNSConfigArgs) (*models.DNSConfig, error) {
 	if args.JSONFile != "" {
 		f, err := os.Open(args.JSONFile)
 		if err != nil {
@@ -108,7 +130,7 @@ func (args *ExecuteDSLArgs) flags() []cli.Flag {
 			Name:        "config",
 			Value:       "dnsconfig.js",
 			Destination: &args.JSFile,
-			Usage:       "File containing dns config in javascript DSL",
+			Usage:       "File containing dns config in javascript DSL (or YAML/JSON if the name ends in .yaml, .yml or .json)",
 		},
 		cli.StringFlag{
 			Name:        "js",
@@ -159,6 +181,11 @@ func (args *GetCredentialsArgs) flags() []cli.Flag {
 			Usage:       "Provider credentials JSON file",
 			Value:       "creds.json",
 		},
+		cli.BoolFlag{
+			Name:        "http-debug",
+			Destination: &providers.HTTPDebug,
+			Usage:       "Log the requests to and responses from the provider APIs. Credentials in headers are redacted, but not in URLs or bodies",
+		},
 	}
 }
 
@@ -179,7 +206,7 @@ func (args *FilterArgs) flags() []cli.Flag {
 		cli.StringFlag{
 			Name:        "domains",
 			Destination: &args.Domains,
-			Usage:       `Comma separated list of domain names to include`,
+			Usage:       `Comma separated list of domain names to include. "example.com" includes all split horizon variants, "example.com!tag" only the tagged one and "example.com!" only the untagged one`,
 			Value:       "",
 		},
 	}
@@ -205,12 +232,19 @@ func (args *FilterArgs) shouldRunProvider(p string, dc *models.DomainConfig, non
 	return false
 }
 
-func (args *FilterArgs) shouldRunDomain(d string) bool {
+func (args *FilterArgs) shouldRunDomain(dc *models.DomainConfig) bool {
 	if args.Domains == "" {
 		return true
 	}
 	for _, dom := range strings.Split(args.Domains, ",") {
-		if dom == d {
+		if !strings.Contains(dom, "!") {
+			// No tag given: match every variant of the domain.
+			if dom == dc.Name {
+				return true
+			}
+			continue
+		}
+		if dom == dc.Name+"!"+dc.Tag {
 			return true
 		}
 	}
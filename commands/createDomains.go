@@ -44,19 +44,23 @@ func CreateDomains(args CreateDomainsArgs) error {
 	}
 	fmt.Printf("Initialized %d registrars and %d dns service providers.\n", len(registrars), len(dnsProviders))
 	for _, domain := range cfg.Domains {
-		fmt.Println("*** ", domain.Name)
+		fmt.Println("*** ", domain.GetUniqueName())
 		for prov := range domain.DNSProviders {
 			dsp, ok := dnsProviders[prov]
 			if !ok {
 				log.Fatalf("DSP %s not declared.", prov)
 			}
-			if creator, ok := dsp.(providers.DomainCreator); ok {
+			var err error
+			if creator, ok := dsp.(providers.DomainConfigCreator); ok {
+				fmt.Println("  -", prov)
+				err = creator.EnsureDomainConfigExists(domain)
+			} else if creator, ok := dsp.(providers.DomainCreator); ok {
 				fmt.Println("  -", prov)
 				// TODO: maybe return bool if it did anything.
-				err := creator.EnsureDomainExists(domain.Name)
-				if err != nil {
-					fmt.Printf("Error creating domain: %s\n", err)
-				}
+				err = creator.EnsureDomainExists(domain.Name)
+			}
+			if err != nil {
+				fmt.Printf("Error creating domain: %s\n", err)
 			}
 		}
 	}
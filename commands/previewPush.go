@@ -105,10 +105,10 @@ func run(args PreviewArgs, push bool, interactive bool, out printer.CLI) error {
 	totalCorrections := 0
 DomainLoop:
 	for _, domain := range cfg.Domains {
-		if !args.shouldRunDomain(domain.Name) {
+		if !args.shouldRunDomain(domain) {
 			continue
 		}
-		out.StartDomain(domain.Name)
+		out.StartDomain(domain.GetUniqueName())
 		nsList, err := nameservers.DetermineNameservers(domain, 0, dnsProviders)
 		if err != nil {
 			return err
@@ -137,7 +137,8 @@ DomainLoop:
 				continue DomainLoop
 			}
 			totalCorrections += len(corrections)
-			anyErrors = printOrRunCorrections(domain.Name, prov, corrections, out, push, interactive, notifier) || anyErrors
+			batcher, _ := dsp.(providers.CorrectionBatcher)
+			anyErrors = printOrRunCorrections(domain.GetUniqueName(), dc, prov, corrections, batcher, out, push, interactive, notifier) || anyErrors
 		}
 		run := args.shouldRunProvider(domain.Registrar, domain, nonDefaultProviders)
 		out.StartRegistrar(domain.Registrar, !run)
@@ -163,7 +164,7 @@ DomainLoop:
 			continue
 		}
 		totalCorrections += len(corrections)
-		anyErrors = printOrRunCorrections(domain.Name, domain.Registrar, corrections, out, push, interactive, notifier) || anyErrors
+		anyErrors = printOrRunCorrections(domain.GetUniqueName(), dc, domain.Registrar, corrections, nil, out, push, interactive, notifier) || anyErrors
 	}
 	if os.Getenv("TEAMCITY_VERSION") != "" {
 		fmt.Fprintf(os.Stderr, "##teamcity[buildStatus status='SUCCESS' text='%d corrections']", totalCorrections)
@@ -210,11 +211,40 @@ func InitializeProviders(credsFile string, cfg *models.DNSConfig, notifyFlag boo
 	return
 }
 
-func printOrRunCorrections(domain string, provider string, corrections []*models.Correction, out printer.CLI, push bool, interactive bool, notifier notifications.Notifier) (anyErrors bool) {
-	anyErrors = false
+// printOrRunCorrections prints the corrections of a domain and, when pushing,
+// runs them. If one fails and all of them can be undone, the rest are skipped
+// and the ones that ran before it are undone. Otherwise the rest still run.
+// batcher, if not nil, applies all the corrections at once instead.
+func printOrRunCorrections(domain string, dc *models.DomainConfig, provider string, corrections []*models.Correction, batcher providers.CorrectionBatcher, out printer.CLI, push bool, interactive bool, notifier notifications.Notifier) (anyErrors bool) {
 	if len(corrections) == 0 {
 		return false
 	}
+	if push && batcher != nil {
+		selected := []*models.Correction{}
+		for i, correction := range corrections {
+			out.PrintCorrection(i, correction)
+			if interactive && !out.PromptToRun() {
+				continue
+			}
+			selected = append(selected, correction)
+		}
+		if len(selected) == 0 {
+			return false
+		}
+		err := batcher.ApplyCorrections(dc, selected)
+		out.EndCorrection(err)
+		for _, correction := range selected {
+			notifier.Notify(domain, provider, correction.Msg, err, false)
+		}
+		return err != nil
+	}
+	canUndo := true
+	for _, correction := range corrections {
+		if correction.Undo == nil {
+			canUndo = false
+		}
+	}
+	applied := []*models.Correction{}
 	for i, correction := range corrections {
 		out.PrintCorrection(i, correction)
 		var err error
@@ -224,11 +254,33 @@ func printOrRunCorrections(domain string, provider string, corrections []*models
 			}
 			err = correction.F()
 			out.EndCorrection(err)
+			if err != nil && canUndo {
+				notifier.Notify(domain, provider, correction.Msg, err, false)
+				if skipped := len(corrections) - i - 1; skipped > 0 {
+					out.Warnf("Skipping the %d remaining corrections of %s.\n", skipped, domain)
+				}
+				undoCorrections(applied, out)
+				return true
+			}
 			if err != nil {
 				anyErrors = true
 			}
+			applied = append(applied, correction)
 		}
 		notifier.Notify(domain, provider, correction.Msg, err, !push)
 	}
 	return anyErrors
 }
+
+// undoCorrections reverts corrections that were applied, newest first. It is
+// a best effort: the corrections that fail to undo are only reported.
+func undoCorrections(applied []*models.Correction, out printer.CLI) {
+	for i := len(applied) - 1; i >= 0; i-- {
+		correction := applied[i]
+		if err := correction.Undo(); err != nil {
+			out.Warnf("Undo failed: %s: %s\n", correction.Msg, err)
+			continue
+		}
+		out.Warnf("Undone: %s\n", correction.Msg)
+	}
+}
@@ -0,0 +1,140 @@
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/StackExchange/dnscontrol/models"
+	"github.com/StackExchange/dnscontrol/pkg/notifications"
+	"github.com/StackExchange/dnscontrol/pkg/printer"
+	"github.com/StackExchange/dnscontrol/providers/memory"
+)
+
+const pipelineJS = `
+var REG = NewRegistrar('none', 'NONE');
+var MEM = NewDnsProvider('memory', 'MEMORY', {default_ns: ['ns1.example.com.']});
+D('example.com', REG, DnsProvider(MEM),
+    A('@', '1.2.3.4'),
+    CNAME('www', '@'),
+    MX('@', 10, 'mx')
+);
+`
+
+const pipelineCreds = `{
+  "memory": {"instance": "pipeline", "seed": "seed.json"}
+}`
+
+const pipelineSeed = `{"example.com": [{"type": "A", "name": "@", "target": "1.2.3.5"}]}`
+
+func TestRunWithMemoryProvider(t *testing.T) {
+	defer memory.Reset()
+	dir, err := ioutil.TempDir("", "pipeline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for name, content := range map[string]string{"dnsconfig.js": pipelineJS, "creds.json": pipelineCreds, "seed.json": pipelineSeed} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	args := PreviewArgs{}
+	args.JSFile = "dnsconfig.js"
+	args.CredsFile = "creds.json"
+	mem := memory.Instance("pipeline")
+
+	if err := run(args, false, false, printer.ConsolePrinter{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(mem.Applied()) != 0 {
+		t.Fatalf("preview applied corrections: %v", mem.Applied())
+	}
+
+	if err := run(args, true, false, printer.ConsolePrinter{}); err != nil {
+		t.Fatal(err)
+	}
+	// NS, CNAME and MX created, A modified.
+	if n := len(mem.Applied()); n != 4 {
+		t.Fatalf("expected 4 corrections to be applied, got %d: %v", n, mem.Applied())
+	}
+	if recs := mem.Zone("example.com"); len(recs) != 4 {
+		t.Errorf("expected 4 records, got %v", recs)
+	}
+
+	if err := run(args, true, false, printer.ConsolePrinter{}); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(mem.Applied()); n != 4 {
+		t.Fatalf("second push should be a no-op, got %v", mem.Applied())
+	}
+}
+
+func TestRunUndoesCorrectionsOnFailure(t *testing.T) {
+	defer memory.Reset()
+	dir, err := ioutil.TempDir("", "pipeline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// The modification of the A record comes after the creations and fails.
+	js := strings.Replace(pipelineJS, "default_ns: ['ns1.example.com.']", "default_ns: ['ns1.example.com.'], fail_on: 'MODIFY'", 1)
+	for name, content := range map[string]string{"dnsconfig.js": js, "creds.json": pipelineCreds, "seed.json": pipelineSeed} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	args := PreviewArgs{}
+	args.JSFile = "dnsconfig.js"
+	args.CredsFile = "creds.json"
+	if err := run(args, true, false, printer.ConsolePrinter{}); err == nil {
+		t.Fatal("expected an error")
+	}
+	mem := memory.Instance("pipeline")
+	recs := mem.Zone("example.com")
+	if len(recs) != 1 || recs[0].Target != "1.2.3.5" {
+		t.Errorf("expected the zone to be rolled back to the seed, got %v", recs)
+	}
+	// NS, CNAME and MX created, then undone.
+	if n := len(mem.Applied()); n != 6 {
+		t.Errorf("expected 6 corrections to be applied, got %d: %v", n, mem.Applied())
+	}
+}
+
+func TestRunContinuesWithoutUndo(t *testing.T) {
+	ran := []string{}
+	correction := func(msg string, fail bool) *models.Correction {
+		return &models.Correction{Msg: msg, F: func() error {
+			ran = append(ran, msg)
+			if fail {
+				return fmt.Errorf("%s failed", msg)
+			}
+			return nil
+		}}
+	}
+	corrections := []*models.Correction{correction("a", false), correction("b", true), correction("c", false)}
+	anyErrors := printOrRunCorrections("example.com", &models.DomainConfig{Name: "example.com"}, "test", corrections, nil,
+		printer.ConsolePrinter{}, true, false, notifications.Init(map[string]string{}))
+	if !anyErrors {
+		t.Error("expected an error to be reported")
+	}
+	// Without Undo, a failure doesn't stop the other corrections.
+	if strings.Join(ran, ",") != "a,b,c" {
+		t.Errorf("expected all the corrections to run, got %v", ran)
+	}
+}
@@ -5,10 +5,13 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/StackExchange/dnscontrol/models"
 	"github.com/StackExchange/dnscontrol/pkg/js"
 	"github.com/StackExchange/dnscontrol/pkg/normalize"
+	"github.com/StackExchange/dnscontrol/pkg/yamlconfig"
 	"github.com/urfave/cli"
 )
 
@@ -96,15 +99,20 @@ func PrintValidationErrors(errs []error) (fatal bool) {
 }
 
 // ExecuteDSL executes the dnsconfig.js contents.
+// Files ending in .yaml, .yml or .json are read as declarative configuration instead.
 func ExecuteDSL(args ExecuteDSLArgs) (*models.DNSConfig, error) {
 	if args.JSFile == "" {
 		return nil, fmt.Errorf("No config specified")
 	}
+	switch strings.ToLower(filepath.Ext(args.JSFile)) {
+	case ".yaml", ".yml", ".json":
+		return yamlconfig.ParseFile(args.JSFile)
+	}
 	text, err := ioutil.ReadFile(args.JSFile)
 	if err != nil {
 		return nil, fmt.Errorf("Reading js file %s: %s", args.JSFile, err)
 	}
-	dnsConfig, err := js.ExecuteJavascript(string(text), args.DevMode)
+	dnsConfig, err := js.ExecuteJavascriptInDir(string(text), filepath.Dir(args.JSFile), args.DevMode)
 	if err != nil {
 		return nil, fmt.Errorf("Executing javascript in %s: %s", args.JSFile, err)
 	}
@@ -0,0 +1,165 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/StackExchange/dnscontrol/models"
+	"github.com/StackExchange/dnscontrol/providers"
+	"github.com/urfave/cli"
+)
+
+var _ = cmd(catUtils, func() *cli.Command {
+	var args RegistrarStatusArgs
+	return &cli.Command{
+		Name:  "registrar-status",
+		Usage: "report expiry date, lock state and delegation of each domain as seen by its registrar",
+		Action: func(ctx *cli.Context) error {
+			return exit(RegistrarStatus(args))
+		},
+		Flags: args.flags(),
+	}
+}())
+
+// RegistrarStatusArgs contains all data/flags needed to run registrar-status, independently of CLI.
+type RegistrarStatusArgs struct {
+	GetDNSConfigArgs
+	GetCredentialsArgs
+	FilterArgs
+	Format   string
+	WarnDays int
+}
+
+func (args *RegistrarStatusArgs) flags() []cli.Flag {
+	flags := args.GetDNSConfigArgs.flags()
+	flags = append(flags, args.GetCredentialsArgs.flags()...)
+	flags = append(flags, args.FilterArgs.flags()...)
+	flags = append(flags,
+		cli.StringFlag{
+			Name:        "format",
+			Destination: &args.Format,
+			Value:       "table",
+			Usage:       "Output format: table or json",
+		},
+		cli.IntFlag{
+			Name:        "warn-days",
+			Destination: &args.WarnDays,
+			Usage:       "Fail if any domain expires within this many days (0 disables the check)",
+		},
+	)
+	return flags
+}
+
+// RegistrarStatus implements the registrar-status subcommand.
+func RegistrarStatus(args RegistrarStatusArgs) error {
+	if args.Format != "table" && args.Format != "json" {
+		return fmt.Errorf("Unknown format %q. Use table or json", args.Format)
+	}
+	cfg, err := GetDNSConfig(args.GetDNSConfigArgs)
+	if err != nil {
+		return err
+	}
+	registrars, _, nonDefaultProviders, _, err := InitializeProviders(args.CredsFile, cfg, false)
+	if err != nil {
+		return err
+	}
+	statuses := []*models.RegistrarStatus{}
+	anyErrors := false
+	for _, domain := range cfg.Domains {
+		if !args.shouldRunDomain(domain) || !args.shouldRunProvider(domain.Registrar, domain, nonDefaultProviders) {
+			continue
+		}
+		reg, ok := registrars[domain.Registrar]
+		if !ok {
+			return fmt.Errorf("Registrar %s not declared", domain.Registrar)
+		}
+		reporter, ok := reg.(providers.RegistrarStatusReporter)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Note: registrar %s cannot report the status of %s, skipping it\n", domain.Registrar, domain.Name)
+			continue
+		}
+		st, err := reporter.GetRegistrarStatus(domain.Name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error getting status of %s from %s: %s\n", domain.Name, domain.Registrar, err)
+			anyErrors = true
+			continue
+		}
+		st.Registrar = domain.Registrar
+		statuses = append(statuses, st)
+	}
+	sortStatuses(statuses)
+
+	now := time.Now()
+	if err := printStatuses(os.Stdout, args.Format, statuses, now); err != nil {
+		return err
+	}
+	if expiring := expiringDomains(statuses, args.WarnDays, now); len(expiring) > 0 {
+		return fmt.Errorf("%d domain(s) expire within %d days: %s", len(expiring), args.WarnDays, strings.Join(expiring, ", "))
+	}
+	if anyErrors {
+		return fmt.Errorf("Completed with errors")
+	}
+	return nil
+}
+
+// sortStatuses sorts the domains by expiry date, then name. The domains
+// whose expiry date is unknown come last.
+func sortStatuses(statuses []*models.RegistrarStatus) {
+	sort.Slice(statuses, func(i, j int) bool {
+		a, b := statuses[i].Expiry, statuses[j].Expiry
+		switch {
+		case a == nil && b == nil, a != nil && b != nil && a.Equal(*b):
+			return statuses[i].Domain < statuses[j].Domain
+		case a == nil || b == nil:
+			return b == nil
+		}
+		return a.Before(*b)
+	})
+}
+
+// printStatuses writes the statuses as a table or as json.
+func printStatuses(out io.Writer, format string, statuses []*models.RegistrarStatus, now time.Time) error {
+	if format == "json" {
+		dat, err := json.MarshalIndent(statuses, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(out, string(dat))
+		return err
+	}
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "DOMAIN\tREGISTRAR\tEXPIRY\tDAYS\tLOCKED\tNAMESERVERS")
+	for _, st := range statuses {
+		expiry, days, locked := "unknown", "unknown", "unknown"
+		if d, ok := st.DaysUntilExpiry(now); ok {
+			expiry, days = st.Expiry.Format("2006-01-02"), strconv.Itoa(d)
+		}
+		if st.Locked != nil {
+			locked = strconv.FormatBool(*st.Locked)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", st.Domain, st.Registrar, expiry, days, locked, strings.Join(st.Nameservers, ","))
+	}
+	return w.Flush()
+}
+
+// expiringDomains returns the domains that expire within warnDays days.
+// Domains whose expiry date is unknown are skipped.
+func expiringDomains(statuses []*models.RegistrarStatus, warnDays int, now time.Time) []string {
+	expiring := []string{}
+	if warnDays <= 0 {
+		return expiring
+	}
+	for _, st := range statuses {
+		if days, ok := st.DaysUntilExpiry(now); ok && days < warnDays {
+			expiring = append(expiring, st.Domain)
+		}
+	}
+	return expiring
+}
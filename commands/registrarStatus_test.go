@@ -0,0 +1,103 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/StackExchange/dnscontrol/models"
+)
+
+var statusNow = time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC)
+
+func testStatus(domain string, days int, locked string) *models.RegistrarStatus {
+	st := &models.RegistrarStatus{Domain: domain, Registrar: "reg", Nameservers: []string{"ns1.example.com", "ns2.example.com"}}
+	if days != 0 {
+		st.SetExpiry(statusNow.Add(time.Duration(days) * 24 * time.Hour))
+	}
+	if locked != "" {
+		st.SetLocked(locked == "true")
+	}
+	return st
+}
+
+func statusDomains(statuses []*models.RegistrarStatus) []string {
+	names := []string{}
+	for _, st := range statuses {
+		names = append(names, st.Domain)
+	}
+	return names
+}
+
+func TestSortStatuses(t *testing.T) {
+	tests := []struct {
+		desc     string
+		statuses []*models.RegistrarStatus
+		expected []string
+	}{
+		{"by expiry", []*models.RegistrarStatus{testStatus("a.com", 300, ""), testStatus("b.com", 10, ""), testStatus("c.com", 100, "")}, []string{"b.com", "c.com", "a.com"}},
+		{"same expiry by name", []*models.RegistrarStatus{testStatus("b.com", 10, ""), testStatus("a.com", 10, "")}, []string{"a.com", "b.com"}},
+		{"unknown last", []*models.RegistrarStatus{testStatus("z.com", 0, ""), testStatus("b.com", 10, ""), testStatus("a.com", 0, "")}, []string{"b.com", "a.com", "z.com"}},
+	}
+	for _, tst := range tests {
+		t.Run(tst.desc, func(t *testing.T) {
+			sortStatuses(tst.statuses)
+			if found := statusDomains(tst.statuses); !reflect.DeepEqual(found, tst.expected) {
+				t.Errorf("expected %v, got %v", tst.expected, found)
+			}
+		})
+	}
+}
+
+func TestPrintStatuses(t *testing.T) {
+	statuses := []*models.RegistrarStatus{testStatus("a.com", 30, "true"), testStatus("b.com", 0, "")}
+
+	buf := &bytes.Buffer{}
+	if err := printStatuses(buf, "table", statuses, statusNow); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"DOMAIN  REGISTRAR  EXPIRY      DAYS     LOCKED   NAMESERVERS",
+		"a.com   reg        2018-07-01  30       true     ns1.example.com,ns2.example.com",
+		"b.com   reg        unknown     unknown  unknown  ns1.example.com,ns2.example.com",
+	}
+	if found := strings.Split(strings.TrimSpace(buf.String()), "\n"); !reflect.DeepEqual(found, expected) {
+		t.Errorf("expected\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(found, "\n"))
+	}
+
+	buf.Reset()
+	if err := printStatuses(buf, "json", statuses, statusNow); err != nil {
+		t.Fatal(err)
+	}
+	found := []map[string]interface{}{}
+	if err := json.Unmarshal(buf.Bytes(), &found); err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 2 || found[0]["expiry"] != "2018-07-01T00:00:00Z" || found[0]["locked"] != true {
+		t.Errorf("unexpected json %s", buf)
+	}
+	if found[1]["expiry"] != nil || found[1]["locked"] != nil {
+		t.Errorf("expected null expiry and lock state, got %s", buf)
+	}
+}
+
+func TestExpiringDomains(t *testing.T) {
+	statuses := []*models.RegistrarStatus{testStatus("soon.com", 5, ""), testStatus("later.com", 60, ""), testStatus("expired.com", -3, ""), testStatus("unknown.com", 0, "")}
+	tests := []struct {
+		warnDays int
+		expected []string
+	}{
+		{0, []string{}},
+		{1, []string{"expired.com"}},
+		{30, []string{"soon.com", "expired.com"}},
+		{90, []string{"soon.com", "later.com", "expired.com"}},
+	}
+	for _, tst := range tests {
+		if found := expiringDomains(statuses, tst.warnDays, statusNow); !reflect.DeepEqual(found, tst.expected) {
+			t.Errorf("--warn-days %d: expected %v, got %v", tst.warnDays, tst.expected, found)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package commands
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/StackExchange/dnscontrol/pkg/normalize"
+	"github.com/StackExchange/dnscontrol/pkg/spflib"
+	"github.com/urfave/cli"
+)
+
+var _ = cmd(catUtils, func() *cli.Command {
+	var args SPFCheckArgs
+	return &cli.Command{
+		Name:  "spf-check",
+		Usage: "evaluate the SPF policy of a domain for the ip address of a mail server",
+		Action: func(ctx *cli.Context) error {
+			return exit(SPFCheck(args))
+		},
+		Flags: args.flags(),
+	}
+}())
+
+// SPFCheckArgs contains all data/flags needed to run spf-check, independently of CLI.
+type SPFCheckArgs struct {
+	GetDNSConfigArgs
+	Domain string
+	IP     string
+	Sender string
+	Helo   string
+	Live   bool
+}
+
+func (args *SPFCheckArgs) flags() []cli.Flag {
+	return append(args.GetDNSConfigArgs.flags(),
+		cli.StringFlag{
+			Name:        "domain",
+			Destination: &args.Domain,
+			Usage:       "The domain whose policy is checked",
+		},
+		cli.StringFlag{
+			Name:        "ip",
+			Destination: &args.IP,
+			Usage:       "The ip address of the mail server",
+		},
+		cli.StringFlag{
+			Name:        "sender",
+			Destination: &args.Sender,
+			Usage:       "The sender of the mail, for the macros of the policy (default: postmaster@domain)",
+		},
+		cli.StringFlag{
+			Name:        "helo",
+			Destination: &args.Helo,
+			Usage:       "The name given by the mail server in HELO, for the macros of the policy",
+		},
+		cli.BoolFlag{
+			Name:        "live",
+			Destination: &args.Live,
+			Usage:       "Check the SPF records published in DNS instead of the ones in the configuration",
+		},
+	)
+}
+
+// configResolver returns the SPF records of the configuration, and looks
+// the others up in DNS.
+type configResolver struct {
+	spflib.LiveResolver
+	records map[string][]string
+}
+
+func (c configResolver) GetSPF(name string) (string, error) {
+	switch recs := c.records[strings.ToLower(name)]; len(recs) {
+	case 0:
+		return c.LiveResolver.GetSPF(name)
+	case 1:
+		return recs[0], nil
+	}
+	return "", fmt.Errorf("%s has multiple SPF records in the configuration", name)
+}
+
+// SPFCheck implements the spf-check subcommand.
+func SPFCheck(args SPFCheckArgs) error {
+	if args.Domain == "" {
+		return fmt.Errorf("--domain is required")
+	}
+	ip := net.ParseIP(args.IP)
+	if ip == nil {
+		return fmt.Errorf("--ip must be an ip address")
+	}
+	var res spflib.DNSResolver = spflib.LiveResolver{}
+	if !args.Live {
+		cfg, err := GetDNSConfig(args.GetDNSConfigArgs)
+		if err != nil {
+			return err
+		}
+		errs := normalize.NormalizeAndValidateConfig(cfg)
+		if PrintValidationErrors(errs) {
+			return fmt.Errorf("Exiting due to validation errors")
+		}
+		records := map[string][]string{}
+		for _, domain := range cfg.Domains {
+			for _, rec := range domain.Records {
+				if rec.Type != "TXT" {
+					continue
+				}
+				txt := strings.Join(rec.TxtStrings, "")
+				if txt == "" {
+					txt = rec.Target
+				}
+				if txt == "v=spf1" || strings.HasPrefix(txt, "v=spf1 ") {
+					name := strings.ToLower(rec.NameFQDN)
+					records[name] = append(records[name], txt)
+				}
+			}
+		}
+		res = configResolver{records: records}
+	}
+	r := spflib.Check(res, ip, strings.TrimSuffix(args.Domain, "."), args.Sender, args.Helo, "")
+	fmt.Printf("%s: %s\n", args.Domain, r)
+	fmt.Printf("lookups: %d/%d, void lookups: %d/2\n", r.Lookups, spflib.MaxLookups, r.VoidLookups)
+	if r.Result == spflib.PermError {
+		return fmt.Errorf("The SPF policy of %s is invalid", args.Domain)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/StackExchange/dnscontrol/pkg/normalize"
+	"github.com/urfave/cli"
+)
+
+var _ = cmd(catUtils, func() *cli.Command {
+	var args UpdateSPFCacheArgs
+	return &cli.Command{
+		Name:  "update-spf-cache",
+		Usage: "looks up the SPF records to flatten again, and updates spfcache.json",
+		Action: func(ctx *cli.Context) error {
+			return exit(UpdateSPFCache(args))
+		},
+		Flags: args.flags(),
+	}
+}())
+
+// UpdateSPFCacheArgs contains all data/flags needed to run update-spf-cache, independently of CLI.
+type UpdateSPFCacheArgs struct {
+	GetDNSConfigArgs
+}
+
+func (args *UpdateSPFCacheArgs) flags() []cli.Flag {
+	return args.GetDNSConfigArgs.flags()
+}
+
+// UpdateSPFCache implements the update-spf-cache subcommand.
+func UpdateSPFCache(args UpdateSPFCacheArgs) error {
+	cfg, err := GetDNSConfig(args.GetDNSConfigArgs)
+	if err != nil {
+		return err
+	}
+	changes, err := normalize.UpdateSPFCache(cfg)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		fmt.Printf("%s is up to date\n", normalize.SPFCacheFile)
+		return nil
+	}
+	for _, c := range changes {
+		switch {
+		case c.Old == "":
+			fmt.Printf("+ %s: %s\n", c.Name, c.New)
+		case c.New == "":
+			fmt.Printf("- %s: %s\n", c.Name, c.Old)
+		default:
+			fmt.Printf("~ %s:\n    - %s\n    + %s\n", c.Name, c.Old, c.New)
+		}
+	}
+	fmt.Printf("Updated %d records in %s\n", len(changes), normalize.SPFCacheFile)
+	return nil
+}
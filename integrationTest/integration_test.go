@@ -1,7 +1,10 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
+	"io/ioutil"
+	"path/filepath"
 	"testing"
 
 	"fmt"
@@ -11,6 +14,7 @@ import (
 
 	"github.com/StackExchange/dnscontrol/models"
 	"github.com/StackExchange/dnscontrol/pkg/nameservers"
+	"github.com/StackExchange/dnscontrol/pkg/recorder"
 	"github.com/StackExchange/dnscontrol/providers"
 	_ "github.com/StackExchange/dnscontrol/providers/_all"
 	"github.com/StackExchange/dnscontrol/providers/config"
@@ -21,55 +25,138 @@ var providerToRun = flag.String("provider", "", "Provider to run")
 var startIdx = flag.Int("start", 0, "Test number to begin with")
 var endIdx = flag.Int("end", 0, "Test index to stop after")
 var verbose = flag.Bool("verbose", false, "Print corrections as you run them")
+var record = flag.Bool("record", false, "Record the HTTP traffic of the provider in cassettes/")
+var replay = flag.Bool("replay", false, "Replay the HTTP traffic recorded in cassettes/ instead of using the network")
 
-func init() {
-	flag.Parse()
-}
-
-func getProvider(t *testing.T) (providers.DNSServiceProvider, string, map[int]bool) {
+func getProvider(t *testing.T) (providers.DNSServiceProvider, string, map[int]bool, func()) {
 	if *providerToRun == "" {
 		t.Log("No provider specified with -provider")
-		return nil, "", nil
+		return nil, "", nil, nil
+	}
+	if *record && *replay {
+		t.Fatal("-record and -replay can not be used together")
+	}
+	cassette := ""
+	if *record || *replay {
+		cassette = filepath.Join("cassettes", *providerToRun, t.Name()+".json")
 	}
+	mode := recorder.Record
+	if *replay {
+		mode = recorder.Replay
+	}
+	return startProvider(t, *providerToRun, cassette, mode)
+}
+
+// startProvider creates the provider name of providers.json. If cassette is
+// not empty, its HTTP traffic is recorded in, or replayed from, that file.
+// The returned function must be called when the test is done.
+func startProvider(t *testing.T, name, cassette string, mode recorder.Mode) (providers.DNSServiceProvider, string, map[int]bool, func()) {
 	jsons, err := config.LoadProviderConfigs("providers.json")
 	if err != nil {
 		t.Fatalf("Error loading provider configs: %s", err)
 	}
+	cfg, ok := jsons[name]
+	if !ok {
+		t.Fatalf("Provider %s not found", name)
+	}
+	done := func() {}
+	if cassette != "" {
+		done = startRecorder(t, name, cfg, cassette, mode)
+	}
+	provider, err := providers.CreateDNSProvider(name, cfg, nil)
+	if err != nil {
+		done()
+		t.Fatal(err)
+	}
 	fails := map[int]bool{}
-	for name, cfg := range jsons {
-		if *providerToRun != name {
+	if f := cfg["knownFailures"]; f != "" {
+		for _, s := range strings.Split(f, ",") {
+			i, err := strconv.Atoi(s)
+			if err != nil {
+				done()
+				t.Fatal(err)
+			}
+			fails[i] = true
+		}
+	}
+	return provider, cfg["domain"], fails, done
+}
+
+// startRecorder records or replays the HTTP traffic of the provider in
+// cassette. Values taken from environment variables in providers.json are
+// secrets: they are scrubbed from the cassette, and replaced by a placeholder
+// when replaying without them.
+// The returned function restores the HTTP transport and saves the cassette.
+func startRecorder(t *testing.T, name string, cfg map[string]string, cassette string, mode recorder.Mode) func() {
+	raw := map[string]map[string]string{}
+	dat, err := ioutil.ReadFile("providers.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(dat, &raw); err != nil {
+		t.Fatal(err)
+	}
+	secrets := []string{}
+	for k, v := range raw[name] {
+		if k == "domain" || !strings.HasPrefix(v, "$") {
 			continue
 		}
-		provider, err := providers.CreateDNSProvider(name, cfg, nil)
-		if err != nil {
-			t.Fatal(err)
+		if cfg[k] == "" && mode == recorder.Replay {
+			cfg[k] = recorder.Placeholder
 		}
-		if f := cfg["knownFailures"]; f != "" {
-			for _, s := range strings.Split(f, ",") {
-				i, err := strconv.Atoi(s)
-				if err != nil {
-					t.Fatal(err)
-				}
-				fails[i] = true
-			}
+		secrets = append(secrets, cfg[k])
+	}
+	rec, err := recorder.New(cassette, mode, secrets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode == recorder.Replay && cfg["domain"] == "" {
+		cfg["domain"] = rec.Cassette.Meta["domain"]
+	}
+	rec.Cassette.Meta["domain"] = cfg["domain"]
+	old := providers.HTTPTransport
+	providers.HTTPTransport = rec
+	return func() {
+		providers.HTTPTransport = old
+		if err := rec.Save(); err != nil {
+			t.Error(err)
 		}
-		return provider, cfg["domain"], fails
 	}
-	t.Fatalf("Provider %s not found", *providerToRun)
-	return nil, "", nil
 }
 
 func TestDNSProviders(t *testing.T) {
-	provider, domain, fails := getProvider(t)
+	provider, domain, fails, done := getProvider(t)
 	if provider == nil {
 		return
 	}
+	defer done()
 	t.Run(fmt.Sprintf("%s", domain), func(t *testing.T) {
-		runTests(t, provider, domain, fails)
+		runTests(t, provider, *providerToRun, domain, fails)
 	})
 
 }
 
+// TestReplay replays the cassettes of TestDNSProviders committed in
+// cassettes/, so that the tests of those providers run without -provider.
+func TestReplay(t *testing.T) {
+	if *providerToRun != "" {
+		t.Skip("-provider is set")
+	}
+	cassettes, err := filepath.Glob(filepath.Join("cassettes", "*", "TestDNSProviders.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, cassette := range cassettes {
+		cassette := cassette
+		name := filepath.Base(filepath.Dir(cassette))
+		t.Run(name, func(t *testing.T) {
+			provider, domain, fails, done := startProvider(t, name, cassette, recorder.Replay)
+			defer done()
+			runTests(t, provider, name, domain, fails)
+		})
+	}
+}
+
 func getDomainConfigWithNameservers(t *testing.T, prv providers.DNSServiceProvider, domainName string) *models.DomainConfig {
 	dc := &models.DomainConfig{
 		Name: domainName,
@@ -84,11 +171,11 @@ func getDomainConfigWithNameservers(t *testing.T, prv providers.DNSServiceProvid
 	return dc
 }
 
-func runTests(t *testing.T, prv providers.DNSServiceProvider, domainName string, knownFailures map[int]bool) {
+func runTests(t *testing.T, prv providers.DNSServiceProvider, name, domainName string, knownFailures map[int]bool) {
 	dc := getDomainConfigWithNameservers(t, prv, domainName)
 	// run tests one at a time
 	end := *endIdx
-	tests := makeTests(t)
+	tests := makeTests(t, name)
 	if end == 0 || end >= len(tests) {
 		end = len(tests) - 1
 	}
@@ -153,10 +240,11 @@ func runTests(t *testing.T, prv providers.DNSServiceProvider, domainName string,
 }
 
 func TestDualProviders(t *testing.T) {
-	p, domain, _ := getProvider(t)
+	p, domain, _, done := getProvider(t)
 	if p == nil {
 		return
 	}
+	defer done()
 	dc := getDomainConfigWithNameservers(t, p, domain)
 	// clear everything
 	run := func() {
@@ -228,6 +316,16 @@ func ptr(name, target string) *rec {
 	return makeRec(name, target, "PTR")
 }
 
+func soa(name, ns, mbox string, refresh, retry, expire, minttl uint32) *rec {
+	r := makeRec(name, ns, "SOA")
+	r.SoaMbox = mbox
+	r.SoaRefresh = refresh
+	r.SoaRetry = retry
+	r.SoaExpire = expire
+	r.SoaMinttl = minttl
+	return r
+}
+
 func srv(name string, priority, weight, port uint16, target string) *rec {
 	r := makeRec(name, target, "SRV")
 	r.SrvPriority = priority
@@ -295,7 +393,7 @@ func manyA(namePattern, target string, n int) []*rec {
 	return recs
 }
 
-func makeTests(t *testing.T) []*TestCase {
+func makeTests(t *testing.T, name string) []*TestCase {
 	// ALWAYS ADD TO BOTTOM OF LIST. Order and indexes matter.
 	tests := []*TestCase{
 		// A
@@ -344,7 +442,7 @@ func makeTests(t *testing.T) []*TestCase {
 	}
 
 	// PTR
-	if !providers.ProviderHasCabability(*providerToRun, providers.CanUsePTR) {
+	if !providers.ProviderHasCabability(name, providers.CanUsePTR) {
 		t.Log("Skipping PTR Tests because provider does not support them")
 	} else {
 		tests = append(tests, tc("Empty"),
@@ -354,7 +452,7 @@ func makeTests(t *testing.T) []*TestCase {
 	}
 
 	// ALIAS
-	if !providers.ProviderHasCabability(*providerToRun, providers.CanUseAlias) {
+	if !providers.ProviderHasCabability(name, providers.CanUseAlias) {
 		t.Log("Skipping ALIAS Tests because provider does not support them")
 	} else {
 		tests = append(tests, tc("Empty"),
@@ -365,7 +463,7 @@ func makeTests(t *testing.T) []*TestCase {
 	}
 
 	// SRV
-	if !providers.ProviderHasCabability(*providerToRun, providers.CanUseSRV) {
+	if !providers.ProviderHasCabability(name, providers.CanUseSRV) {
 		t.Log("Skipping SRV Tests because provider does not support them")
 	} else {
 		tests = append(tests, tc("Empty"),
@@ -381,7 +479,7 @@ func makeTests(t *testing.T) []*TestCase {
 	}
 
 	// CAA
-	if !providers.ProviderHasCabability(*providerToRun, providers.CanUseCAA) {
+	if !providers.ProviderHasCabability(name, providers.CanUseCAA) {
 		t.Log("Skipping CAA Tests because provider does not support them")
 	} else {
 		tests = append(tests, tc("Empty"),
@@ -395,7 +493,7 @@ func makeTests(t *testing.T) []*TestCase {
 	}
 
 	// TLSA
-	if !providers.ProviderHasCabability(*providerToRun, providers.CanUseTLSA) {
+	if !providers.ProviderHasCabability(name, providers.CanUseTLSA) {
 		t.Log("Skipping TLSA Tests because provider does not support them")
 	} else {
 		sha256hash := strings.Repeat("0123456789abcdef", 4)
@@ -410,6 +508,17 @@ func makeTests(t *testing.T) []*TestCase {
 		)
 	}
 
+	// SOA
+	if !providers.ProviderHasCabability(name, providers.CanUseSOA) {
+		t.Log("Skipping SOA Tests because provider does not support them")
+	} else {
+		tests = append(tests, tc("Empty"),
+			tc("SOA record", soa("@", "ns1.example.com.", "hostmaster.example.com.", 3600, 600, 604800, 1440)),
+			tc("SOA change mbox", soa("@", "ns1.example.com.", "admin.example.com.", 3600, 600, 604800, 1440)),
+			tc("SOA change timers", soa("@", "ns1.example.com.", "admin.example.com.", 7200, 900, 1209600, 300)),
+		)
+	}
+
 	// Case
 	tests = append(tests, tc("Empty"),
 		tc("Empty"),
@@ -427,7 +536,7 @@ func makeTests(t *testing.T) []*TestCase {
 	skip := map[string]bool{
 		"NS1": true, // ns1 free acct only allows 50 records
 	}
-	if skip[*providerToRun] {
+	if skip[name] {
 		t.Log("Skipping Large record count Tests because provider does not support them")
 	} else {
 		tests = append(tests, tc("Empty"),
@@ -449,7 +558,7 @@ func makeTests(t *testing.T) []*TestCase {
 	)
 
 	// TXTMulti
-	if !providers.ProviderHasCabability(*providerToRun, providers.CanUseTXTMulti) {
+	if !providers.ProviderHasCabability(name, providers.CanUseTXTMulti) {
 		t.Log("Skipping TXTMulti Tests because provider does not support them")
 	} else {
 		tests = append(tests,
@@ -13,6 +13,7 @@ import (
 
 	"github.com/StackExchange/dnscontrol/pkg/transform"
 	"github.com/miekg/dns"
+	"github.com/miekg/dns/dnsutil"
 	"golang.org/x/net/idna"
 )
 
@@ -27,15 +28,23 @@ type DNSConfig struct {
 }
 
 // FindDomain returns the *DomainConfig for domain query in config.
+// query is matched against the unique name, so a tagged domain is found with "example.com!tag".
 func (config *DNSConfig) FindDomain(query string) *DomainConfig {
 	for _, b := range config.Domains {
-		if b.Name == query {
+		if b.GetUniqueName() == query {
 			return b
 		}
 	}
 	return nil
 }
 
+// UpdateSplitHorizonNames calls UpdateSplitHorizonNames on every domain.
+func (config *DNSConfig) UpdateSplitHorizonNames() {
+	for _, d := range config.Domains {
+		d.UpdateSplitHorizonNames()
+	}
+}
+
 // RegistrarConfig describes a registrar.
 type RegistrarConfig struct {
 	Name     string          `json:"name"`
@@ -75,11 +84,13 @@ type DNSProviderConfig struct {
 //     MX
 //     NS
 //     PTR
+//     SOA
 //     SRV
 //     TLSA
 //     TXT
 //   Pseudo-Types:
 //     ALIAs
+//     CF_PAGE_RULE
 //     CF_REDIRECT
 //     CF_TEMP_REDIRECT
 //     FRAME
@@ -88,6 +99,7 @@ type DNSProviderConfig struct {
 //     NO_PURGE
 //     PAGE_RULE
 //     PURGE
+//     R53_HEALTH_CHECK
 //     URL
 //     URL301
 type RecordConfig struct {
@@ -106,6 +118,12 @@ type RecordConfig struct {
 	TlsaUsage        uint8             `json:"tlsausage,omitempty"`
 	TlsaSelector     uint8             `json:"tlsaselector,omitempty"`
 	TlsaMatchingType uint8             `json:"tlsamatchingtype,omitempty"`
+	SoaMbox          string            `json:"soambox,omitempty"` // The master nameserver is stored in Target.
+	SoaSerial        uint32            `json:"soaserial,omitempty"`
+	SoaRefresh       uint32            `json:"soarefresh,omitempty"`
+	SoaRetry         uint32            `json:"soaretry,omitempty"`
+	SoaExpire        uint32            `json:"soaexpire,omitempty"`
+	SoaMinttl        uint32            `json:"soaminttl,omitempty"`
 	TxtStrings       []string          `json:"txtstrings,omitempty"` // TxtStrings stores all strings (including the first). Target stores only the first one.
 
 	CombinedTarget bool `json:"-"`
@@ -126,6 +144,8 @@ func (rc *RecordConfig) String() (content string) {
 		content += fmt.Sprintf(" pref=%d", rc.MxPreference)
 	case "SOA":
 		content = fmt.Sprintf("%s %s %s %d", rc.Type, rc.Name, rc.Target, rc.TTL)
+		content += fmt.Sprintf(" soambox=%s soaserial=%d soarefresh=%d soaretry=%d soaexpire=%d soaminttl=%d",
+			rc.SoaMbox, rc.SoaSerial, rc.SoaRefresh, rc.SoaRetry, rc.SoaExpire, rc.SoaMinttl)
 	case "SRV":
 		content += fmt.Sprintf(" srvpriority=%d srvweight=%d srvport=%d", rc.SrvPriority, rc.SrvWeight, rc.SrvPort)
 	case "TLSA":
@@ -188,6 +208,12 @@ func (rc *RecordConfig) MergeToTarget() {
 	rc.TlsaUsage = 0
 	rc.TlsaMatchingType = 0
 	rc.TlsaSelector = 0
+	rc.SoaMbox = ""
+	rc.SoaSerial = 0
+	rc.SoaRefresh = 0
+	rc.SoaRetry = 0
+	rc.SoaExpire = 0
+	rc.SoaMinttl = 0
 
 	rc.CombinedTarget = true
 }
@@ -229,15 +255,13 @@ func (rc *RecordConfig) ToRR() dns.RR {
 	case dns.TypeNS:
 		rr.(*dns.NS).Ns = rc.Target
 	case dns.TypeSOA:
-		t := strings.Replace(rc.Target, `\ `, ` `, -1)
-		parts := strings.Fields(t)
-		rr.(*dns.SOA).Ns = parts[0]
-		rr.(*dns.SOA).Mbox = parts[1]
-		rr.(*dns.SOA).Serial = atou32(parts[2])
-		rr.(*dns.SOA).Refresh = atou32(parts[3])
-		rr.(*dns.SOA).Retry = atou32(parts[4])
-		rr.(*dns.SOA).Expire = atou32(parts[5])
-		rr.(*dns.SOA).Minttl = atou32(parts[6])
+		rr.(*dns.SOA).Ns = rc.Target
+		rr.(*dns.SOA).Mbox = rc.SoaMbox
+		rr.(*dns.SOA).Serial = rc.SoaSerial
+		rr.(*dns.SOA).Refresh = rc.SoaRefresh
+		rr.(*dns.SOA).Retry = rc.SoaRetry
+		rr.(*dns.SOA).Expire = rc.SoaExpire
+		rr.(*dns.SOA).Minttl = rc.SoaMinttl
 	case dns.TypeSRV:
 		rr.(*dns.SRV).Priority = rc.SrvPriority
 		rr.(*dns.SRV).Weight = rc.SrvWeight
@@ -263,12 +287,53 @@ func (rc *RecordConfig) ToRR() dns.RR {
 	return rr
 }
 
-func atou32(s string) uint32 {
-	i64, err := strconv.ParseInt(s, 10, 32)
-	if err != nil {
-		panic(fmt.Sprintf("atou32 failed (%v) (err=%v", s, err))
+// RRtoRC converts a dns.RR into a RecordConfig. origin is the zone the
+// record belongs to. The RR is kept in Original. SOA records and types that
+// RecordConfig can not represent return an error.
+func RRtoRC(rr dns.RR, origin string) (*RecordConfig, error) {
+	header := rr.Header()
+	rc := &RecordConfig{
+		Type:     dns.TypeToString[header.Rrtype],
+		NameFQDN: strings.ToLower(strings.TrimSuffix(header.Name, ".")),
+		TTL:      header.Ttl,
+		Original: rr,
+	}
+	rc.Name = strings.ToLower(dnsutil.TrimDomainName(header.Name, origin))
+	switch v := rr.(type) { // #rtype_variations
+	case *dns.A:
+		rc.Target = v.A.String()
+	case *dns.AAAA:
+		rc.Target = v.AAAA.String()
+	case *dns.CAA:
+		rc.CaaTag = v.Tag
+		rc.CaaFlag = v.Flag
+		rc.Target = v.Value
+	case *dns.CNAME:
+		rc.Target = v.Target
+	case *dns.MX:
+		rc.Target = v.Mx
+		rc.MxPreference = v.Preference
+	case *dns.NS:
+		rc.Target = v.Ns
+	case *dns.PTR:
+		rc.Target = v.Ptr
+	case *dns.SRV:
+		rc.Target = v.Target
+		rc.SrvPort = v.Port
+		rc.SrvWeight = v.Weight
+		rc.SrvPriority = v.Priority
+	case *dns.TLSA:
+		rc.TlsaUsage = v.Usage
+		rc.TlsaSelector = v.Selector
+		rc.TlsaMatchingType = v.MatchingType
+		rc.Target = v.Certificate
+	case *dns.TXT:
+		rc.Target = strings.Join(v.Txt, " ")
+		rc.TxtStrings = v.Txt
+	default:
+		return nil, fmt.Errorf("unsupported record type %s", rc.Type)
 	}
-	return uint32(i64)
+	return rc, nil
 }
 
 // Records is a list of *RecordConfig.
@@ -297,7 +362,7 @@ func Downcase(recs []*RecordConfig) {
 		switch r.Type {
 		case "ANAME", "CNAME", "MX", "NS", "PTR":
 			r.Target = strings.ToLower(r.Target)
-		case "A", "AAAA", "ALIAS", "CAA", "IMPORT_TRANSFORM", "SRV", "TLSA", "TXT", "SOA", "CF_REDIRECT", "CF_TEMP_REDIRECT":
+		case "A", "AAAA", "ALIAS", "CAA", "IMPORT_TRANSFORM", "SRV", "TLSA", "TXT", "SOA", "CF_PAGE_RULE", "CF_REDIRECT", "CF_TEMP_REDIRECT", "R53_HEALTH_CHECK":
 			// Do nothing.
 		default:
 			// TODO: we'd like to panic here, but custom record types complicate things.
@@ -344,8 +409,14 @@ func StringsToNameservers(nss []string) []*Nameserver {
 }
 
 // DomainConfig describes a DNS domain (tecnically a  DNS zone).
+//
+// The same zone may be declared more than once with different records
+// (split horizon) by tagging it: "example.com!internal". The tag is split off
+// into Tag by UpdateSplitHorizonNames, so providers only ever see the bare Name.
 type DomainConfig struct {
 	Name         string            `json:"name"` // NO trailing "."
+	Tag          string            `json:"tag,omitempty"`
+	UniqueName   string            `json:"-"` // Name!Tag, or Name if there is no tag.
 	Registrar    string            `json:"registrar"`
 	DNSProviders map[string]int    `json:"dnsProviders"`
 	Metadata     map[string]string `json:"meta,omitempty"`
@@ -354,6 +425,27 @@ type DomainConfig struct {
 	KeepUnknown  bool              `json:"keepunknown,omitempty"`
 }
 
+// UpdateSplitHorizonNames splits a tagged name such as "example.com!internal"
+// into Name and Tag, and sets UniqueName. It is safe to call more than once.
+func (dc *DomainConfig) UpdateSplitHorizonNames() {
+	if i := strings.Index(dc.Name, "!"); i != -1 {
+		dc.Name, dc.Tag = dc.Name[:i], dc.Name[i+1:]
+	}
+	dc.UniqueName = dc.Name
+	if dc.Tag != "" {
+		dc.UniqueName = dc.Name + "!" + dc.Tag
+	}
+}
+
+// GetUniqueName returns the name that identifies the domain in the configuration,
+// including the split horizon tag if any.
+func (dc *DomainConfig) GetUniqueName() string {
+	if dc.UniqueName != "" {
+		return dc.UniqueName
+	}
+	return dc.Name
+}
+
 // Copy returns a deep copy of the DomainConfig.
 func (dc *DomainConfig) Copy() (*DomainConfig, error) {
 	newDc := &DomainConfig{}
@@ -385,7 +477,7 @@ func (dc *DomainConfig) Punycode() error {
 			return err
 		}
 		switch rec.Type { // #rtype_variations
-		case "ALIAS", "MX", "NS", "CNAME", "PTR", "SRV", "URL", "URL301", "FRAME":
+		case "ALIAS", "MX", "NS", "CNAME", "PTR", "SOA", "SRV", "URL", "URL301", "FRAME":
 			rec.Target, err = idna.ToASCII(rec.Target)
 			if err != nil {
 				return err
@@ -571,4 +663,10 @@ func InterfaceToIP(i interface{}) (net.IP, error) {
 type Correction struct {
 	F   func() error `json:"-"`
 	Msg string
+	// Undo reverts F after it succeeded, or is nil if the provider can't.
+	// When a correction fails, the ones applied before it are undone.
+	Undo func() error `json:"-"`
+	// Change is the provider's own description of the correction, for
+	// providers that apply corrections in batches (see providers.CorrectionBatcher).
+	Change interface{} `json:"-"`
 }
@@ -88,3 +88,24 @@ func TestDowncase(t *testing.T) {
 		t.Errorf("%v: target1 expected (%v) got (%v)\n", dc.Records, "targetmx", dc.Records[1].Target)
 	}
 }
+
+func TestUpdateSplitHorizonNames(t *testing.T) {
+	tests := []struct {
+		name, wantName, wantTag, wantUnique string
+	}{
+		{"example.com", "example.com", "", "example.com"},
+		{"example.com!internal", "example.com", "internal", "example.com!internal"},
+	}
+	for _, tst := range tests {
+		dc := &DomainConfig{Name: tst.name}
+		dc.UpdateSplitHorizonNames()
+		dc.UpdateSplitHorizonNames() // must be idempotent
+		if dc.Name != tst.wantName || dc.Tag != tst.wantTag || dc.UniqueName != tst.wantUnique {
+			t.Errorf("%s: got name=%q tag=%q unique=%q", tst.name, dc.Name, dc.Tag, dc.UniqueName)
+		}
+		cfg := &DNSConfig{Domains: []*DomainConfig{dc}}
+		if cfg.FindDomain(tst.name) != dc {
+			t.Errorf("FindDomain(%q) did not find the domain", tst.name)
+		}
+	}
+}
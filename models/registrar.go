@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+)
+
+// RegistrarStatus describes the registration of a domain as reported by its registrar.
+// Expiry and Locked are nil when the registrar doesn't report them.
+type RegistrarStatus struct {
+	Domain      string     `json:"domain"`
+	Registrar   string     `json:"registrar"`
+	Expiry      *time.Time `json:"expiry"`
+	Locked      *bool      `json:"locked"`
+	Nameservers []string   `json:"nameservers"`
+}
+
+// DaysUntilExpiry returns the number of whole days between now and the expiry date.
+// A negative value means the domain has already expired. ok is false if the expiry
+// date is unknown.
+func (s *RegistrarStatus) DaysUntilExpiry(now time.Time) (days int, ok bool) {
+	if s.Expiry == nil {
+		return 0, false
+	}
+	return int(s.Expiry.Sub(now).Hours() / 24), true
+}
+
+// SetExpiry sets the expiry date.
+func (s *RegistrarStatus) SetExpiry(t time.Time) {
+	s.Expiry = &t
+}
+
+// SetLocked sets the lock state.
+func (s *RegistrarStatus) SetLocked(locked bool) {
+	s.Locked = &locked
+}
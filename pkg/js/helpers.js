@@ -80,12 +80,80 @@ function D(name, registrar) {
         processDargs(m, domain);
     }
     if (conf.domain_names.indexOf(name) !== -1) {
-        throw name + ' is declared more than once';
+        throw name +
+            ' is declared more than once. Use D_EXTEND() to add to an existing domain.';
     }
     conf.domains.push(domain);
     conf.domain_names.push(name);
 }
 
+// D_EXTEND(name): Add records and modifiers to a domain already declared with D().
+// If name is a subdomain of a declared domain, record names are made relative to
+// that subdomain, so D_EXTEND("team.example.com", A("www", ...)) creates www.team.
+// Split horizon domains are extended by tag: D_EXTEND("team.example.com!internal").
+function D_EXTEND(name) {
+    var found = _getDomainObject(name);
+    if (found == null) {
+        throw name +
+            ' was not declared yet and therefore cannot be extended. Use D() first.';
+    }
+    var domain = found.domain;
+    if (found.subdomain) {
+        domain.subdomain = found.subdomain;
+    }
+    try {
+        for (var i = 1; i < arguments.length; i++) {
+            processDargs(arguments[i], domain);
+        }
+    } finally {
+        delete domain.subdomain;
+    }
+}
+
+// _splitTag("example.com!tag") returns { name: "example.com", tag: "tag" }.
+function _splitTag(name) {
+    var i = name.indexOf('!');
+    if (i === -1) {
+        return { name: name, tag: '' };
+    }
+    return { name: name.substr(0, i), tag: name.substr(i + 1) };
+}
+
+// _getDomainObject(name): Returns the declared domain (with the same tag) that
+// is name, or the longest declared domain that name is a subdomain of, along
+// with the subdomain part of name. Returns null if none.
+function _getDomainObject(name) {
+    var want = _splitTag(name);
+    var found = null;
+    var foundName = '';
+    for (var i = 0; i < conf.domains.length; i++) {
+        var have = _splitTag(conf.domains[i].name);
+        if (have.tag !== want.tag) {
+            continue;
+        }
+        var suffix = '.' + have.name;
+        if (
+            want.name === have.name ||
+            want.name.substr(-suffix.length) === suffix
+        ) {
+            if (found == null || have.name.length > foundName.length) {
+                found = conf.domains[i];
+                foundName = have.name;
+            }
+        }
+    }
+    if (found == null) {
+        return null;
+    }
+    return {
+        domain: found,
+        subdomain: want.name.substr(
+            0,
+            Math.max(0, want.name.length - foundName.length - 1)
+        ),
+    };
+}
+
 // DEFAULTS provides a set of default arguments to apply to all future domains.
 // Each call to DEFAULTS will clear any previous values set.
 function DEFAULTS() {
@@ -184,6 +252,29 @@ var CNAME = recordBuilder('CNAME');
 // PTR(name,target, recordModifiers...)
 var PTR = recordBuilder('PTR');
 
+// SOA(name,ns,mbox,refresh,retry,expire,minttl, recordModifiers...)
+// The serial number is managed by the provider.
+var SOA = recordBuilder('SOA', {
+    args: [
+        ['name', _.isString],
+        ['target', _.isString],
+        ['mbox', _.isString],
+        ['refresh', _.isNumber],
+        ['retry', _.isNumber],
+        ['expire', _.isNumber],
+        ['minttl', _.isNumber],
+    ],
+    transform: function(record, args, modifiers) {
+        record.name = args.name;
+        record.target = args.target;
+        record.soambox = args.mbox;
+        record.soarefresh = args.refresh;
+        record.soaretry = args.retry;
+        record.soaexpire = args.expire;
+        record.soaminttl = args.minttl;
+    },
+});
+
 // SRV(name,priority,weight,port,target, recordModifiers...)
 var SRV = recordBuilder('SRV', {
     args: [
@@ -346,6 +437,16 @@ function getModifiers(args, start) {
     return mods;
 }
 
+// Record types whose name is not adjusted by D_EXTEND() of a subdomain.
+var _subdomainExemptTypes = [
+    'CF_PAGE_RULE',
+    'CF_REDIRECT',
+    'CF_TEMP_REDIRECT',
+    'IMPORT_TRANSFORM',
+    'PTR',
+    'R53_HEALTH_CHECK',
+];
+
 /**
  * Record type builder
  * @param {string} type Record type
@@ -442,6 +543,16 @@ function recordBuilder(type, opts) {
             opts.applyModifier(record, modifiers);
             opts.transform(record, parsedArgs, modifiers);
 
+            // Within D_EXTEND() of a subdomain, names are relative to the subdomain.
+            // Names ending in "." are left as they are, for validation to report.
+            if (d.subdomain && _subdomainExemptTypes.indexOf(type) === -1) {
+                if (record.name === '@') {
+                    record.name = d.subdomain;
+                } else if (record.name.charAt(record.name.length - 1) !== '.') {
+                    record.name += '.' + d.subdomain;
+                }
+            }
+
             d.records.push(record);
             return record;
         };
@@ -528,6 +639,27 @@ var CF_PROXY_DEFAULT_OFF = { cloudflare_proxy_default: 'off' };
 // Proxy default on for entire domain:
 var CF_PROXY_DEFAULT_ON = { cloudflare_proxy_default: 'on' };
 
+// CF_SETTING(name, value): Set a Cloudflare zone setting, for example
+// CF_SETTING('min_tls_version', '1.2'). true and false are 'on' and 'off',
+// objects only set the fields they have.
+function CF_SETTING(name, value) {
+    if (!_.isString(name)) {
+        throw 'CF_SETTING: the name of the setting must be a string';
+    }
+    if (_.isBoolean(value)) {
+        value = value ? 'on' : 'off';
+    } else if (_.isNumber(value)) {
+        value = String(value);
+    } else if (_.isObject(value)) {
+        value = JSON.stringify(value);
+    } else if (!_.isString(value)) {
+        throw 'CF_SETTING: unsupported value for ' + name;
+    }
+    var m = {};
+    m['cloudflare_setting_' + name] = value;
+    return m;
+}
+
 // CUSTOM, PROVIDER SPECIFIC RECORD TYPES
 
 function _validateCloudFlareRedirect(value) {
@@ -537,6 +669,23 @@ function _validateCloudFlareRedirect(value) {
     return value.indexOf(',') === -1;
 }
 
+// CF_PAGE_RULE(pattern, actions, priority, recordModifiers...)
+// actions is an object of page rule action ids to values, for example
+// {cache_level: 'bypass', always_use_https: true}.
+var CF_PAGE_RULE = recordBuilder('CF_PAGE_RULE', {
+    args: [
+        ['pattern', _.isString],
+        ['actions', _.isObject],
+        ['priority', _.isNumber],
+    ],
+    transform: function(record, args, modifiers) {
+        record.name = '@';
+        record.target = args.pattern;
+        record.meta.cloudflare_page_rule_actions = JSON.stringify(args.actions);
+        record.meta.cloudflare_page_rule_priority = String(args.priority);
+    },
+});
+
 var CF_REDIRECT = recordBuilder('CF_REDIRECT', {
     args: [
         ['source', _validateCloudFlareRedirect],
@@ -559,6 +708,36 @@ var CF_TEMP_REDIRECT = recordBuilder('CF_TEMP_REDIRECT', {
     },
 });
 
+// R53_HEALTH_CHECK(name, options, recordModifiers...)
+// options has type ('HTTP', 'HTTPS' or 'TCP'), fqdn and/or ip_address, and
+// optionally port, path, search_string, interval and threshold.
+// Records use the check with the R53_HEALTH_CHECK_NAME modifier.
+var R53_HEALTH_CHECK = recordBuilder('R53_HEALTH_CHECK', {
+    args: [['name', _.isString], ['options', _.isObject]],
+    transform: function(record, args, modifiers) {
+        record.name = '@';
+        record.target = args.name;
+        record.meta.r53_health_check = JSON.stringify(args.options);
+    },
+});
+
+// R53_HEALTH_CHECK_NAME(name) makes a record use a health check.
+function R53_HEALTH_CHECK_NAME(name) {
+    return { r53_health_check: name };
+}
+
+// R53_FAILOVER(setIdentifier, role) puts a record in the failover record set
+// setIdentifier. role is 'PRIMARY' or 'SECONDARY'.
+function R53_FAILOVER(setIdentifier, role) {
+    return { r53_set_identifier: setIdentifier, r53_failover: role };
+}
+
+// R53_WEIGHT(setIdentifier, weight) puts a record in the weighted record set
+// setIdentifier, answered in proportion to weight (0 to 255).
+function R53_WEIGHT(setIdentifier, weight) {
+    return { r53_set_identifier: setIdentifier, r53_weight: String(weight) };
+}
+
 var URL = recordBuilder('URL');
 var URL301 = recordBuilder('URL301');
 var FRAME = recordBuilder('FRAME');
@@ -569,6 +748,8 @@ var FRAME = recordBuilder('FRAME');
 // raw: Where (which label) to store an unaltered version of the SPF settings.
 // split: The template for additional records to be created (default: '_spf%d')
 // flatten: A list of domains to be flattened.
+// optimize: Flatten the includes needed to use at most 10 lookups (or the
+//   given number), and aggregate the ip ranges.
 
 function SPF_BUILDER(value) {
     if (!value.parts || value.parts.length < 2) {
@@ -588,6 +769,11 @@ function SPF_BUILDER(value) {
     // If flattening is requested, generate a TXT record with the raw SPF settings.
     if (value.flatten && value.flatten.length > 0) {
         p.flatten = value.flatten.join(',');
+    }
+    if (value.optimize) {
+        p.optimize = value.optimize === true ? '10' : String(value.optimize);
+    }
+    if (p.flatten || p.optimize) {
         r.push(TXT(value.raw, rawspf));
     }
 
@@ -602,6 +788,141 @@ function SPF_BUILDER(value) {
     return r;
 }
 
+// _emailAuthLabel returns the label of an email authentication record:
+// prefix at the label (default: '@') of the domain.
+function _emailAuthLabel(prefix, label) {
+    if (!label || label === '@') {
+        return prefix;
+    }
+    return prefix + '.' + label;
+}
+
+// _emailAuthRecord returns a TXT record with the tags, with the TTL if given.
+function _emailAuthRecord(name, tags, ttl) {
+    if (ttl) {
+        return TXT(name, tags.join('; '), TTL(ttl));
+    }
+    return TXT(name, tags.join('; '));
+}
+
+// _reportURIs returns the report URIs of list (a string or an array),
+// adding 'mailto:' to bare email addresses.
+function _reportURIs(list) {
+    if (_.isString(list)) {
+        list = [list];
+    }
+    return _.map(list, function(uri) {
+        if (uri.indexOf(':') === -1 && uri.indexOf('@') !== -1) {
+            return 'mailto:' + uri;
+        }
+        return uri;
+    }).join(',');
+}
+
+// DMARC_BUILDER takes an object:
+// label: The DNS label of the domain. The record is at _dmarc.label. (default: '@')
+// policy: 'none', 'quarantine' or 'reject'.
+// subdomainPolicy: The policy of the subdomains. (default: policy)
+// rua: The addresses that receive aggregate reports.
+// ruf: The addresses that receive failure reports.
+// pct: The percentage of the mail the policy applies to. (default: 100)
+// alignment: 'strict' or 'relaxed', for both SPF and DKIM. (default: 'relaxed')
+// alignmentSPF, alignmentDKIM: The alignment of SPF or DKIM only.
+// failureOptions: When failure reports are sent: '0', '1', 'd' and 's', in
+//   a string such as '1:d' or an array.
+// reportInterval: The number of seconds (or a duration such as '1d') between
+//   aggregate reports. (default: 86400)
+// ttl: The TTL of the record.
+
+function DMARC_BUILDER(value) {
+    if (!value.policy) {
+        throw 'DMARC_BUILDER requires a policy';
+    }
+    var alignments = { strict: 's', relaxed: 'r', s: 's', r: 'r' };
+    function alignment(a, name) {
+        if (!alignments[a]) {
+            throw 'DMARC_BUILDER ' + name + " must be 'strict' or 'relaxed'";
+        }
+        return alignments[a];
+    }
+
+    var tags = ['v=DMARC1', 'p=' + value.policy];
+    if (value.subdomainPolicy) {
+        tags.push('sp=' + value.subdomainPolicy);
+    }
+    var aspf = value.alignmentSPF || value.alignment;
+    var adkim = value.alignmentDKIM || value.alignment;
+    if (adkim) {
+        tags.push('adkim=' + alignment(adkim, 'alignmentDKIM'));
+    }
+    if (aspf) {
+        tags.push('aspf=' + alignment(aspf, 'alignmentSPF'));
+    }
+    if (value.pct !== undefined) {
+        tags.push('pct=' + value.pct);
+    }
+    if (value.rua) {
+        tags.push('rua=' + _reportURIs(value.rua));
+    }
+    if (value.ruf) {
+        tags.push('ruf=' + _reportURIs(value.ruf));
+    }
+    if (value.failureOptions) {
+        var fo = value.failureOptions;
+        tags.push('fo=' + (_.isArray(fo) ? fo.join(':') : fo));
+    }
+    if (value.reportInterval) {
+        var ri = value.reportInterval;
+        tags.push('ri=' + (_.isString(ri) ? stringToDuration(ri) : ri));
+    }
+    return _emailAuthRecord(_emailAuthLabel('_dmarc', value.label), tags, value.ttl);
+}
+
+// MTA_STS takes an object:
+// label: The DNS label of the domain. The record is at _mta-sts.label. (default: '@')
+// id: The id of the policy served at https://mta-sts.<domain>/.well-known/mta-sts.txt.
+//   It must change when the policy changes.
+// ttl: The TTL of the record.
+
+function MTA_STS(value) {
+    if (!value.id) {
+        throw 'MTA_STS requires an id';
+    }
+    return _emailAuthRecord(_emailAuthLabel('_mta-sts', value.label), ['v=STSv1', 'id=' + value.id], value.ttl);
+}
+
+// TLSRPT takes an object:
+// label: The DNS label of the domain. The record is at _smtp._tls.label. (default: '@')
+// rua: The addresses (mailto: or https:) that receive the reports.
+// ttl: The TTL of the record.
+
+function TLSRPT(value) {
+    if (!value.rua || value.rua.length === 0) {
+        throw 'TLSRPT requires rua';
+    }
+    return _emailAuthRecord(
+        _emailAuthLabel('_smtp._tls', value.label),
+        ['v=TLSRPTv1', 'rua=' + _reportURIs(value.rua)],
+        value.ttl
+    );
+}
+
+// BIMI takes an object:
+// label: The DNS label of the domain. The record is at selector._bimi.label. (default: '@')
+// selector: The BIMI selector. (default: 'default')
+// location: The https URL of the SVG logo.
+// authority: The https URL of the Verified Mark Certificate.
+// ttl: The TTL of the record.
+
+function BIMI(value) {
+    var selector = value.selector || 'default';
+    var tags = ['v=BIMI1', 'l=' + (value.location || '')];
+    if (value.authority) {
+        tags.push('a=' + value.authority);
+    }
+    return _emailAuthRecord(_emailAuthLabel(selector + '._bimi', value.label), tags, value.ttl);
+}
+
 // Split a DKIM string if it is >254 bytes.
 function DKIM(arr) {
     chunkSize = 255;
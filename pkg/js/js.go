@@ -4,6 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/StackExchange/dnscontrol/models"
 	"github.com/StackExchange/dnscontrol/pkg/transform"
@@ -15,10 +19,19 @@ import (
 )
 
 // ExecuteJavascript accepts a javascript string and runs it, returning the resulting dnsConfig.
+// Relative paths passed to require() are resolved against the working directory.
 func ExecuteJavascript(script string, devMode bool) (*models.DNSConfig, error) {
+	return ExecuteJavascriptInDir(script, "", devMode)
+}
+
+// ExecuteJavascriptInDir is like ExecuteJavascript, but treats the script as if it was read from a file in dir.
+// Relative paths passed to require() and require_glob() are resolved against the directory of the requiring file.
+func ExecuteJavascriptInDir(script string, dir string, devMode bool) (*models.DNSConfig, error) {
 	vm := otto.New()
+	l := &loader{dir: dir}
 
-	vm.Set("require", require)
+	vm.Set("require", l.require)
+	vm.Set("require_glob", l.requireGlob)
 	vm.Set("REV", reverse)
 
 	helperJs := GetHelpers(devMode)
@@ -53,21 +66,113 @@ func GetHelpers(devMode bool) string {
 	return _escFSMustString(devMode, "/helpers.js")
 }
 
-func require(call otto.FunctionCall) otto.Value {
+// loader implements require() and require_glob(). It tracks the directory of the file currently
+// being executed, so that relative paths are resolved against the requiring file.
+type loader struct {
+	dir string
+}
+
+func (l *loader) resolve(file string) string {
+	if filepath.IsAbs(file) {
+		return file
+	}
+	return filepath.Join(l.dir, file)
+}
+
+func (l *loader) require(call otto.FunctionCall) otto.Value {
 	if len(call.ArgumentList) != 1 {
 		throw(call.Otto, "require takes exactly one argument")
 	}
-	file := call.Argument(0).String()
+	return l.load(call.Otto, l.resolve(call.Argument(0).String()))
+}
+
+// load runs a javascript file, or returns the parsed contents of a .json file.
+func (l *loader) load(vm *otto.Otto, file string) otto.Value {
 	fmt.Printf("requiring: %s\n", file)
 	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		throw(vm, err.Error())
+	}
+	if strings.ToLower(filepath.Ext(file)) == ".json" {
+		value, err := vm.Call("JSON.parse", nil, string(data))
+		if err != nil {
+			throw(vm, fmt.Sprintf("parsing %s: %s", file, err))
+		}
+		return value
+	}
+	prev := l.dir
+	l.dir = filepath.Dir(file)
+	defer func() { l.dir = prev }()
+	if _, err = vm.Run(string(data)); err != nil {
+		throw(vm, err.Error())
+	}
+	return otto.TrueValue()
+}
+
+// requireGlob requires every .js and .json file in a directory, in sorted order.
+// If the optional second argument is true, subdirectories are included too.
+// It returns an object with the value of each file, by path relative to the directory.
+func (l *loader) requireGlob(call otto.FunctionCall) otto.Value {
+	if len(call.ArgumentList) < 1 || len(call.ArgumentList) > 2 {
+		throw(call.Otto, "require_glob takes one or two arguments")
+	}
+	dir := l.resolve(call.Argument(0).String())
+	recursive := false
+	if len(call.ArgumentList) == 2 {
+		var err error
+		if recursive, err = call.Argument(1).ToBoolean(); err != nil {
+			throw(call.Otto, err.Error())
+		}
+	}
+	files, err := listFiles(dir, recursive, ".js", ".json")
 	if err != nil {
 		throw(call.Otto, err.Error())
 	}
-	_, err = call.Otto.Run(string(data))
+	values, err := call.Otto.Object("({})")
 	if err != nil {
 		throw(call.Otto, err.Error())
 	}
-	return otto.TrueValue()
+	for _, f := range files {
+		v := l.load(call.Otto, f)
+		rel, err := filepath.Rel(dir, f)
+		if err != nil {
+			rel = f
+		}
+		if err := values.Set(filepath.ToSlash(rel), v); err != nil {
+			throw(call.Otto, err.Error())
+		}
+	}
+	return values.Value()
+}
+
+// listFiles returns the sorted paths of all files in dir with one of the given extensions.
+// Other files are skipped.
+func listFiles(dir string, recursive bool, exts ...string) ([]string, error) {
+	files := []string{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path != dir && !recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		ext := strings.ToLower(filepath.Ext(path))
+		for _, e := range exts {
+			if ext == e {
+				files = append(files, path)
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
 }
 
 func throw(vm *otto.Otto, str string) {
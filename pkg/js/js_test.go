@@ -78,6 +78,10 @@ func TestErrors(t *testing.T) {
 		{"CF_TEMP_REDIRECT With comma", `D("foo.com","reg",CF_TEMP_REDIRECT("foo.com","baa,a"))`},
 		{"Bad cidr", `D(reverse("foo.com"), "reg")`},
 		{"Dup domains", `D("example.org", "reg"); D("example.org", "reg")`},
+		{"D_EXTEND undeclared", `D("foo.com", "reg"); D_EXTEND("foo.net", A("@", "1.2.3.4"))`},
+		{"D_EXTEND parent", `D("sub.foo.com", "reg"); D_EXTEND("foo.com", A("@", "1.2.3.4"))`},
+		{"require missing file", `require("pkg/js/parse_tests/require/missing.js")`},
+		{"require_glob missing dir", `require_glob("pkg/js/parse_tests/missing/")`},
 	}
 	for _, tst := range tests {
 		t.Run(tst.desc, func(t *testing.T) {
@@ -0,0 +1,5 @@
+var values = require("pkg/js/parse_tests/require/values.json");
+D("foo.com", "none",
+    A("@", values.web),
+    A("mail", values.mail)
+);
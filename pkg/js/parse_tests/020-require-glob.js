@@ -0,0 +1 @@
+require_glob("pkg/js/parse_tests/require/", true);
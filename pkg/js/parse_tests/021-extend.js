@@ -0,0 +1,21 @@
+var REG = NewRegistrar("Third-Party", "NONE");
+var CF = NewDnsProvider("Cloudflare", "CLOUDFLAREAPI");
+D("foo.com", REG, DnsProvider(CF),
+    A("@", "10.1.1.1")
+);
+D("bar.foo.com", REG, DnsProvider(CF),
+    A("@", "10.2.2.2")
+);
+D_EXTEND("foo.com",
+    A("www", "10.1.1.2"),
+    {extended: "true"}
+);
+D_EXTEND("team.foo.com",
+    A("@", "10.1.1.3"),
+    A("www", "10.1.1.4"),
+    CNAME("mail", "mail.foo.com."),
+    A("host.foo.com.", "10.1.1.5")
+);
+D_EXTEND("sub.bar.foo.com",
+    A("@", "10.2.2.3")
+);
@@ -0,0 +1,12 @@
+var REG = NewRegistrar("Third-Party", "NONE");
+var CF = NewDnsProvider("Cloudflare", "CLOUDFLAREAPI");
+var BIND = NewDnsProvider("bind", "BIND");
+D("foo.com", REG, DnsProvider(CF),
+    A("@", "1.1.1.1")
+);
+D("foo.com!internal", REG, DnsProvider(BIND),
+    A("@", "10.1.1.1")
+);
+D_EXTEND("www.foo.com!internal",
+    A("@", "10.1.1.2")
+);
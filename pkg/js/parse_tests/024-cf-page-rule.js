@@ -0,0 +1,4 @@
+D("foo.com","none",
+    CF_PAGE_RULE("foo.com/static/*", {cache_level: "cache_everything", edge_cache_ttl: 7200}, 2),
+    CF_PAGE_RULE("http://*foo.com/*", {always_use_https: true}, 3)
+);
@@ -0,0 +1,6 @@
+D("foo.com","none",
+    CF_SETTING("min_tls_version", "1.2"),
+    CF_SETTING("always_use_https", true),
+    CF_SETTING("browser_cache_ttl", 14400),
+    CF_SETTING("security_header", {strict_transport_security: {enabled: true, max_age: 31536000}})
+);
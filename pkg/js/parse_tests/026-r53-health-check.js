@@ -0,0 +1,6 @@
+D("foo.com","none",
+    R53_HEALTH_CHECK("web", {type: "HTTPS", fqdn: "www.foo.com", path: "/health", interval: 10}),
+    A("www","1.2.3.4", R53_HEALTH_CHECK_NAME("web"), R53_FAILOVER("primary", "PRIMARY")),
+    A("www","1.2.3.5", R53_FAILOVER("secondary", "SECONDARY")),
+    A("api","1.2.3.6", R53_WEIGHT("a", 10))
+);
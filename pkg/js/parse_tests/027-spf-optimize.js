@@ -0,0 +1,10 @@
+D("foo.com","none",
+    SPF_BUILDER({
+        parts: [
+            "v=spf1",
+            "include:_spf.google.com",
+            "~all"
+        ],
+        optimize: 8
+    })
+);
@@ -0,0 +1,31 @@
+D("foo.com","none",
+    DMARC_BUILDER({
+        policy: "reject",
+        subdomainPolicy: "quarantine",
+        alignment: "strict",
+        pct: 50,
+        rua: ["dmarc@foo.com", "mailto:reports@example.net"],
+        ruf: "forensic@foo.com",
+        failureOptions: ["1", "d"],
+        reportInterval: "1h"
+    }),
+    DMARC_BUILDER({
+        label: "mail",
+        policy: "none",
+        ttl: "5m"
+    }),
+    MTA_STS({
+        id: "20180101T000000"
+    }),
+    TLSRPT({
+        rua: ["tlsrpt@foo.com", "https://reports.example.net/tlsrpt"]
+    }),
+    BIMI({
+        location: "https://foo.com/logo.svg",
+        authority: "https://foo.com/vmc.pem"
+    }),
+    BIMI({
+        selector: "news",
+        label: "mail"
+    })
+);
@@ -0,0 +1,6 @@
+var loaded = require_glob("pkg/js/parse_tests/require_glob/", true);
+var defaults = loaded["defaults.json"];
+D("e.com", "none",
+    A("@", defaults.web, TTL(defaults.ttl)),
+    A("mail", loaded["zones/hosts.json"].mail)
+);
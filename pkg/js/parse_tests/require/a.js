@@ -0,0 +1,4 @@
+var values = require("./values.json");
+D("a.com", "none",
+    A("mail", values.mail)
+);
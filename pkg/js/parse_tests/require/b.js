@@ -0,0 +1,4 @@
+var values = require("values.json");
+D("b.com", "none",
+    A("@", values.web)
+);
@@ -0,0 +1,4 @@
+require("../shared.js");
+D("c.com", "none",
+    A("@", SHARED_IP)
+);
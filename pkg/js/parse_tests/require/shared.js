@@ -0,0 +1 @@
+var SHARED_IP = "9.9.9.9";
@@ -0,0 +1,3 @@
+D("d.com", "none",
+    A("@", "10.0.0.4")
+);
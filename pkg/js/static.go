@@ -192,89 +192,139 @@ var _escData = map[string]*_escFile{
 
 	"/helpers.js": {
 		local:   "pkg/js/helpers.js",
-		size:    17434,
+		size:    28715,
 		modtime: 0,
 		compressed: `
-H4sIAAAAAAAC/+w7a3PjNpLf/Ss6U7ehOObQj8nMbslRLoofOVf8KlmTnS2dzgWTkIQxXweA0ngTz2+/
-wosE+JCd1CX7Zf1hRgS7G43uRqO70fRKhoFxSiLuHe3srBGFKM8WMIJfdgAAKF4SximibAizeSDH4ozd
-FTRfkxg7w3mKSNYauMtQivXok54ixgtUJnxMlwxGMJsf7ewsyiziJM+AZIQTlJB/4oGvmXA46uNqC2ed
-3D0dKSZbrDxZzFzhzcTMNRALCYA/FjiAFHNk2CMLGIhR3+JQPMNoBN7l+OrD+MJTkz3Jf4UEKF6KFYGg
-OYSa8tCiP5T/GkaFEMJ64WFRstWA4qV/pBXFS5pJSq0lnGTsRkvl2UXkCzXrSDCf33/CEffg66/BI8Vd
-lGdrTBnJM+YByRx88SeeQxcORrDIaYr4HeeDjvd+UzAxK36PYBzNK9nErHhONhnenEi70GKpxOtX5i8x
-6yVabLWtcVj/DByhDOGXJxs+ymncNt2b2nJtcG2h0+nFEPYDhxOG6dqx9Cd3fQXNI8zYCaJLNkgDvQnM
-4vb2hG4Ao2gFaR6TBcE0EIZAOBAGKAzDCk5THEKEkkQAbAhfaXoGCFGKHodmUrHMkjKyxsmjgVD2JNRH
-l1hOk/FcSihGHFV2eBcSdqZnHKS+Y2IDvQZtN4AThiukseCggSGWOBCW9UmarP1K/Lkimn2aV1I6quCe
-uua6lmtpTHYX4s8cZ7HmMhRLCyB1ubW8xIrmG/D+Pp5cnV/9ONQzV8pQXqTMWFkUOeU4HoIHuw77Zss2
-hj1Qdt1G0IypvaAW97Szs7cHJ2oP1FtgCMcUI44BwcnVrSYYwgeGga8wFIiiFHNMGSBmbBpQFgv2WVgb
-4Unf5pLbXa14tGUrKjYrNRIYwf4REPjW9t1hgrMlXx0B2d21FeKo14Kfkaain9rTHKppEF2WKc547yQC
-PoVRDTgj86NuFtLOWYVNKTdmHZkhyWL8+XohBeLDV6MRvDnwW9Yj3sIueGLLxjhKEMVCBVRoCWWQZxF2
-Th9rHuMobYbabEgYycORMZXTs/GHi+ktaI/LAAHDHPKFUUktCuA5oKJIHuWPJIFFyUuKzXkcCnqnwgNJ
-x8LzmviGJAlECUYUUPYIBcVrkpcM1igpMRMT2kamsaqYoX2u91nRs+q1zUwKw9az7+6i6fRisPaHcIu5
-3CXT6YWcVO0htUssthW4dQQLz3LLKcmWg7XjWdYwknFatpzmJyVF0jeuHSvSh5UhPqA2Pg05T2AE66Ou
-g6KDsrVJU8SjFRZyXIfy92Dvfwb/He/6gxlLV/Eme5z/p/8fe5oZsYwKYwRZmSRtq10bk81yDkjolMQQ
-69k1O47ZlhnhMAKPea1ZZodzewINWb90QgwYCc/F8HnGK/wDo0Wx2FKGH2wIBwGkQ3i/H8BqCG/f7++b
-gKOcebE3hxGU4Qpew+E31fBGD8fwGv5ajWbW6Nv9avjRHn7/TnMAr0dQzsQa5k7wsq42XxUOOIZmNp4x
-ODmmXLa1S2zcP8jqYmfrhHX00mt8KXrAx+PxWYKWA7m5G9FXbdBy+zhWrTZUhNAiQUv4daS8gz3N3h4c
-j8d3x5Pz6fnx+EKcaoSTCCViGASaTElsGGk9NU8H8O23sO8fKfFbsfQrE3FeoRS/CmDfFxAZO87LTHrD
-fUgxyhjEeeZxEKlWTvXJhpVXs6K40EYW28JQ10QEOkoSW52tuF6jdwT1hrCM68ssxguS4dizhVmBwJuD
-36JhK3KdCTaEWWtaDUWMFZukCLTmLnWkw8Iw9KUexjDS734oSSJW5o09LfvxePwSCuNxF5HxuKZzcT6+
-VYQ4okvMtxAToB3UxLAhd2y44mgZSPvrp3fcxdvxeOwFdVA+vT65HvCEpP4QzjmwVV4mMdxjQBlgSnMq
-9CrnMQ50X9jVweHfVLwuAo0hzGaeYMoLoN7d8wBmHkfL9qAk5w7rlIJTlDGRww2bGzGQMwVVuMo6dqaM
-TmRkxKyY0926HC0NCEfLFoRSkYGw97di0Ex/Vab3mHZw6fiUttdgTbcR7DwZzV6NL09fZigStEO1YtgY
-ys108jJiN9NJm9TNdGII3U5+VoQKSnJK+GOwwWS54oFIE56lfjv5uU39dvJzZYPagCp5dVqS9dZwoSGU
-IhwIxV7/e8F3/1u1oK75/xwbZXRtlmjgzHMXrFqsgVRPnTRzWkGJ389Yvnpq2ahy/CVDSxwAwwmOeE4D
-Ff6QbKmqJhGmnCxIhDiWJjC9uO3wQ2L0dxuB5KBfh4azfgib499oC8JrOmuBDGORjcIrBf+qivL/RLPh
-CUNSKgZKPnSCGekYSPPcCWwLyiDYY7/Djurqq5bpNVW1lM+NWMyKFD/78OuvUJddPlf54fTj9GV+bvpx
-2mGFH6dNI+w/yrQxNNj+o88u4YK5SrGxjo8Z8A2J8NCGATCiJ0yCLghlXCM0AT9zQ0gDkywmaxKXKDFT
-hC7O1fX0dAjnCwFNMSCKrbz/QCMFVRjJTCSRZ8kjoCjCjPUyEQBflQwIhzjHTESvKeIiaN2sEIeNWLWY
-imRmiQ3e/ivf4DWmAdw/SlCSLVsSUHwHsg6YCi4xg3sUPWwQjRucRXlaIE7uSSJ88GaFM0ktwdlAVh19
-kWkeyOrTgGQcZ0LVKEkefbinGD00yN3T/AFnlmQwosmjWI0SPMdLnYlyzLgl90ayZO0nv1lYfHbvNQFr
-AxjBzIK2SkmtAuIzE83258/P1cnYU/OYufzYiDie29uXH9tb+/LjHxhj/KujhPRzQfECU5xF+Nkw4Te4
-5GiFo4cxXbKB/MUMszFmkZ3ooboMCt8qLPPcrr8I5N66py6MOSRaVTEx5VcKZEbmcvYZmbe2QT2drPi8
-qQ5i8GAXiF0GinJKccRlTdtrmaI+W65emLhddWRtV1XKJqLy29PJz6dOQO5bt2YNANAQPZWJRkpsZ/Wy
-Yti4z5K0hvp/ePI7yyL1vVlluHcc3SfYur+ZCi5msyTfyHrViixXQzgMIMObHxDDQ3grzkn5+hvz+p18
-fX4zhPfzuSEkL2JeHcAXOIQv8Ba+HME38AXewReAL/D+VVUeS0iGn6uoNvjdVjYnBYya8E71XABJdmEE
-pAjlzyPHCOVQ0+zcGyEF0oSRNQ9N+i5MUaHgglqtpAvFvlEs08M45wPiH7XAnvzwU06ygRd4jbedXtxm
-xpBVbDeQd9q/tIyExispiYeWnMTgs5KSQD2y0lNU0hLP/1J5aYYsiUn2XyYz4ZlGMKu4KsIk3/gBWANi
-y/jVftI7xzJPuR30XXy+0SuAL+D5XUVSBa2BjsCrIubzy5vryfRuOhlf3Z5dTy7Vlk9kDKI2RXVvJL1b
-E77t65oQ7ZC6NYUnY2o1jfrNeeKet/+fJ6n3vffMsahYaR+0mCPNfu00ZDG9dpnqWG2u0G9PKC9FFDRP
-Wsn1zYfJj6cD61xQA5W7j8OfMC4+ZA9ZvskEAyhh2Cj16vquhV+N9ZLgtNQUXr/egdfwfYwLikXmHu/A
-672a1BLz6tgbKKkzjih3bm7yuNdZS+DqCqz3nJe3uebay7nxsgxbANlMT6R01f31vTJJuRZ5aQy/qODz
-Sb23YLtg8oKzUE49n+3PYWzCB2FFNryRy8hFOZjDdaGyAXXLhHhOt+FVdgWmBaG+wnRuNc1lHrw2opqi
-Bww9G8EHxKyrRhhnj/UmUXed99iiJSYkOIZ7vFA5HWHVXgutsnBacsRVIroka5zZbPWKRizG2E7HMmu+
-eC4pK5qu+bn+RpWZBHVjO+K3PCr0DRAb/PKkIALLul6W4Au/Uwewv8/56EBHQSqBr9AaW4tFCcUofjSi
-b2IK2kZRgDLdzCL3lNULoS9WurKu/gzCPoeVp92aWnY5THNm2XgvPEZfnKla56ilD8eaOnTSq42u0LEC
-7nNHTs9FHsOoRpFxYwuw3VCUx35fnJLmsbll7IhQuhuAtpDb2wPV68Zrq5WbSmffnUjyZjuPLUf09ddW
-mc151TuzXoxFxGnEc2gcdVJ46hytGpyss1iquF9e3Qzq1qfTyeR6MgRz/DmdT14HyX57VDGkNoBmftZM
-O2QLQKybQ355ctON2iPo3lRbM81uEfi2Pm46sm1Ds0K7IEzssQqntUQZWtcRNcfpM0G1AGkVepQ02sR1
-iA3NGFupQ57Huy0sz3hNiv+3JBSzVleZcfi2GDoJ1SfooIuGK6YOAn4I11nyCFuRtzGwwRQDK5WL95rV
-MSFQu/Kw4+zkJBEOv5pmZ5sja0qj05FpyzgRZwaRp6plGU4abKDVtW9fq5llpDVNI43v4KDLksSZWGZ1
-bCQIGPl0OtOvHOqzg7lu2vC37vQe02qZmLcFyJ14f76VXlVn0iuTJRVEkpbWt/kV2b9X+YpZkwGRc1g3
-x/02U7mUbpvpMJaXNKbZt9/9rWkNrraWrur2dKmMUYdKrWbs1rt2r3OFxZOh0w3kgjw1Du52mNoRThy1
-UapDrQKvteeiuk2xoe5fNV31HRGAlpt6Z0nWaXF5JmVDcayynUFsevDtiqDkkFnlPbKA+gIpk4FhAIix
-MsVACkGOYsbCKsgg+hqmEUt2hJGtuNEJGe3vFCLHCrq039UT75Y4rfF+OzC1cqfL3bUoLezuxvUYRyTG
-cI8YjkGkM4JVA/+mSnNMCztTLex1eiMSNPHk3BRL1OvOtnUB67SuS1jThXJ+Bpcfa8pKZVKPZp07VrDH
-OjvW3bj42ZMkVcFw95Gwpae+7q2nOOpOGrY2vf/uaFcuvjfOfUGUm/bFt1uj23Zka0e1jZ793wjWG/NG
-ecbyBIdJvhx0rqX+CuCyt/3fC7pPWP0RQPdbb3D7QIqCZMuvfK8F8Uyp9Gmn2z+6X9ZQHJmiFymg/ryn
-OmUYLGieworzYri3xziKHvI1posk34RRnu6hvb8d7L/76zf7eweHB+/f7wtKa4IMwie0RiyipOAhus9L
-LnESck8Rfdy7T0ih7S5c8bT2tuc3gzh3ymHiRItzHrIiIXzghSYK3tuDgmLOCaZvyDLLKbZXN5B/u/Fs
-f+7Dazh8996HXRADB3O/MXLYGnk79xsfHZladZna13dZmcrmzKo3062cSk48r/nVgHUZLeh14GRl2vrG
-Svl9+Ivgs6My+Fb4nO+k63nzxukQFTzCJeKrcJHkOZVM78nV1mbkUIdd8EIPdiHuqBrGVYNukpfxIkEU
-A0oIYpgN1aUz5vLLAi6vqgWPVlOEMUnVfnd2dzO5/viPu+uzM9nAHVUk7wqaf34cgpcvFh48HQlt34gh
-iAlD9wmOmySueilkLgGcdeGffbi46KOwKJPEobE7QSRZlllNS7zB9I35FsgWwXCn5l33e+eLhToMM06q
-zypgYLWE+0OXPf2pRK+k7jReLbGOWbP2pH3TXD07i5SqMoQPt9PrywBuJtc/n5+cTuD25vT4/Oz8GCan
-x9eTE5j+4+b01tpMdzq6x9KEzgT9CY4JFaeU0/cpMxe7z72Vs5jAWJXwW8YqEapvcrzA8+V2fXMgjVgv
-fXJ6cj45Pe7oarJebumBYHlJI1kH7V+X0/QQY8ZJJrObF2H9uRc4ajnCBwTCB6hLnZpj97pFi3B6enmz
-XY4OxL+F2SvMD5OLtvw+TC7Eqaffv90/6AR5u39goM4mnY3NcrjqR745u/vhw/mF2LEcPWBW18elyyoQ
-5WwIU/XZIGeQy6Y1gWdC5AHP4R7Dp1wcfSo098DzpTtM0D1OFPrJ1a16rD5yKShJEX20aIUwqJ3L9578
-KIOizRD+LvvkBpsViVaKiq/C05zKin6ZoYRjimMw8YvFp/HBkiMZQCiOOE6LBHGsPvOKY6Ivm8wXkWpd
-kfyUMrY5u2PF4i+xYm+RIM5xNoQxJISpL+nUB3IaXwOI86F2fpbYO5ydclhK3r/+CtZjXbo8bHcGebYy
-q4If4pBgxDgcAk6wrDC0YhE9oxasXXCthm1DbyFStGmjUbQRSHcUbVixqFCVZ1YFWtkIs8KV5CzJK9+t
-kuJClXoNtDhYrXsbYQdYHmwyrxOH6PTjtL5NE9NJFkzJR4tSX+Z7fkW4tiLXbEykeb4w2iTZUiSEQsiY
-cRwHsMQZpuqb23p2K1FFmwZRI0LFkqYrEilnoC4B7jsfx1YIowZ8RycGVbH/9ON0UGkm0DKpmx2sRZoA
-XyyRFTgSHjAOdJyjdpBYRHMNBs1lVIJXbBqY5qw/bhefq3Kt1OaypJ2ahQVQ+I07BWqC1lvJEoKTn84v
-TUdr9fH8d4fvvoH7R46dL6F/Or8cIFp9lxmtyuzhlvxT+P/Dd+/qbxAnvQ1WASRSXYhSp1aY4Ez82B3V
-ROvq/8TUBmnIEhLhAQlk510N6qZzE7HE/wsAAP//x1uJKhpEAAA=
+H4sIAAAAAAAC/+x9W3PbONLou39Fj+t8SzFmaDuZ5NuSR7ur8WXGZ30rWZnNlo+PChYhCROK1AKgZW/i
++e2nGhcS4EV2UrPzvRw/TESw0Wh0NxqNRjcnKAQFITmbyuBga+uecJjm2QwG8HkLAIDTOROSEy76cHMb
+qbYkE5MVz+9ZQr3mfElY1miYZGRJTeuTGSKhM1KkcsjnAgZwc3uwtTUrsqlkeQYsY5KRlP2b9kJDhEdR
+F1UbKGul7ulA/dMk5ckh5oKuR3asHk4kAvm4ohEsqSSWPDaDHraGDoX4DIMBBOfDiw/Ds0AP9qT+ixzg
+dI4zAsTZhwpz38HfV/+1hCIT4mri8aoQix6n8/DACEoWPFOYGlM4ysSV4cqzk8hnqhkGSHx+9yudygD+
+9CcI2GoyzbN7ygXLMxEAy7z++IfPsQ8HA5jlfEnkRMpey/uwzphErL6FMZ7kNW8SsXqONxldHym9MGwp
+2RvCZ7dnNUWHrKY29qufkceUPnx+cuGnOU+aqntVaa4LbjR0PD7rw17kUSIov/c0/cmf34rnUyrEEeFz
+0VtGZhHYye3uomyAkukClnnCZozyCNgMmAQmgMRxXMIZjH2YkjRFgDWTC4PPAhHOyWPfDorTLLhg9zR9
+tBBan1B8fE7VMJnMFYcSIkmph5OYiRMzYm8ZeirWM3MwegM0FbTsNEQKaj1wij3UrF+Vyrqv8M9n0c2v
+txF4I1TaWRvrUs2lNtgkpg+SZomhMsapRbD0qa3A5YLnawj+MRxdnF781Dcjl8LQVqTIRLFa5VzSpA8B
+7Hjk2yVbaw5A63WzgyFMrwU9uaetrd1dONJroFoCfTjklEgKBI4urg3CGD4ICnJBYUU4WVJJuQAirE4D
+yRIkX8SVEh51LS613PWMBxuW4sGWJ0YGA9g7AAY/uLY7Tmk2l4sDYDs7rkA88TrwN6wu6KfmMG/0MITP
+iyXNZOcgCL+EQQV4w24P2klYto6KOqXNmLNlxixL6MPlTDEkhO8GA3i9Hza0B982pM8EJHSaEk5RHBwl
+RjLIsynVAjyaHH8cH18c9UKQOZAkUf9kQB+YkCybWzXx9i2HQmti3ak0J6BgFPUHVsnsuKq1D8MkqauO
+0nyh6DFUAEk5JcljNSVlfI56YYw4T2eaB2iyQBR3plM+A1L10I2RGUx1EEA4hSVJKHCaEsnuKcgcMcoF
+kRWmCEReEb4tKVnG9IEsVymNp/lyO4Jhb3u9Xm9HEMdxGMJULRsB6/U6VtCI83qVMgmLnLN/55n1RhQF
+2mLQBO4eQZJ5f8NY37FMUp6RdDt0F5jHVGdxzfIiS2AAkzmVenEZo2VkYlXPwA0gK9L0hRq2JgKyXFYc
+fqRSiVAuKKeznFOYkgwh7qopGuXrhTBjXMi44RaV5kCRZLSpRmhcSsYl1Shs+a7EUba4Y0n+2LZF4KLf
+f+Gibyxtd/Vv2ERmLCNp6g6f0JRK2piBb54nAhVoTOa9bVchJJlvh8ZNEaXbtO3rp9KqbQSFJ0dvKpR1
+xUE+YFtpgoLvAkdfGAwaxsh6Sr7jhgMHgfXUnrY6IHHSQvLeXgQsNN3cdgY7sB8a72Z3t0Oh+zAyfMD9
+qbb0oaeMBr4RqM+SzEO10BEfE4bgnCuINM/mVMgGDoTvMDYREOyF2KqByvcrwiXk2lDFJZm43JChWZ5R
+Vy6ts3PEsyaZhEFdfgeNdY/4a60XSPwAgqB7V/XM/IYdb0HuqUeF2/GG3cYOVVZzsE8syVztZjiNWMnB
+X1XTPJMsK2h97diBRTGbsQecRRzAjqLD8WfsUB5KNVSm5j4YVD3gy5d2MKt4r/VYhg2h6q2byn516hsG
+Fb58qUY0mOAvlThK5D4eLR6FB2qcPWgHNLJt4YfPw6ea59Fp/u25qVSjp/ZDUWJ8TYWoOqGU6t9vMtYj
+bS/yHs+JXMRL8oDmoOpoGPe6wTh4DfthJQ73IIQex/HJ8MPZ+BrM6VCtW6oWo3EHK1OvnI7VKn1UP9IU
+ZoUsuLXMQu3jx3haUocgmVfI1yxNYZpSwoFkj7Di9J7lhYB7khZU4IDufm16lfGNZgyia20+uyu5LrFy
+v9xdKfS3lPH4rHcf9uGaSmWuxuMzNaj297Wn5JCtwZ1wAZ6CriVn2bx3752C7mGgYkrZfJwfFZxg9959
+2KJDFnmPe3oXS5nCAO4P2g61LZgd27gkcrqgyMf7WP3u7f7f3v9JdsLejVguknX2ePvX8H/tOrtZ2aPL
+/7mHHe1SZ7kEgjJlCSRmdEOO58kUGZNonkTQGOXmza07gIGsXnoOEQxw4xD0NJNl//1bx9AXKlQi+rAf
+wbIP7/ciWPTh7fu9PbvlFjdBEtzCAIp4Aa/gzfdl89o0J/AK/rtszZzWt3tl86Pb/P6doQBeDaC4wTnc
+eoGW+3LxlaELT9HswrMKJxd2jbmrxO37H9K6xFs6cRVp6VS+JflED4fDk5TMe2px1yJFlUKr5eNbU7Wg
+poTMUjKHLwNtHQ58e3U4HE4OR6fj08PhGZ7AmWRTkmIzYDcVPnVhYODRtA8//AB74YFmvxP327bRMbSc
+2xHshQiRicO8yJQ13IMlJZmAJM8CCYWgkHNzCqfaqjkRp9jtjMvCYjdIsDtJU1ecjRik6d4SgDRvdAyy
+yBI6YxlNApeZJQi83v8aCVdUiBskA9Xa4KoJYqjJZCt7aDy3Z1M85Sk5DGFg3v1YsBRnFgwDw/vhcPgS
+DMNhG5LhsMJzdjq81ogk4XMqNyBD0BZs2GzRHVqqJJlHSv+68R220XY4HAZRFUAcXx5d9mTKlmEfTiWI
+RV6kCdxRIBlQznOOclXjWAO6BzmH/Td/1rFFPDn14eYmQKKCCKrVfRvBTSDJvNmo0PnNJvwpOckExpv7
+9YUYqZGiKsDQsjK1f6gAa/5TtXQlmVsQSeYNCC0iC+Gub02gHf6iWN5R3kKlZ1OaVkPUzUa09WQlezE8
+P36ZoijQFtFis1WUq/HoZciuxqMmqqvxyCK6vjQal4loeZc/RJzOOBWLiFPJHyP6sGKcRkuWSZm2j4Jq
+hqcpyhlJIVOsAyZgSTIyN3ETDEiahR0rsq4vW5T3+rJSXqN5JaNbVdB5q/nQ/R7n1v3WTNoAaPHXACR/
+7H6t2dT9XvOv7f0fszR8xddPDSCRE+SShcLfbTCGVxbMPLZDSv5YwUn+2AaleWfB9FMrbYqHJXXqqbHM
+rke/aHVecZZzJh+jNWXzhYwwQv/sYrke/dKilaNfvlkrLRXdmqHJ636PdHe/7db6P0avBL+3U7Rw9rkN
+Vk/WQuqnVpw5L6Hw91fos6MLSg+gEGROIxA0pVOZ80h78yyb6wvLKeWSzdiUSKpUYHx23WKZsPWblUBR
+0C1DS9kG6+FQ/JW6gE6ANxfIKE0EENjW8NvlofWPNEepIIorFko9tIJZ7lhI+9wK7DKqNBRO2zfoUZX4
+YHh6yfU15kPtaOEcfB5CDCpVN54P5QXL+OP4Zdv2+OO4RQs/jutK2O2ZGWWokf2fdsXQBEt9o0XNcU+A
+XLMp7bswAJb1TIeD1YWD6VAHfJAWkQFmWcLuWVKQ1A4R+30uLsfHfTid6dsOfZFUXrPtm05R6ZII6xjn
+WfoIZIqXBp1ERCAXhQAmIcmpyAKJBkVSDusFkbDGWeNQLLNTrNH2c76m95RH6BchKMvmDQ5ouiMchC2R
+Sirgjkw/rQlPapRN8+WKSHbHUrTB6wXNFLaUZj114Y9BUdhXVz89lkmaoajxhiOEO07Jpxq6O55/opnD
+GUp4+ggsM4yXdG4CK5IK6fC9dvZ31lNYD52+yCdxASsFGMCNA+1EWht3988MdLN3+/xYrYQ91beZ8481
+j+O5tX3+sbm0zz/+B32M/2kvYfmw4nRGOc2m9Fk34StM8nRBp58wltpTv4QlNqFi6sYtSJWBgBcoCrYl
+rK/Didi5M+XA3sG4KBpBXhzyOw1yw27V6BjdrS+DajgVwHxdbsQQwA4wN6o5zTmnU6nSSYKGKpq95eKF
+cYiLliDERRmBwEPm9fHol2PvfBk6CWs1ADAQ8PklER43SKUC4PUbScTVN//CU9ga5atS1krFnUhyl1In
+dWqMVNzcpPlahV8XbL7ow5sIMrr+kQjah7e4T6rX39vX79Tr06s+vL+9tYhUDtT2PvwGb+A3eAu/HcD3
+8Bu8g98AfoP322W0N2UZfe6CoEbvpvs7toJBHd67XEIgRS4MgK1i9dO/Y1NNbRdglWuiQeow+GdRT+Il
+WWm4qBIra+viXUot3yS57LGweRv2FMa/5izrBVFQe9tqxV1iLFpN9uYbNIdHKPGSS/jQ4BM2PsspBdTB
+KzNEyS18/h/llyHI4Zgi/2U8Q8s0gJuSqlWc5uswAqcBl0xYriezchz1VMtBr3Ger80M4DcIwraYv4Y2
+QAcQlB7z6fnV5Wg8GY+GF9cnl6NzveRT5YPoRVEmcyjrVodv2ro6RNOlbgwRKJ9aD6N/N6I5v+dOGvwt
+eGZbdFNQ3I2WSnITlDRY4r0sX9W/McOwOWAVa2kLtFx9GP103HP2Bd1Qmvsk/julqw/Zpyxfq2wfkgpq
+hXpxOWn0L9s6UUheGAyvXm3BK/hbQlec4sk92YJXuxWqOZXlttfTXBeScOldROZJp7FWwOWNbuc+jyjK
+W1zvAtdRbASy0x4pzqpdXMB6kQtaZquou8vk10JIHTF1MgBVplx5Wa/Dp5Py+fiBLldyrFAOjNcYHJ5M
+roY/HU9GH86Og6hsGx0fnY6OD8dO0/j4/KrR3lwhuh3Dxubn6N3byc/Hw7Pxz5PDn48P/x5EW7cHpWSc
+icKdXndKYCopFT5rD/tJv3dg22DylRSx4u/tzd4tDK2PhIxz4a3wB36X/Vu4XOkjj74ZJjLnm/qViwds
+inOVduBlItgLeHhl9WFMPlHoWO0hEFH1j2GYPZbvhM5PuKMOLhyQoSroXD25YKI0KLFzlbMsJJFUHc7m
+7J5mLlmdrMHJ2AXSMk0vyxMxa5z+GvONqo6lIXa7QPC32g/Nra3ofX7SEJGzhF4WxUDjWnb5RgtrvDkN
+qRmuMqRK4DKN1bC+3hNxW0EByUyyvDIcTq61uQxtO1p2H5NcZ0NvJxvPz227gt2Y3X4v9BVefBx3nAVH
+Hp42tcikUxpt/nEJvCmt0zHhMKi6KOe4AdgsWMiTsMsZW+aJobvNDWsvMNiAbncXdC2NrLRWLSoTYmjt
+hPiXeeIYoj/9yYkleq86RzaTqSD9Qh8Px0ErhqfW1rKAwnE4lIi7+dVOoCmtOB6NLkd9sHu8V1kRtKDs
+1kf1T2gUoH4IrZ+tVNpOYhK6Pj/5Z6rKIpjaN1cy9Qwv+KHabjoyBRFn2e2MCQmDqk9jiur8UB0bJF0+
+c3JAkEY0S3OjidycI6B+kNDiQK7XMsnxL7BWk9N/FYxTAUELVJ0NrYhKPkCvDYfPphYEYQyXGKPd2HkT
+AWvKKYhCm/jgYKvJUDe8suWt5BRvHqphtjYZsjo3Wg2Z0Ywj3DMYytvVjEYiKULrVI2uUhZHSSucVUrr
+fpsm4Z5YZJVvhAgsf1qN6Xce9pv9W5NoFW5c6R2q1VCxYAOQP/De7UZ8lkN2ZipuRFjakPomu4J/la24
+qROABysn26NbZ0qT0q4zLcry0hKHasPsSietUbUxPlce/7UwBi0idYo9G++atZT2T8q072Xw+SBPtY27
+6aa2uBMHzS7lplaCV9Lzu9a9u38wuWBZ97ErcmqSnHIkv44grmO9UH1olrBsDiyD7XhbYUjpTAJRl1l4
+qqCR0gdHU9X5Am++48bidkpmlGfQdhIsC0N03XOzHMRF6LmnAxV96N5yHFBoFO90eEtOr3i6IHwovSYn
+TVzVHQTxi8bfsQUGm8morWzvMYk1wrJau8XzM+tFv3NWlJeO+Ew8giSJPuX2Elvb7Ya7lWYKJ3bNZlDd
+juqEqgiIEMWSAlshOk6FiEvnkhk1qZ0hWo4PjfOCd1Rw69+n3upvW/VttdZ+/N5p717/9iLIq572LcnT
+QVno3CyITuiUJRTuiKAJ5Jkm1cK/hpNaabSo6n+0TM069NMgVNfL1nJohPVKohWszRg8PcHrvRKzFpmS
+o53nluPkixA+b3QjEOY5D2KpD0HtrsCGWm37p4xl+2FxYzH1N59y1OQ7zzcvON0su841G081T1ubTjO1
+WvCvBOs860zzTOR40ZPPe61zqarLzzvLyoOotastLm9/G/SuP7HVimXz78KgARG+pPqoaR/9LzZwOrWh
+TbaC6rMRpXchYMbzJSykXPV3d4Uk00/5PeWzNF9j7eMu2f3z/t67//5+b3f/zf7793uI6Z4R2+FXck/E
+lLOVjMldXkjVJ2V3nPDH3buUrYzexQu5rKzt6VUvyb1YbwIDSHIZq1K4Hu4vpUFZcSolo/w1m2c5p+7s
+eupvJ7nZuw2xNuPd+xB2ABv2b8Nay5tGy9vbsPYxC3sRUyzdu+msWKpdt8yjb6nwCoJ6NbqTaYH4WqvC
+lo3qXW334b+Qzpaw99sDYPAXZXpev3ZRKhp13dcszXOuiN5Vs63UyMMOO3ZzbgmJJ2UxRZoXySwlnAJJ
+GRFU9FX7OZWqCgxL3YWi0cn4sSqpU6VPJlejy4//nFyenOCGBdMSJX5v5OGxD0E+m2GJK0r7CpsgYQKv
+PJI6iotODJmPgGZt/U8+nJ11YZgVaerh2BkRls6LrMKFbyh/bb8x4bKgv1XRrndQyGczvRlmkpUlcNBz
+ynfCvk+eKWvr5NTE9Ks41jJq1hy0a5iLZ0dRXNWKcDK5Ph6PTy9+MjkF+kipS5KIqyf/zjNq+aK9ZlNL
+XUODGdcTmYqJMUkY89iP32AAQfKCAskSfSUFiFXRgk1q6tFW+S0UoVPChKmMmjGaJsZtV3Wc1fLumIKz
+0r9zFy3W3jZzT4IKS18NiHCQqzw2O2tYFkJV7ZO22jZrHH7M85SSrOVsbkMI+t+/6rkbobd+tcV4k92Y
+bLmXAjjY8DGWbhT/+/ryItbTYbPHLlQuB5u4WljobKJmKNQYtEvuB1ZcR8oGA5c3gaO6hvUT27N28LdX
+faVd+3A9vjyP4Gp0+cvp0fEIrq+OD09PTg9hdHx4OTqC8T+vjq+dvWFijn5UafoJjjmiCeMV0zr0qMkF
+e77X160N26s6VN8NiAJ7Oixpd64OeyuV2ZhFQPR2HpVebGcpiIEEJpxLknwGKzKnwIuUGghgiXISFUGi
+sZQ/T8l0QScpvadpH4K7xxURIoiApGvyKCaFoBN0EkRfLeenyhRb2lvqZ7xL0c5kOzPn7nw7M0MDoFX7
+xQl5f2yagJlLa55A7JpmMqcTFM7Eiq+xJBU+8zb8CoROeYCbGmqbQz+xwEjRXki3CrG6re6UocgLPlX3
+id0LyxNZQoVkmYq9vKjXHytGPR30qSK0QKrNobiVhd7Ffisfa1f//5+ZLczEpI1apoPZ3vOVsYgddtC8
+hwUR+kDXC34ej6/QD8F/rwPAnWh8eBWEEcz+lWRAsmQ358BWExPgibCpwqU+S6PKmGBF5CICQQmfLiY2
+m119fOiepOYzP1iUlafasdTRJ133KxdU569WgZD6FCeqANFyW9vWOkxTpZopIS8rVTCc8u3pH6sT7VnL
+aNP4u7eTBSWpXEw009oNo5lD+Kz2TMoy0FAVhgsgZjwlHAJ6MC0hx73chMf/FiLUSdYxuqpyGlGdDE/P
+LjGFV1B5mqA/r4NpPE9pCKtCOnSZ3H+8PMHDu20WVH2bx0MQKwTABARXo9Pz4eifWs2vjw8vL47wsTal
+zXS0TExQOWElYB/q/d69nVhC+5oYb97/OD796edxfTRdiNYxb/2SJpvmjStV4NWi6rXiOS5TE8fX/aG3
+hw9v3r0LayzYTNK3sUD37tsd1yLTrMDV/GF01lzAH0ZnGBkx79/u7beCvN3bt1Ano9ZCZdVc1hdfnUx+
+/HB6hm6w1Apv3UJ1rF0RLkVfVRCrn/a0c311YvBCT+ZwRwHvru3H5QK8CsbuKbmjqe6OX0VRj+VHK1ac
+LQl/dHDF0KsOoH8LlKXmZN2Hf6hCod56waYLjSXUIcycU6S4yEgqlYBtjMuh057TFUUqyKQpknS5SonU
+pw6SJEybcUOLcn/vqPkeXeJSNhGr2X8lmrxZSqSkWR+GkDKhv4xjPk+n+xsAE0NAS7Rk/6Z9ONHtpnBn
+mhYJFar6jybYU5kbCctcSNjfgzTPPxUrAT3Nuy1VDaRTyvQNRKi2IyDzOadzm3PGVsBJNqcidk4zjshb
+Ti+qJday/vIFnMcqpeJNy7nOwVolIhAJKSVCwhugKVU3n43jsBnRCLV29tTN7ibR6MjJutmNkzV2mnCy
+Fqvy4GyyT3TiCJjCeCs1R+p6PetLm5U+dVpo3HWcfDKZ688haTGi2FVpYJnlBwCaBBh4rDSZ1EFYIq40
+2FdZGwk9nVlNUpeVQjGZCkmTCOY0o1x/a7Qa3blIIesaUstCTZLBi4F+r6FKTdjzPgpadhjU4Gtp8E+1
+cazu+8hsa4mtahjopGKMguzvBdD3QhkVuvpoFYFfvsCqdViuQ+ZY71kqTGREVSXAO7y3cXFgAsSKTtGa
+J5EJD2qjgryts9Z286eswKv5Gpj6qD9tlqqvifFW67TU8rETi2AV1lKwePlNQLokLB0WcnGGXcx7ffWm
+kKgL9wwUGJBCLnBLmxIn2VTvF5zi1+WIdHr6Nt1a5sYni2ok9DSqCDzLoBa+akHh6h8tt+JmehpFS5xF
+vyjD4ApPkxUjm1eledEuB0nQzy0f8fNHbKZNc+vkNNKyOAs7S+nNz3l0SC5rk1Uns9awFCPCMVWntgT3
+zm5lCcdEpzJ8GJ0KT+y6GVR7PtN2smejmpBzIJn+aHWoIrK4gWZzCHCeMu8HavsjnFqV0Ycm6n7b2Bm5
+h+g7PlSlXrkcSXWW3g3+e9syZ13lg2+dEp+Cs3oVYMFZFWvrl7E2NITeq78FOvWhmaRhBqwmvYM92z69
+aCDLt37VkPng1/lwdLjRHWv1p/wFpV5aH1ltwZNkSfhU24I2D2uVp2yK1yBZnuEBMPhXQTjJJMuoPh9w
+igQE2n+yyRxXppdyDdVvS0gJItzRNIwakBdE9yuVQn8blNMpZffUcWK0gmjPjRezjb3wYFFwv89qany9
+FeVTmkkyL+P1SitlRbzOKxcgc5fq/T39qS+SsnmGDkwfAvM/fDCsSckDTQIdIb3L5UJttSRL4Ojvp+ce
+uy2sj/D66iSqnrCTmaVtQoIRZ84VSnXloSZnJqwLKIRykrM6F4BwCkLTvaduWfA/iblOwVM9y7aUM1mu
+bFFMF0AEBPv9JHDXuZaCwntqghmaVO2BIp2CTvMs0W4qcb7yV6JMghDuqFxTaodtyNph2Z/ff2/4L6UZ
+C+2rkaD1spzPpLkLqNu51ZrY9GG97o4Xa1Sk8b3lUkRCf0lQ6UVfs9UIG8UeRCBsq3q2GTMl3SWiHtG5
+R4165WqoG3LbXqpcI99ehsAObJeXUq26u73BXnnjeh4KMgC3FDTEwf1ADa6UazXAoV1W3x7U3KKaEfFE
+Qey3NwPhYqp3acjCdbLdxVWdYsrW6hOQJPnEls1uap119cNpqH4dZKt3inJHrNgWQeCNUCu1VHjR/+xA
+K1azOlbl1AXubFtwGklMpdrDWhMZnGFWU+nKbyo78PGCdGDgBVEY3N296tOJbtaJbtaJbtaFzjeN9fqC
+WV4dXTzAgzYCZrka36k1nuUh/BVmudnB0XPowyzvnJtnMuvEcFYS4wO2EsNZRYxxjzhDahrf7sTmPnDW
+6hU23NG68x1opyGI3HN4aB1W3YYup3VdzsfDyfX4+nd0WpaSvBYYdehyW1iicbLEIjM7ufqAQYJI9B3k
+7q7F9YMe7i+78Zqm6WtV1VK+lA9S7XAAp1IbzOkCgyfVV1sMft0s4pfvS4Y7nTsSS1p2I9PJ2YcyYEnw
+bdI0k2zIE0339fj6XlluljhLnyW3bYIen12Prsa/o5zFUq5izAXplnSLv9gzHjfk3Eg59L3B6gTzNYLS
+s+uUEy9ItS3wgtgICZ4b9lpEqNFVEuQFeZH8SjxNQZbsqovSueG7H+iBtVQ322PnarAU9pZJQdQS//H0
+/PT3k7f9QFg8uWNL1i1zC6fxKxrKri64+aU7pbmOSOhOSi9ULN0Gg3/5CdJ8niuFwBiGyWxuBf6FchXk
+gXPCP8Fh9R26r1AnJNtXJvUF/uqbaca3sQ1fvlQTOmjzshChEmqqtwGjAWbaqn8QNtytcqpdroWz7CvY
+bzE05UwwsqIk/OIdRP8vXog+45ijSPl/0/rLm3ffw92j9MIHCNkjvPz4+XRRZJ+udSjxzbt3FQNHnZ99
+iSBVcUzCuVfck9IMf+wMKqRVud7IFvPwWKRsSnssQlgH1M/DHeEU/98A127DbytwAAA=
 `,
 	},
 
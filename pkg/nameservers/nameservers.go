@@ -27,7 +27,13 @@ func DetermineNameservers(dc *models.DomainConfig, maxNS int, dsps map[string]pr
 		if !ok {
 			return nil, fmt.Errorf("DNS provider %s not declared", dsp)
 		}
-		nss, err := p.GetNameservers(dc.Name)
+		var nss []*models.Nameserver
+		var err error
+		if getter, ok := p.(providers.DomainConfigNameserverGetter); ok {
+			nss, err = getter.GetDomainConfigNameservers(dc)
+		} else {
+			nss, err = p.GetNameservers(dc.Name)
+		}
 		if err != nil {
 			return nil, err
 		}
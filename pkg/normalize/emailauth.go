@@ -0,0 +1,323 @@
+package normalize
+
+// Validation of the TXT records of email authentication: DMARC (RFC 7489),
+// MTA-STS (RFC 8461), TLS-RPT (RFC 8460) and BIMI.
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+
+	"github.com/StackExchange/dnscontrol/models"
+)
+
+type tag struct {
+	name, value string
+}
+
+var tagNameRe = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]*$`)
+
+// parseTags parses a list of tags such as "v=DMARC1; p=none".
+func parseTags(txt string) ([]tag, error) {
+	tags := []tag{}
+	seen := map[string]bool{}
+	for _, part := range strings.Split(txt, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			// a trailing ; is allowed
+			continue
+		}
+		i := strings.Index(part, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("%q is not a tag=value pair", part)
+		}
+		t := tag{strings.TrimSpace(part[:i]), strings.TrimSpace(part[i+1:])}
+		if !tagNameRe.MatchString(t.name) {
+			return nil, fmt.Errorf("invalid tag name %q", t.name)
+		}
+		if seen[t.name] {
+			return nil, fmt.Errorf("duplicate tag %s", t.name)
+		}
+		seen[t.name] = true
+		tags = append(tags, t)
+	}
+	return tags, nil
+}
+
+// emailAuthChecks are the checks of the records, by version tag, with the
+// names the records must have.
+var emailAuthChecks = map[string]struct {
+	name  *regexp.Regexp
+	where string
+	check func(tags []tag) []error
+}{
+	"DMARC1":   {regexp.MustCompile(`^_dmarc\.`), "_dmarc.<domain>", checkDMARCTags},
+	"STSv1":    {regexp.MustCompile(`^_mta-sts\.`), "_mta-sts.<domain>", checkMTASTSTags},
+	"TLSRPTv1": {regexp.MustCompile(`^_smtp\._tls\.`), "_smtp._tls.<domain>", checkTLSRPTTags},
+	"BIMI1":    {regexp.MustCompile(`^[^.]+\._bimi\.`), "<selector>._bimi.<domain>", checkBIMITags},
+}
+
+// checkEmailAuth checks the syntax of the email authentication records,
+// and that the domains receiving DMARC reports for other domains accept
+// them.
+func checkEmailAuth(config *models.DNSConfig) (errs []error) {
+	for _, domain := range config.Domains {
+		for _, rec := range domain.Records {
+			if rec.Type != "TXT" {
+				continue
+			}
+			txt := strings.Join(rec.TxtStrings, "")
+			if txt == "" {
+				txt = rec.Target
+			}
+			if !strings.HasPrefix(txt, "v=") {
+				continue
+			}
+			version := strings.TrimSpace(strings.SplitN(txt[2:], ";", 2)[0])
+			c, ok := emailAuthChecks[version]
+			if !ok {
+				continue
+			}
+			name := rec.NameFQDN
+			if version == "DMARC1" && strings.Contains(name, "._report._dmarc.") {
+				// An authorization to receive reports for another domain.
+				continue
+			}
+			if !c.name.MatchString(name) {
+				errs = append(errs, fmt.Errorf("%s record %s should be at %s", version, name, c.where))
+				continue
+			}
+			tags, err := parseTags(txt)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s record %s: %s", version, name, err))
+				continue
+			}
+			for _, err := range c.check(tags) {
+				if w, ok := err.(Warning); ok {
+					errs = append(errs, Warning{fmt.Errorf("%s record %s: %s", version, name, w.error)})
+				} else {
+					errs = append(errs, fmt.Errorf("%s record %s: %s", version, name, err))
+				}
+			}
+			if version == "DMARC1" {
+				errs = append(errs, checkDMARCDestinations(config, strings.TrimPrefix(name, "_dmarc."), tags)...)
+			}
+		}
+	}
+	return errs
+}
+
+var reportSizeRe = regexp.MustCompile(`![0-9]+[kmgt]?$`)
+
+// parseReportURIs parses a comma separated list of URIs. DMARC URIs may
+// end with a maximum report size, such as !10m.
+func parseReportURIs(value string, sizes bool) ([]*url.URL, error) {
+	uris := []*url.URL{}
+	for _, s := range strings.Split(value, ",") {
+		s = strings.TrimSpace(s)
+		if sizes {
+			s = reportSizeRe.ReplaceAllString(s, "")
+		}
+		u, err := url.Parse(s)
+		if err != nil {
+			return nil, err
+		}
+		switch u.Scheme {
+		case "mailto":
+			if !strings.Contains(u.Opaque, "@") {
+				return nil, fmt.Errorf("%q is not an email address", s)
+			}
+		case "https":
+			if u.Host == "" {
+				return nil, fmt.Errorf("%q has no host", s)
+			}
+		default:
+			return nil, fmt.Errorf("%q should be a mailto: or https: URI", s)
+		}
+		uris = append(uris, u)
+	}
+	return uris, nil
+}
+
+func checkDMARCTags(tags []tag) (errs []error) {
+	if len(tags) < 2 || tags[1].name != "p" {
+		errs = append(errs, fmt.Errorf("p= must follow v=DMARC1"))
+	}
+	for _, t := range tags[1:] {
+		var err error
+		switch t.name {
+		case "p", "sp":
+			switch t.value {
+			case "none", "quarantine", "reject":
+			default:
+				err = fmt.Errorf("%s=%s should be none, quarantine or reject", t.name, t.value)
+			}
+		case "adkim", "aspf":
+			if t.value != "r" && t.value != "s" {
+				err = fmt.Errorf("%s=%s should be r or s", t.name, t.value)
+			}
+		case "pct":
+			if n, e := strconv.Atoi(t.value); e != nil || n < 0 || n > 100 {
+				err = fmt.Errorf("pct=%s should be a number between 0 and 100", t.value)
+			}
+		case "ri":
+			if _, e := strconv.ParseUint(t.value, 10, 32); e != nil {
+				err = fmt.Errorf("ri=%s should be a number of seconds", t.value)
+			}
+		case "fo":
+			for _, o := range strings.Split(t.value, ":") {
+				if o != "0" && o != "1" && o != "d" && o != "s" {
+					err = fmt.Errorf("fo=%s should be 0, 1, d or s, separated by :", t.value)
+				}
+			}
+		case "rf":
+			// afrf is the only format, but others may be registered.
+		case "rua", "ruf":
+			if _, e := parseReportURIs(t.value, true); e != nil {
+				err = fmt.Errorf("%s: %s", t.name, e)
+			}
+		default:
+			err = Warning{fmt.Errorf("unknown tag %s", t.name)}
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// checkDMARCDestinations checks that the domains outside of the
+// organizational domain of domain that receive its reports have an
+// authorization record (RFC 7489 section 7.1). Domains of the
+// configuration must have it, the others are only warned about.
+func checkDMARCDestinations(config *models.DNSConfig, domain string, tags []tag) (errs []error) {
+	org := orgDomain(domain)
+	seen := map[string]bool{}
+	for _, t := range tags {
+		if t.name != "rua" && t.name != "ruf" {
+			continue
+		}
+		uris, _ := parseReportURIs(t.value, true)
+		for _, u := range uris {
+			if u.Scheme != "mailto" {
+				continue
+			}
+			dest := strings.ToLower(u.Opaque[strings.LastIndex(u.Opaque, "@")+1:])
+			if orgDomain(dest) == org || seen[dest] {
+				continue
+			}
+			seen[dest] = true
+			auth := domain + "._report._dmarc." + dest
+			dc := findParentDomain(config, dest)
+			if dc == nil {
+				errs = append(errs, Warning{fmt.Errorf("DMARC reports of %s are sent to %s, which must publish a TXT record v=DMARC1 at %s", domain, dest, auth)})
+				continue
+			}
+			if !hasDMARCAuthorization(dc, auth, "*._report._dmarc."+dest) {
+				errs = append(errs, fmt.Errorf("DMARC reports of %s are sent to %s, which has no TXT record v=DMARC1 at %s", domain, dest, auth))
+			}
+		}
+	}
+	return errs
+}
+
+// orgDomain returns the organizational domain of name: the domain just
+// below its public suffix.
+func orgDomain(name string) string {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	if org, err := publicsuffix.EffectiveTLDPlusOne(name); err == nil {
+		return org
+	}
+	return name
+}
+
+// findParentDomain returns the domain of the configuration that name is in.
+func findParentDomain(config *models.DNSConfig, name string) *models.DomainConfig {
+	var found *models.DomainConfig
+	for _, dc := range config.Domains {
+		if (name == dc.Name || strings.HasSuffix(name, "."+dc.Name)) && (found == nil || len(dc.Name) > len(found.Name)) {
+			found = dc
+		}
+	}
+	return found
+}
+
+func hasDMARCAuthorization(dc *models.DomainConfig, names ...string) bool {
+	for _, rec := range dc.Records {
+		if rec.Type != "TXT" {
+			continue
+		}
+		txt := strings.Join(rec.TxtStrings, "")
+		if txt == "" {
+			txt = rec.Target
+		}
+		if txt != "v=DMARC1" && !strings.HasPrefix(txt, "v=DMARC1;") {
+			continue
+		}
+		for _, n := range names {
+			if strings.EqualFold(rec.NameFQDN, n) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+var stsIDRe = regexp.MustCompile(`^[a-zA-Z0-9]{1,32}$`)
+
+func checkMTASTSTags(tags []tag) (errs []error) {
+	id := ""
+	for _, t := range tags[1:] {
+		switch t.name {
+		case "id":
+			id = t.value
+		default:
+			errs = append(errs, Warning{fmt.Errorf("unknown tag %s", t.name)})
+		}
+	}
+	if !stsIDRe.MatchString(id) {
+		errs = append(errs, fmt.Errorf("id=%s should be 1 to 32 letters and digits", id))
+	}
+	return errs
+}
+
+func checkTLSRPTTags(tags []tag) (errs []error) {
+	rua := false
+	for _, t := range tags[1:] {
+		switch t.name {
+		case "rua":
+			rua = true
+			if _, err := parseReportURIs(t.value, false); err != nil {
+				errs = append(errs, fmt.Errorf("rua: %s", err))
+			}
+		default:
+			errs = append(errs, Warning{fmt.Errorf("unknown tag %s", t.name)})
+		}
+	}
+	if !rua {
+		errs = append(errs, fmt.Errorf("missing rua="))
+	}
+	return errs
+}
+
+func checkBIMITags(tags []tag) (errs []error) {
+	for _, t := range tags[1:] {
+		switch t.name {
+		case "l", "a":
+			if t.value == "" {
+				// Declines to publish a logo or an authority.
+				continue
+			}
+			if u, err := url.Parse(t.value); err != nil || u.Scheme != "https" || u.Host == "" {
+				errs = append(errs, fmt.Errorf("%s=%s should be an https URL", t.name, t.value))
+			}
+		default:
+			errs = append(errs, Warning{fmt.Errorf("unknown tag %s", t.name)})
+		}
+	}
+	return errs
+}
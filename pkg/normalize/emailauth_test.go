@@ -0,0 +1,62 @@
+package normalize
+
+import (
+	"testing"
+
+	"github.com/StackExchange/dnscontrol/models"
+)
+
+func TestEmailAuthValidation(t *testing.T) {
+	txt := func(name, target string) *models.RecordConfig {
+		return &models.RecordConfig{Name: name, Type: "TXT", Target: target}
+	}
+	tests := []struct {
+		desc     string
+		records  []*models.RecordConfig
+		errs     int
+		warnings int
+	}{
+		{"dmarc", []*models.RecordConfig{txt("_dmarc", "v=DMARC1; p=reject; sp=none; adkim=s; aspf=r; pct=50; rua=mailto:d@example.com!10m,https://r.example.com/; fo=1:d; ri=3600;")}, 0, 0},
+		{"dmarc subdomain", []*models.RecordConfig{txt("_dmarc.mail", "v=DMARC1; p=none; rua=mailto:d@example.com")}, 0, 0},
+		{"dmarc without p", []*models.RecordConfig{txt("_dmarc", "v=DMARC1; rua=mailto:d@example.com")}, 1, 0},
+		{"dmarc values", []*models.RecordConfig{txt("_dmarc", "v=DMARC1; p=block; adkim=x; pct=101; ri=1d; fo=2")}, 5, 0},
+		{"dmarc syntax", []*models.RecordConfig{txt("_dmarc", "v=DMARC1; p=none; p=reject")}, 1, 0},
+		{"dmarc uri", []*models.RecordConfig{txt("_dmarc", "v=DMARC1; p=none; rua=d@example.com")}, 1, 0},
+		{"dmarc unknown tag", []*models.RecordConfig{txt("_dmarc", "v=DMARC1; p=none; x=1")}, 0, 1},
+		{"dmarc label", []*models.RecordConfig{txt("@", "v=DMARC1; p=none")}, 1, 0},
+		{"dmarc other domain", []*models.RecordConfig{txt("_dmarc", "v=DMARC1; p=none; rua=mailto:d@example.net")}, 0, 1},
+		{"dmarc configured domain", []*models.RecordConfig{txt("_dmarc", "v=DMARC1; p=none; rua=mailto:d@example.org")}, 1, 0},
+		{"dmarc authorized domain", []*models.RecordConfig{txt("_dmarc", "v=DMARC1; p=none; rua=mailto:d@reports.example.org")}, 0, 0},
+		{"mta-sts", []*models.RecordConfig{txt("_mta-sts", "v=STSv1; id=20180101T000000")}, 0, 0},
+		{"mta-sts id", []*models.RecordConfig{txt("_mta-sts", "v=STSv1; id=2018-01-01")}, 1, 0},
+		{"tlsrpt", []*models.RecordConfig{txt("_smtp._tls", "v=TLSRPTv1; rua=mailto:t@example.com,https://r.example.com/tlsrpt")}, 0, 0},
+		{"tlsrpt without rua", []*models.RecordConfig{txt("_smtp._tls", "v=TLSRPTv1")}, 1, 0},
+		{"bimi", []*models.RecordConfig{txt("default._bimi", "v=BIMI1; l=https://example.com/logo.svg; a=")}, 0, 0},
+		{"bimi url", []*models.RecordConfig{txt("default._bimi", "v=BIMI1; l=http://example.com/logo.svg")}, 1, 0},
+		{"bimi label", []*models.RecordConfig{txt("_bimi", "v=BIMI1; l=")}, 1, 0},
+	}
+	for _, tst := range tests {
+		t.Run(tst.desc, func(t *testing.T) {
+			config := &models.DNSConfig{
+				Domains: []*models.DomainConfig{
+					{Name: "example.com", Registrar: "BIND", Records: tst.records},
+					{Name: "example.org", Registrar: "BIND", Records: []*models.RecordConfig{
+						txt("example.com._report._dmarc.reports", "v=DMARC1"),
+					}},
+				},
+			}
+			found := NormalizeAndValidateConfig(config)
+			errs, warnings := 0, 0
+			for _, err := range found {
+				if _, ok := err.(Warning); ok {
+					warnings++
+				} else {
+					errs++
+				}
+			}
+			if errs != tst.errs || warnings != tst.warnings {
+				t.Errorf("expected %d errors and %d warnings, got %v", tst.errs, tst.warnings, found)
+			}
+		})
+	}
+}
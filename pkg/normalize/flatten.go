@@ -2,6 +2,8 @@ package normalize
 
 import (
 	"fmt"
+	"os"
+	"strconv"
 	"strings"
 
 	"github.com/miekg/dns/dnsutil"
@@ -10,7 +12,18 @@ import (
 	"github.com/StackExchange/dnscontrol/pkg/spflib"
 )
 
+// SPFCacheFile is the file of the SPF lookups cache.
+const SPFCacheFile = "spfcache.json"
+
+// SPFCacheMode is how flattenSPFs uses the cache. With spflib.CacheOffline,
+// preview and check don't look anything up.
+var SPFCacheMode = spflib.CacheTTL
+
 // hasSpfRecords returns true if this record requests SPF unrolling.
+func hasSpfRecords(txt *models.RecordConfig) bool {
+	return txt.Metadata["flatten"] != "" || txt.Metadata["split"] != "" || txt.Metadata["optimize"] != ""
+}
+
 func flattenSPFs(cfg *models.DNSConfig) []error {
 	var cache spflib.CachingResolver
 	var errs []error
@@ -20,9 +33,9 @@ func flattenSPFs(cfg *models.DNSConfig) []error {
 		// flatten all spf records that have the "flatten" metadata
 		for _, txt := range apexTXTs {
 			var rec *spflib.SPFRecord
-			if txt.Metadata["flatten"] != "" || txt.Metadata["split"] != "" {
+			if hasSpfRecords(txt) {
 				if cache == nil {
-					cache, err = spflib.NewCache("spfcache.json")
+					cache, err = spflib.NewCacheMode(SPFCacheFile, SPFCacheMode)
 					if err != nil {
 						return []error{err}
 					}
@@ -37,6 +50,22 @@ func flattenSPFs(cfg *models.DNSConfig) []error {
 				rec = rec.Flatten(flatten)
 				txt.SetTxt(rec.TXT())
 			}
+			// choose what else to flatten to stay within the lookup limit
+			if max, ok := txt.Metadata["optimize"]; ok && strings.HasPrefix(txt.Target, "v=spf1") {
+				n, err := strconv.Atoi(max)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("Optimize value `%s` in `%s` is not a number of lookups", max, txt.NameFQDN))
+					continue
+				}
+				opt, report, err := rec.Optimize(n)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("Optimizing SPF record `%s`: %s", txt.NameFQDN, err))
+					continue
+				}
+				fmt.Fprintf(os.Stderr, "SPF %s: %s\n", txt.NameFQDN, report)
+				rec = opt
+				txt.SetTxt(rec.TXT())
+			}
 			// now split if needed
 			if split, ok := txt.Metadata["split"]; ok {
 				if !strings.Contains(split, "%d") {
@@ -68,12 +97,32 @@ func flattenSPFs(cfg *models.DNSConfig) []error {
 	if len(cache.ResolveErrors()) == 0 {
 		changed := cache.ChangedRecords()
 		if len(changed) > 0 {
-			if err := cache.Save("spfcache.updated.json"); err != nil {
-				errs = append(errs, err)
-			} else {
-				errs = append(errs, Warning{fmt.Errorf("%d spf record lookups are out of date with cache (%s).\nWrote changes to spfcache.updated.json. Please rename and commit:\n    $ mv spfcache.updated.json spfcache.json\n    $ git commit spfcache.json", len(changed), strings.Join(changed, ","))})
-			}
+			errs = append(errs, Warning{fmt.Errorf("%d spf record lookups are out of date with cache (%s).\nPlease update and commit the cache:\n    $ dnscontrol update-spf-cache\n    $ git commit spfcache.json", len(changed), strings.Join(changed, ","))})
 		}
 	}
 	return errs
 }
+
+// UpdateSPFCache looks up again all the SPF records used by the records
+// to flatten, saves them in the cache and returns what changed.
+func UpdateSPFCache(cfg *models.DNSConfig) ([]spflib.CacheChange, error) {
+	cache, err := spflib.NewCacheMode(SPFCacheFile, spflib.CacheRefresh)
+	if err != nil {
+		return nil, err
+	}
+	for _, domain := range cfg.Domains {
+		for _, txt := range domain.Records.Grouped()[models.RecordKey{Type: "TXT", Name: "@"}] {
+			if !hasSpfRecords(txt) {
+				continue
+			}
+			if _, err := spflib.Parse(txt.Target, cache); err != nil {
+				return nil, fmt.Errorf("%s: %s", domain.Name, err)
+			}
+		}
+	}
+	if errs := cache.ResolveErrors(); len(errs) > 0 {
+		return nil, fmt.Errorf("problem resolving SPF record: %s", errs[0])
+	}
+	changes := cache.Changes()
+	return changes, cache.Save(SPFCacheFile)
+}
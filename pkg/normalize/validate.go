@@ -3,6 +3,7 @@ package normalize
 import (
 	"fmt"
 	"net"
+	"regexp"
 	"strings"
 
 	"github.com/StackExchange/dnscontrol/models"
@@ -61,6 +62,7 @@ func validateRecordTypes(rec *models.RecordConfig, domain string, pTypes []strin
 		"TXT":              true,
 		"NS":               true,
 		"PTR":              true,
+		"SOA":              true,
 		"ALIAS":            false,
 	}
 	_, ok := validTypes[rec.Type]
@@ -158,6 +160,14 @@ func checkTargets(rec *models.RecordConfig, domain string) (errs []error) {
 		check(checkTarget(target))
 	case "ALIAS":
 		check(checkTarget(target))
+	case "SOA":
+		check(checkTarget(target))
+		if label != "@" {
+			check(fmt.Errorf("SOA record must be declared on the bare domain"))
+		}
+		if rec.SoaMbox == "" {
+			check(fmt.Errorf("SOA record requires an mbox"))
+		}
 	case "SRV":
 		check(checkTarget(target))
 	case "TXT", "IMPORT_TRANSFORM", "CAA", "TLSA":
@@ -217,7 +227,7 @@ func importTransform(srcDomain, dstDomain *models.DomainConfig, transforms []tra
 			r := newRec()
 			r.Target = transformCNAME(r.Target, srcDomain.Name, dstDomain.Name)
 			dstDomain.Records = append(dstDomain.Records, r)
-		case "MX", "NS", "SRV", "TXT", "CAA", "TLSA":
+		case "MX", "NS", "SOA", "SRV", "TXT", "CAA", "TLSA":
 			// Not imported.
 			continue
 		default:
@@ -251,6 +261,9 @@ func NormalizeAndValidateConfig(config *models.DNSConfig) (errs []error) {
 		ptypeMap[p.Name] = p.Type
 	}
 
+	config.UpdateSplitHorizonNames()
+	errs = append(errs, checkSplitHorizon(config)...)
+
 	for _, domain := range config.Domains {
 		pTypes := []string{}
 		txtMultiDissenters := []string{}
@@ -298,6 +311,9 @@ func NormalizeAndValidateConfig(config *models.DNSConfig) (errs []error) {
 			// Canonicalize Targets.
 			if rec.Type == "CNAME" || rec.Type == "MX" || rec.Type == "NS" {
 				rec.Target = dnsutil.AddOrigin(rec.Target, domain.Name+".")
+			} else if rec.Type == "SOA" {
+				rec.Target = dnsutil.AddOrigin(rec.Target, domain.Name+".")
+				rec.SoaMbox = mboxToName(rec.SoaMbox, domain.Name)
 			} else if rec.Type == "A" || rec.Type == "AAAA" {
 				rec.Target = net.ParseIP(rec.Target).String()
 			} else if rec.Type == "PTR" {
@@ -367,11 +383,16 @@ func NormalizeAndValidateConfig(config *models.DNSConfig) (errs []error) {
 		}
 	}
 
-	// Check that CNAMES don't have to co-exist with any other records
+	// Check that CNAMES don't have to co-exist with any other records,
+	// and that there is at most one SOA.
 	for _, d := range config.Domains {
 		errs = append(errs, checkCNAMEs(d)...)
+		errs = append(errs, checkSOA(d)...)
 	}
 
+	// Check the DMARC, MTA-STS, TLS-RPT and BIMI records
+	errs = append(errs, checkEmailAuth(config)...)
+
 	// Check that if any aliases / ptr / etc.. are used in a domain, every provider for that domain supports them
 	for _, d := range config.Domains {
 		err := checkProviderCapabilities(d, config.DNSProviders)
@@ -383,6 +404,48 @@ func NormalizeAndValidateConfig(config *models.DNSConfig) (errs []error) {
 	return errs
 }
 
+var tagRe = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// checkSplitHorizon checks that domains declared more than once (with different tags)
+// do not step on each other: each variant must use different DNS providers, and
+// at most one of them may use a registrar that actually changes the delegation.
+func checkSplitHorizon(config *models.DNSConfig) (errs []error) {
+	rtypeMap := map[string]string{}
+	for _, r := range config.Registrars {
+		rtypeMap[r.Name] = r.Type
+	}
+	seen := map[string]bool{}
+	providerUser := map[string]string{}  // name + provider -> unique name
+	registrarUser := map[string]string{} // name -> unique name
+	for _, d := range config.Domains {
+		if d.Tag != "" && !tagRe.MatchString(d.Tag) {
+			errs = append(errs, fmt.Errorf("%s: invalid tag %q. Tags may only contain letters, digits, _ and -", d.UniqueName, d.Tag))
+		}
+		if seen[d.UniqueName] {
+			errs = append(errs, fmt.Errorf("%s is declared more than once", d.UniqueName))
+			continue
+		}
+		seen[d.UniqueName] = true
+		for p := range d.DNSProviders {
+			k := d.Name + "\x00" + p
+			if other, ok := providerUser[k]; ok {
+				errs = append(errs, fmt.Errorf("%s and %s both use DNS provider %s. Each variant of a split horizon domain needs its own provider", other, d.UniqueName, p))
+				continue
+			}
+			providerUser[k] = d.UniqueName
+		}
+		if rtypeMap[d.Registrar] == "NONE" {
+			continue
+		}
+		if other, ok := registrarUser[d.Name]; ok {
+			errs = append(errs, fmt.Errorf("%s and %s both use a registrar. Only one variant of a split horizon domain may manage the delegation; use a NONE registrar for the others", other, d.UniqueName))
+			continue
+		}
+		registrarUser[d.Name] = d.UniqueName
+	}
+	return errs
+}
+
 func checkCNAMEs(dc *models.DomainConfig) (errs []error) {
 	cnames := map[string]bool{}
 	for _, r := range dc.Records {
@@ -401,6 +464,31 @@ func checkCNAMEs(dc *models.DomainConfig) (errs []error) {
 	return
 }
 
+func checkSOA(dc *models.DomainConfig) (errs []error) {
+	count := 0
+	for _, r := range dc.Records {
+		if r.Type == "SOA" {
+			count++
+		}
+	}
+	if count > 1 {
+		errs = append(errs, fmt.Errorf("Cannot have more than one SOA record: %s", dc.Name))
+	}
+	return
+}
+
+// mboxToName converts the mbox of a SOA record to a domain name. An email
+// address such as hostmaster@example.com becomes hostmaster.example.com.
+// Names without an @ are made fully qualified.
+func mboxToName(mbox, domain string) string {
+	i := strings.LastIndex(mbox, "@")
+	if i == -1 {
+		return dnsutil.AddOrigin(mbox, domain+".")
+	}
+	local := strings.Replace(mbox[:i], ".", `\.`, -1)
+	return dns.Fqdn(local + "." + mbox[i+1:])
+}
+
 func checkProviderCapabilities(dc *models.DomainConfig, pList []*models.DNSProviderConfig) error {
 	types := []struct {
 		rType string
@@ -408,6 +496,7 @@ func checkProviderCapabilities(dc *models.DomainConfig, pList []*models.DNSProvi
 	}{
 		{"ALIAS", providers.CanUseAlias},
 		{"PTR", providers.CanUsePTR},
+		{"SOA", providers.CanUseSOA},
 		{"SRV", providers.CanUseSRV},
 		{"CAA", providers.CanUseCAA},
 		{"TLSA", providers.CanUseTLSA},
@@ -224,3 +224,78 @@ func TestTLSAValidation(t *testing.T) {
 		t.Error("Expect error on invalid TLSA but got none")
 	}
 }
+
+func TestSOAValidation(t *testing.T) {
+	soa := func(name, mbox string) *models.RecordConfig {
+		return &models.RecordConfig{Name: name, Type: "SOA", Target: "ns1", SoaMbox: mbox, SoaRefresh: 3600, SoaRetry: 600, SoaExpire: 604800, SoaMinttl: 1440}
+	}
+	tests := []struct {
+		desc    string
+		records []*models.RecordConfig
+		errs    int
+	}{
+		{"valid", []*models.RecordConfig{soa("@", "first.last@example.org")}, 0},
+		{"not at apex", []*models.RecordConfig{soa("www", "hostmaster")}, 1},
+		{"no mbox", []*models.RecordConfig{soa("@", "")}, 1},
+		{"two SOA", []*models.RecordConfig{soa("@", "hostmaster"), soa("@", "hostmaster")}, 1},
+	}
+	for _, tst := range tests {
+		t.Run(tst.desc, func(t *testing.T) {
+			config := &models.DNSConfig{
+				Domains: []*models.DomainConfig{{Name: "example.com", Registrar: "BIND", Records: tst.records}},
+			}
+			errs := NormalizeAndValidateConfig(config)
+			if len(errs) != tst.errs {
+				t.Fatalf("expected %d errors, got %v", tst.errs, errs)
+			}
+			if tst.errs == 0 {
+				rec := tst.records[0]
+				if rec.Target != "ns1.example.com." || rec.SoaMbox != `first\.last.example.org.` {
+					t.Errorf("SOA was not normalized: %s %s", rec.Target, rec.SoaMbox)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitHorizon(t *testing.T) {
+	regs := []*models.RegistrarConfig{{Name: "none", Type: "NONE"}, {Name: "reg", Type: "NAMEDOTCOM"}}
+	tests := []struct {
+		desc    string
+		domains []*models.DomainConfig
+		fail    bool
+	}{
+		{"distinct providers", []*models.DomainConfig{
+			{Name: "example.com", Registrar: "reg", DNSProviders: map[string]int{"public": -1}},
+			{Name: "example.com!internal", Registrar: "none", DNSProviders: map[string]int{"internal": 0}},
+		}, false},
+		{"same provider", []*models.DomainConfig{
+			{Name: "example.com", Registrar: "none", DNSProviders: map[string]int{"public": -1}},
+			{Name: "example.com!internal", Registrar: "none", DNSProviders: map[string]int{"public": -1}},
+		}, true},
+		{"two registrars", []*models.DomainConfig{
+			{Name: "example.com", Registrar: "reg", DNSProviders: map[string]int{"public": -1}},
+			{Name: "example.com!internal", Registrar: "reg", DNSProviders: map[string]int{"internal": 0}},
+		}, true},
+		{"same tag twice", []*models.DomainConfig{
+			{Name: "example.com!internal", Registrar: "none"},
+			{Name: "example.com!internal", Registrar: "none"},
+		}, true},
+		{"bad tag", []*models.DomainConfig{
+			{Name: "example.com!in.ternal", Registrar: "none"},
+		}, true},
+	}
+	for _, tst := range tests {
+		t.Run(tst.desc, func(t *testing.T) {
+			config := &models.DNSConfig{Registrars: regs, Domains: tst.domains}
+			config.UpdateSplitHorizonNames()
+			errs := checkSplitHorizon(config)
+			if len(errs) != 0 && !tst.fail {
+				t.Errorf("Got errors but expected none: %v", errs)
+			}
+			if len(errs) == 0 && tst.fail {
+				t.Error("Expected error but got none")
+			}
+		})
+	}
+}
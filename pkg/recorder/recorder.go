@@ -0,0 +1,200 @@
+// Package recorder records the HTTP traffic of providers into cassette files
+// and replays it later, so that provider tests can run without credentials or
+// network access.
+//
+// Secrets (API keys, tokens...) are replaced by a placeholder before anything
+// is written to disk. Requests are scrubbed the same way before they are
+// matched during replay, so a cassette replays with either the real secrets or
+// the placeholder.
+package recorder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Placeholder replaces secrets in cassettes.
+const Placeholder = "REDACTED"
+
+// Mode selects what a Recorder does with requests.
+type Mode int
+
+const (
+	// Record sends requests to the real server and saves the exchanges.
+	Record Mode = iota
+	// Replay answers requests from a cassette. Nothing is sent over the network.
+	Replay
+)
+
+// scrubbedHeaders are never written to a cassette.
+var scrubbedHeaders = []string{"Set-Cookie", "Date"}
+
+// Interaction is one recorded request and its response.
+type Interaction struct {
+	Method       string      `json:"method"`
+	URL          string      `json:"url"`
+	Body         string      `json:"body,omitempty"`
+	Status       int         `json:"status"`
+	Header       http.Header `json:"header,omitempty"`
+	ResponseBody string      `json:"response_body,omitempty"`
+
+	used bool
+}
+
+// Cassette is the content of a cassette file.
+type Cassette struct {
+	// Meta holds values the test needs to replay, such as the domain name.
+	Meta         map[string]string `json:"meta,omitempty"`
+	Interactions []*Interaction    `json:"interactions"`
+}
+
+// Recorder is an http.RoundTripper that records or replays a cassette. In
+// Record mode it sends the requests with http.DefaultTransport. To record the
+// traffic of providers, set providers.HTTPTransport to the Recorder.
+type Recorder struct {
+	Cassette *Cassette
+
+	mode    Mode
+	path    string
+	secrets []string
+	real    http.RoundTripper
+
+	mu sync.Mutex
+}
+
+// New creates a Recorder for the cassette at path. In Replay mode the
+// cassette must exist. secrets are scrubbed from everything that is recorded.
+func New(path string, mode Mode, secrets []string) (*Recorder, error) {
+	r := &Recorder{
+		Cassette: &Cassette{Meta: map[string]string{}},
+		mode:     mode,
+		path:     path,
+		real:     http.DefaultTransport,
+	}
+	for _, s := range secrets {
+		// Very short values would match random text.
+		if len(s) >= 4 && s != Placeholder {
+			r.secrets = append(r.secrets, s)
+		}
+	}
+	// Replace longer secrets first, in case one contains another.
+	sort.Slice(r.secrets, func(i, j int) bool { return len(r.secrets[i]) > len(r.secrets[j]) })
+	if mode == Replay {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("can not replay: %s", err)
+		}
+		if err := json.Unmarshal(data, r.Cassette); err != nil {
+			return nil, fmt.Errorf("can not replay %s: %s", path, err)
+		}
+	}
+	return r, nil
+}
+
+func (r *Recorder) scrub(s string) string {
+	for _, secret := range r.secrets {
+		s = strings.Replace(s, secret, Placeholder, -1)
+		if escaped := url.QueryEscape(secret); escaped != secret {
+			s = strings.Replace(s, escaped, Placeholder, -1)
+		}
+	}
+	return s
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	method, u, b := req.Method, r.scrub(req.URL.String()), r.scrub(string(body))
+
+	if r.mode == Replay {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		for _, i := range r.Cassette.Interactions {
+			if !i.used && i.Method == method && i.URL == u && i.Body == b {
+				i.used = true
+				return &http.Response{
+					Status:        fmt.Sprintf("%d %s", i.Status, http.StatusText(i.Status)),
+					StatusCode:    i.Status,
+					Proto:         "HTTP/1.1",
+					ProtoMajor:    1,
+					ProtoMinor:    1,
+					Header:        i.Header,
+					Body:          ioutil.NopCloser(strings.NewReader(i.ResponseBody)),
+					ContentLength: int64(len(i.ResponseBody)),
+					Request:       req,
+				}, nil
+			}
+		}
+		return nil, fmt.Errorf("recorder: no recorded response for %s %s in %s", method, u, r.path)
+	}
+
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	resp, err := r.real.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+
+	header := http.Header{}
+	for k, vs := range resp.Header {
+		for _, v := range vs {
+			header.Add(k, r.scrub(v))
+		}
+	}
+	for _, h := range scrubbedHeaders {
+		header.Del(h)
+	}
+	r.mu.Lock()
+	r.Cassette.Interactions = append(r.Cassette.Interactions, &Interaction{
+		Method:       method,
+		URL:          u,
+		Body:         b,
+		Status:       resp.StatusCode,
+		Header:       header,
+		ResponseBody: r.scrub(string(respBody)),
+	})
+	r.mu.Unlock()
+	return resp, nil
+}
+
+// Save writes the cassette to disk. It does nothing in Replay mode.
+func (r *Recorder) Save() error {
+	if r.mode == Replay {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for k, v := range r.Cassette.Meta {
+		r.Cassette.Meta[k] = r.scrub(v)
+	}
+	data, err := json.MarshalIndent(r.Cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.path, append(data, '\n'), 0644)
+}
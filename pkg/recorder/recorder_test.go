@@ -0,0 +1,90 @@
+package recorder
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func get(t *testing.T, rec *Recorder, u string) string {
+	resp, err := (&http.Client{Transport: rec}).Get(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "recorder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "sub", "cassette.json")
+
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("X-Token", "s3cr3t-key")
+		w.Write([]byte("hello " + r.URL.Query().Get("key") + " " + r.URL.Path))
+	}))
+
+	rec, err := New(path, Record, []string{"s3cr3t-key", "abc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body := get(t, rec, srv.URL+"/one?key=s3cr3t-key"); body != "hello s3cr3t-key /one" {
+		t.Fatalf("recording changed the response: %q", body)
+	}
+	get(t, rec, srv.URL+"/two?key=s3cr3t-key")
+	rec.Cassette.Meta["domain"] = "example.com"
+	if err := rec.Save(); err != nil {
+		t.Fatal(err)
+	}
+	srv.Close()
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "s3cr3t-key") {
+		t.Fatalf("secret was not scrubbed:\n%s", data)
+	}
+
+	// Replay with the real secret: the server is gone, so the answer must come from the cassette.
+	rep, err := New(path, Replay, []string{"s3cr3t-key"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.Cassette.Meta["domain"] != "example.com" {
+		t.Errorf("meta = %v", rep.Cassette.Meta)
+	}
+	if body := get(t, rep, srv.URL+"/two?key=s3cr3t-key"); body != "hello REDACTED /two" {
+		t.Errorf("unexpected replayed body %q", body)
+	}
+	if body := get(t, rep, srv.URL+"/one?key=REDACTED"); body != "hello REDACTED /one" {
+		t.Errorf("unexpected replayed body %q", body)
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 calls to the server, got %d", calls)
+	}
+	// Each interaction is only replayed once.
+	if _, err := (&http.Client{Transport: rep}).Get(srv.URL + "/one?key=REDACTED"); err == nil {
+		t.Error("expected an error for a request that was not recorded")
+	}
+}
+
+func TestReplayMissingCassette(t *testing.T) {
+	if _, err := New(filepath.Join("does", "not", "exist.json"), Replay, nil); err == nil {
+		t.Fatal("expected an error")
+	}
+}
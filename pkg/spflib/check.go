@@ -0,0 +1,338 @@
+package spflib
+
+// The check_host() function of RFC 7208 section 4.
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Result is the result of an SPF check.
+type Result string
+
+// The results of RFC 7208 section 2.6.
+const (
+	None      Result = "none"
+	Neutral   Result = "neutral"
+	Pass      Result = "pass"
+	Fail      Result = "fail"
+	SoftFail  Result = "softfail"
+	TempError Result = "temperror"
+	PermError Result = "permerror"
+)
+
+var qualifierResults = map[byte]Result{
+	'+': Pass,
+	'-': Fail,
+	'~': SoftFail,
+	'?': Neutral,
+}
+
+// The limits of RFC 7208 section 4.6.4.
+const (
+	maxVoidLookups = 2
+	maxNames       = 10 // The MX or PTR names checked by a mechanism.
+)
+
+// DNSResolver answers the queries of an SPF check. Names that don't exist
+// and names without records of the type are not errors: they return no
+// records.
+type DNSResolver interface {
+	Resolver
+	LookupIP(name string) ([]net.IP, error)
+	LookupMX(name string) ([]string, error)
+	LookupAddr(ip net.IP) ([]string, error)
+}
+
+// CheckResult is the result of Check, with how it was reached.
+type CheckResult struct {
+	Result      Result
+	Mechanism   string // The mechanism that matched, with the domain of its record.
+	Lookups     int
+	VoidLookups int
+	Err         error // Why the result is a temperror or permerror.
+}
+
+func (r *CheckResult) String() string {
+	s := string(r.Result)
+	if r.Mechanism != "" {
+		s += " (" + r.Mechanism + ")"
+	}
+	if r.Err != nil {
+		s += ": " + r.Err.Error()
+	}
+	return s
+}
+
+type checker struct {
+	res    DNSResolver
+	ip     net.IP
+	sender string
+	helo   string
+	result *CheckResult
+}
+
+// checkError ends a check with a temperror or permerror.
+type checkError struct {
+	result Result
+	err    error
+}
+
+func (e *checkError) Error() string { return e.err.Error() }
+
+func permError(format string, args ...interface{}) error {
+	return &checkError{PermError, fmt.Errorf(format, args...)}
+}
+
+// Check returns the SPF result for mail from sender, sent by ip, for
+// domain. The SPF record of domain is read with res, unless record is set.
+// helo is the name given by the client, used for the %{h} macro.
+func Check(res DNSResolver, ip net.IP, domain, sender, helo, record string) *CheckResult {
+	if sender == "" {
+		sender = "postmaster@" + domain
+	}
+	c := &checker{res: res, ip: ip, sender: sender, helo: helo, result: &CheckResult{}}
+	var r Result
+	var err error
+	if record != "" {
+		r, err = c.checkRecord(domain, record)
+	} else {
+		r, err = c.checkHost(domain)
+	}
+	if err != nil {
+		if ce, ok := err.(*checkError); ok {
+			r, err = ce.result, ce.err
+		} else {
+			r = TempError
+		}
+		c.result.Mechanism = ""
+	}
+	c.result.Result, c.result.Err = r, err
+	return c.result
+}
+
+// checkHost evaluates the SPF record of domain.
+func (c *checker) checkHost(domain string) (Result, error) {
+	record, err := c.res.GetSPF(domain)
+	if err != nil {
+		if _, ok := err.(NoSPFError); ok {
+			return None, nil
+		}
+		return "", &checkError{TempError, err}
+	}
+	return c.checkRecord(domain, record)
+}
+
+func (c *checker) checkRecord(domain, record string) (Result, error) {
+	rec, err := Parse(record, nil)
+	if err != nil {
+		return "", permError("%s: %s", domain, err)
+	}
+	var redirect *SPFPart
+	hasAll := false
+	for _, p := range rec.Parts {
+		if p.Name == "redirect" {
+			redirect = p
+		}
+		if p.Name == "all" {
+			hasAll = true
+		}
+	}
+	for _, p := range rec.Parts {
+		if p.IsModifier() {
+			continue
+		}
+		match, err := c.match(p, domain)
+		if err != nil {
+			return "", err
+		}
+		if match {
+			if p.Name == "include" {
+				c.result.Mechanism = p.Text + " in " + domain + ", " + c.result.Mechanism
+			} else {
+				c.result.Mechanism = p.Text + " in " + domain
+			}
+			return qualifierResults[p.Qualifier], nil
+		}
+	}
+	if redirect != nil && !hasAll {
+		if err := c.countLookup(); err != nil {
+			return "", err
+		}
+		target, err := c.target(redirect, domain)
+		if err != nil {
+			return "", err
+		}
+		r, err := c.checkHost(target)
+		if err != nil {
+			return "", err
+		}
+		if r == None {
+			return "", permError("redirect=%s: no SPF record", target)
+		}
+		return r, nil
+	}
+	return Neutral, nil
+}
+
+func (c *checker) countLookup() error {
+	c.result.Lookups++
+	if c.result.Lookups > MaxLookups {
+		return permError("more than %d DNS lookups", MaxLookups)
+	}
+	return nil
+}
+
+// countVoid counts the lookups that returned nothing.
+func (c *checker) countVoid(n int) error {
+	if n > 0 {
+		return nil
+	}
+	c.result.VoidLookups++
+	if c.result.VoidLookups > maxVoidLookups {
+		return permError("more than %d void DNS lookups", maxVoidLookups)
+	}
+	return nil
+}
+
+// target returns the expanded domain-spec of p, or domain if it has none.
+func (c *checker) target(p *SPFPart, domain string) (string, error) {
+	if p.DomainSpec == "" {
+		return domain, nil
+	}
+	d, err := expandDomain(p.DomainSpec, &macroContext{sender: c.sender, domain: domain, ip: c.ip, helo: c.helo})
+	if err != nil {
+		return "", permError("%s: %s", p.Text, err)
+	}
+	return d, nil
+}
+
+func tempError(err error) error {
+	return &checkError{TempError, err}
+}
+
+func (c *checker) match(p *SPFPart, domain string) (bool, error) {
+	switch p.Name {
+	case "all":
+		return true, nil
+	case "ip4", "ip6":
+		return p.Network.Contains(c.ip) && (p.Name == "ip4") == (c.ip.To4() != nil), nil
+	}
+
+	if err := c.countLookup(); err != nil {
+		return false, err
+	}
+	target, err := c.target(p, domain)
+	if err != nil {
+		return false, err
+	}
+	switch p.Name {
+	case "include":
+		r, err := c.checkHost(target)
+		if err != nil {
+			return false, err
+		}
+		switch r {
+		case Pass:
+			return true, nil
+		case None:
+			return false, permError("include:%s: no SPF record", target)
+		}
+		c.result.Mechanism = ""
+		return false, nil
+	case "a":
+		ips, err := c.res.LookupIP(target)
+		if err != nil {
+			return false, tempError(err)
+		}
+		return c.matchIPs(ips, p), c.countVoid(len(ips))
+	case "mx":
+		names, err := c.res.LookupMX(target)
+		if err != nil {
+			return false, tempError(err)
+		}
+		if err := c.countVoid(len(names)); err != nil {
+			return false, err
+		}
+		if len(names) > maxNames {
+			return false, permError("%s: more than %d MX records", p.Text, maxNames)
+		}
+		for _, name := range names {
+			ips, err := c.res.LookupIP(strings.TrimSuffix(name, "."))
+			if err != nil {
+				return false, tempError(err)
+			}
+			if err := c.countVoid(len(ips)); err != nil {
+				return false, err
+			}
+			if c.matchIPs(ips, p) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "ptr":
+		names, err := c.res.LookupAddr(c.ip)
+		if err != nil || len(names) == 0 {
+			// Errors only mean that the mechanism doesn't match.
+			return false, c.countVoid(0)
+		}
+		if len(names) > maxNames {
+			names = names[:maxNames]
+		}
+		target = strings.ToLower(target)
+		for _, name := range names {
+			name = strings.ToLower(strings.TrimSuffix(name, "."))
+			if name != target && !strings.HasSuffix(name, "."+target) {
+				continue
+			}
+			// The name must resolve back to the ip.
+			ips, err := c.res.LookupIP(name)
+			if err != nil {
+				continue
+			}
+			for _, ip := range ips {
+				if ip.Equal(c.ip) {
+					return true, nil
+				}
+			}
+		}
+		return false, nil
+	case "exists":
+		ips, err := c.res.LookupIP(target)
+		if err != nil {
+			return false, tempError(err)
+		}
+		found := false
+		for _, ip := range ips {
+			// exists only looks at A records.
+			found = found || ip.To4() != nil
+		}
+		if !found {
+			return false, c.countVoid(0)
+		}
+		return true, nil
+	}
+	return false, permError("unsupported mechanism %s", p.Text)
+}
+
+// matchIPs returns true if the ip is in the networks of ips with the prefix
+// lengths of p.
+func (c *checker) matchIPs(ips []net.IP, p *SPFPart) bool {
+	v4 := c.ip.To4() != nil
+	for _, ip := range ips {
+		if (ip.To4() != nil) != v4 {
+			continue
+		}
+		bits, size := p.CIDR6, 128
+		if v4 {
+			bits, size = p.CIDR4, 32
+			ip = ip.To4()
+		}
+		n := &net.IPNet{IP: ip.Mask(net.CIDRMask(bits, size)), Mask: net.CIDRMask(bits, size)}
+		if n.Contains(c.ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package spflib
+
+import (
+	"fmt"
+	"net"
+	"testing"
+)
+
+type fakeDNS struct {
+	spf mapResolver
+	ips map[string][]string
+	mx  map[string][]string
+	ptr map[string][]string
+}
+
+func (f *fakeDNS) GetSPF(name string) (string, error) { return f.spf.GetSPF(name) }
+
+func (f *fakeDNS) LookupIP(name string) ([]net.IP, error) {
+	if name == "timeout.example.com" {
+		return nil, fmt.Errorf("timeout")
+	}
+	ips := []net.IP{}
+	for _, s := range f.ips[name] {
+		ips = append(ips, net.ParseIP(s))
+	}
+	return ips, nil
+}
+
+func (f *fakeDNS) LookupMX(name string) ([]string, error) { return f.mx[name], nil }
+
+func (f *fakeDNS) LookupAddr(ip net.IP) ([]string, error) { return f.ptr[ip.String()], nil }
+
+var checkDNS = &fakeDNS{
+	spf: mapResolver{
+		"example.com":       "v=spf1 ip4:192.0.2.0/24 mx a:web.example.com/30 include:_spf.vendor.net ptr:trusted.net -all",
+		"_spf.vendor.net":   "v=spf1 ip6:2001:db8::/32 exists:%{ir}.allow.vendor.net ~all",
+		"redirect.com":      "v=spf1 redirect=example.com",
+		"neutral.com":       "v=spf1 ip4:10.0.0.1",
+		"broken.com":        "v=spf1 include:nospf.com -all",
+		"void.com":          "v=spf1 a:void1.com a:void2.com a:void3.com -all",
+		"loop.com":          "v=spf1 include:loop.com -all",
+		"temp.com":          "v=spf1 a:timeout.example.com -all",
+		"badsyntax.com":     "v=spf1 ip4:300.0.0.1 -all",
+		"macro.com":         "v=spf1 exists:%{l}.users.macro.com -all",
+		"many-mx.com":       "v=spf1 mx -all",
+		"redirect-none.com": "v=spf1 redirect=nospf.com",
+		"redirect-fail.com": "v=spf1 redirect=neutral.com",
+		"ipv6-mx.com":       "v=spf1 mx//64 -all",
+		"helo.example.com":  "v=spf1 a -all",
+		"empty.com":         "v=spf1",
+	},
+	ips: map[string][]string{
+		"mail.example.com":         {"198.51.100.1"},
+		"web.example.com":          {"203.0.113.5"},
+		"9.9.9.9.allow.vendor.net": {"127.0.0.2"},
+		"host.trusted.net":         {"198.51.100.77"},
+		"bob.users.macro.com":      {"127.0.0.2"},
+		"mx6.example.com":          {"2001:db8:1::1"},
+		"helo.example.com":         {"198.51.100.9"},
+	},
+	mx: map[string][]string{
+		"example.com": {"mail.example.com."},
+		"many-mx.com": {"1.", "2.", "3.", "4.", "5.", "6.", "7.", "8.", "9.", "10.", "11."},
+		"ipv6-mx.com": {"mx6.example.com"},
+	},
+	ptr: map[string][]string{
+		"198.51.100.77": {"host.trusted.net."},
+		"198.51.100.78": {"host.trusted.net."},
+	},
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		ip, domain, sender string
+		result             Result
+		mechanism          string
+	}{
+		{"192.0.2.10", "example.com", "", Pass, "ip4:192.0.2.0/24 in example.com"},
+		{"198.51.100.1", "example.com", "", Pass, "mx in example.com"},
+		{"203.0.113.6", "example.com", "", Pass, "a:web.example.com/30 in example.com"},
+		{"203.0.113.9", "example.com", "", Fail, "-all in example.com"},
+		{"2001:db8::5", "example.com", "", Pass, "include:_spf.vendor.net in example.com, ip6:2001:db8::/32 in _spf.vendor.net"},
+		{"9.9.9.9", "example.com", "", Pass, "include:_spf.vendor.net in example.com, exists:%{ir}.allow.vendor.net in _spf.vendor.net"},
+		// The PTR name must resolve to the ip.
+		{"198.51.100.77", "example.com", "", Pass, "ptr:trusted.net in example.com"},
+		{"198.51.100.78", "example.com", "", Fail, "-all in example.com"},
+		{"192.0.2.10", "redirect.com", "", Pass, "ip4:192.0.2.0/24 in example.com"},
+		{"203.0.113.9", "redirect.com", "", Fail, "-all in example.com"},
+		{"10.0.0.2", "neutral.com", "", Neutral, ""},
+		{"10.0.0.1", "neutral.com", "", Pass, "ip4:10.0.0.1 in neutral.com"},
+		{"10.0.0.1", "nospf.com", "", None, ""},
+		{"10.0.0.1", "empty.com", "", Neutral, ""},
+		{"10.0.0.1", "broken.com", "", PermError, ""},
+		{"10.0.0.1", "void.com", "", PermError, ""},
+		{"10.0.0.1", "loop.com", "", PermError, ""},
+		{"10.0.0.1", "temp.com", "", TempError, ""},
+		{"10.0.0.1", "badsyntax.com", "", PermError, ""},
+		{"10.0.0.1", "macro.com", "bob@macro.com", Pass, "exists:%{l}.users.macro.com in macro.com"},
+		{"10.0.0.1", "macro.com", "alice@macro.com", Fail, "-all in macro.com"},
+		{"10.0.0.1", "many-mx.com", "", PermError, ""},
+		{"10.0.0.1", "redirect-none.com", "", PermError, ""},
+		{"10.0.0.2", "redirect-fail.com", "", Neutral, ""},
+		{"2001:db8:1::ff", "ipv6-mx.com", "", Pass, "mx//64 in ipv6-mx.com"},
+		{"198.51.100.9", "helo.example.com", "", Pass, "a in helo.example.com"},
+	}
+	for _, tst := range tests {
+		t.Run(tst.ip+" "+tst.domain, func(t *testing.T) {
+			r := Check(checkDNS, net.ParseIP(tst.ip), tst.domain, tst.sender, "", "")
+			if r.Result != tst.result || r.Mechanism != tst.mechanism {
+				t.Errorf("expected %s (%s), got %s", tst.result, tst.mechanism, r)
+			}
+		})
+	}
+}
+
+func TestCheckLookups(t *testing.T) {
+	r := Check(checkDNS, net.ParseIP("203.0.113.9"), "example.com", "", "", "")
+	// mx, a, include, exists, ptr. exists and ptr find nothing.
+	if r.Lookups != 5 || r.VoidLookups != 2 {
+		t.Errorf("expected 5 lookups and 2 void lookups, got %d and %d", r.Lookups, r.VoidLookups)
+	}
+	r = Check(checkDNS, net.ParseIP("10.0.0.1"), "example.org", "", "", "v=spf1 ip4:10.0.0.0/8 -all")
+	if r.Result != Pass || r.Lookups != 0 {
+		t.Errorf("unexpected result %s", r)
+	}
+}
@@ -73,7 +73,7 @@ func (s *SPFRecord) split(thisfqdn string, pattern string, nextIdx int, m map[st
 func (s *SPFRecord) Flatten(spec string) *SPFRecord {
 	newRec := &SPFRecord{}
 	for _, p := range s.Parts {
-		if p.IncludeRecord == nil {
+		if p.IncludeRecord == nil || p.Name != "include" {
 			// non-includes copy straight over
 			newRec.Parts = append(newRec.Parts, p)
 		} else if !matchesFlatSpec(spec, p.IncludeDomain) {
@@ -82,9 +82,11 @@ func (s *SPFRecord) Flatten(spec string) *SPFRecord {
 		} else {
 			// flatten child recursively
 			flattenedChild := p.IncludeRecord.Flatten(spec)
-			// include their parts (skipping final all term)
-			for _, childPart := range flattenedChild.Parts[:len(flattenedChild.Parts)-1] {
-				newRec.Parts = append(newRec.Parts, childPart)
+			// include their parts (skipping the all term and the modifiers)
+			for _, childPart := range flattenedChild.Parts {
+				if childPart.Name != "all" && !childPart.IsModifier() {
+					newRec.Parts = append(newRec.Parts, childPart)
+				}
 			}
 		}
 	}
@@ -0,0 +1,193 @@
+package spflib
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// macroContext holds the values of the macros of RFC 7208 section 7.
+type macroContext struct {
+	sender string // s: the sender, as local-part@domain.
+	domain string // d: the domain being checked.
+	ip     net.IP // i, v.
+	helo   string // h
+}
+
+func hasMacro(spec string) bool {
+	return strings.Contains(spec, "%")
+}
+
+// checkDomainSpec checks the syntax of a domain-spec.
+func checkDomainSpec(spec string) error {
+	if spec == "" {
+		return fmt.Errorf("empty domain")
+	}
+	_, err := expand(spec, &macroContext{ip: net.IPv4zero})
+	return err
+}
+
+// expand expands the macros of spec.
+func expand(spec string, ctx *macroContext) (string, error) {
+	var out bytes.Buffer
+	for i := 0; i < len(spec); i++ {
+		c := spec[i]
+		if c != '%' {
+			if c < 0x21 || c > 0x7e {
+				return "", fmt.Errorf("invalid character %q", c)
+			}
+			out.WriteByte(c)
+			continue
+		}
+		if i+1 == len(spec) {
+			return "", fmt.Errorf("%% at the end of %s", spec)
+		}
+		i++
+		switch spec[i] {
+		case '%':
+			out.WriteByte('%')
+		case '_':
+			out.WriteByte(' ')
+		case '-':
+			out.WriteString("%20")
+		case '{':
+			end := strings.IndexByte(spec[i:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated macro in %s", spec)
+			}
+			v, err := expandMacro(spec[i+1:i+end], ctx)
+			if err != nil {
+				return "", err
+			}
+			out.WriteString(v)
+			i += end
+		default:
+			return "", fmt.Errorf("invalid macro %%%c in %s", spec[i], spec)
+		}
+	}
+	return out.String(), nil
+}
+
+// expandMacro expands the inside of %{...}: a letter, the number of parts
+// to keep, "r" to reverse them and the delimiters to split on.
+func expandMacro(m string, ctx *macroContext) (string, error) {
+	if m == "" {
+		return "", fmt.Errorf("empty macro")
+	}
+	letter := m[0]
+	value, err := ctx.value(letter)
+	if err != nil {
+		return "", err
+	}
+	rest := m[1:]
+	digits := 0
+	for digits < len(rest) && rest[digits] >= '0' && rest[digits] <= '9' {
+		digits++
+	}
+	keep := 0
+	if digits > 0 {
+		if keep, err = strconv.Atoi(rest[:digits]); err != nil || keep == 0 {
+			return "", fmt.Errorf("invalid macro %%{%s}", m)
+		}
+	}
+	rest = rest[digits:]
+	reverse := false
+	if rest != "" && (rest[0] == 'r' || rest[0] == 'R') {
+		reverse = true
+		rest = rest[1:]
+	}
+	delimiters := rest
+	if strings.Trim(delimiters, ".-+,/_=") != "" {
+		return "", fmt.Errorf("invalid macro %%{%s}", m)
+	}
+	if delimiters == "" {
+		delimiters = "."
+	}
+	parts := strings.FieldsFunc(value, func(r rune) bool { return strings.ContainsRune(delimiters, r) })
+	if reverse {
+		for i, j := 0, len(parts)-1; i < j; i, j = i+1, j-1 {
+			parts[i], parts[j] = parts[j], parts[i]
+		}
+	}
+	if keep > 0 && keep < len(parts) {
+		parts = parts[len(parts)-keep:]
+	}
+	value = strings.Join(parts, ".")
+	// Upper case letters are URL escaped.
+	if letter >= 'A' && letter <= 'Z' {
+		value = url.QueryEscape(value)
+		value = strings.Replace(value, "+", "%20", -1)
+	}
+	return value, nil
+}
+
+func (ctx *macroContext) value(letter byte) (string, error) {
+	local, domain := "postmaster", ctx.sender
+	if i := strings.LastIndex(ctx.sender, "@"); i >= 0 {
+		domain = ctx.sender[i+1:]
+		if i > 0 {
+			local = ctx.sender[:i]
+		}
+	}
+	switch letter | 0x20 { // lower case
+	case 's':
+		return local + "@" + domain, nil
+	case 'l':
+		return local, nil
+	case 'o':
+		return domain, nil
+	case 'd':
+		return ctx.domain, nil
+	case 'h':
+		return ctx.helo, nil
+	case 'i':
+		if ip4 := ctx.ip.To4(); ip4 != nil {
+			return ip4.String(), nil
+		}
+		// Dotted nibbles.
+		nibbles := make([]string, 0, 32)
+		for _, b := range ctx.ip.To16() {
+			nibbles = append(nibbles, fmt.Sprintf("%x", b>>4), fmt.Sprintf("%x", b&0xf))
+		}
+		return strings.Join(nibbles, "."), nil
+	case 'v':
+		if ctx.ip.To4() != nil {
+			return "in-addr", nil
+		}
+		return "ip6", nil
+	case 'p':
+		// The validated domain name of the ip is discouraged by the RFC,
+		// which allows "unknown".
+		return "unknown", nil
+	case 'c':
+		// c, r and t are for exp= only, but aren't rejected elsewhere.
+		return ctx.ip.String(), nil
+	case 'r':
+		return "unknown", nil
+	case 't':
+		return strconv.FormatInt(time.Now().Unix(), 10), nil
+	}
+	return "", fmt.Errorf("invalid macro letter %c", letter)
+}
+
+// expandDomain expands a domain-spec and shortens the result to the 253
+// characters a domain name can have, by removing labels on the left.
+func expandDomain(spec string, ctx *macroContext) (string, error) {
+	d, err := expand(spec, ctx)
+	if err != nil {
+		return "", err
+	}
+	d = strings.TrimSuffix(d, ".")
+	for len(d) > 253 {
+		i := strings.Index(d, ".")
+		if i < 0 {
+			break
+		}
+		d = d[i+1:]
+	}
+	return d, nil
+}
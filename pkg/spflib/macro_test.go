@@ -0,0 +1,53 @@
+package spflib
+
+import (
+	"net"
+	"testing"
+)
+
+func TestExpand(t *testing.T) {
+	// The examples of RFC 7208 section 7.4.
+	ctx := &macroContext{sender: "strong-bad@email.example.com", domain: "email.example.com", ip: net.ParseIP("192.0.2.3")}
+	tests := []struct{ spec, expected string }{
+		{"%{s}", "strong-bad@email.example.com"},
+		{"%{o}", "email.example.com"},
+		{"%{d}", "email.example.com"},
+		{"%{d4}", "email.example.com"},
+		{"%{d3}", "email.example.com"},
+		{"%{d2}", "example.com"},
+		{"%{d1}", "com"},
+		{"%{dr}", "com.example.email"},
+		{"%{d2r}", "example.email"},
+		{"%{l}", "strong-bad"},
+		{"%{l-}", "strong.bad"},
+		{"%{lr}", "strong-bad"},
+		{"%{lr-}", "bad.strong"},
+		{"%{l1r-}", "strong"},
+		{"%{ir}.%{v}._spf.%{d2}", "3.2.0.192.in-addr._spf.example.com"},
+		{"%{lr-}.lp._spf.%{d2}", "bad.strong.lp._spf.example.com"},
+		{"%{lr-}.lp.%{ir}.%{v}._spf.%{d2}", "bad.strong.lp.3.2.0.192.in-addr._spf.example.com"},
+		{"%{ir}.%{v}.%{l1r-}.lp._spf.%{d2}", "3.2.0.192.in-addr.strong.lp._spf.example.com"},
+		{"%{d2}.trusted-domains.example.net", "example.com.trusted-domains.example.net"},
+		{"%%%_%-", "% %20"},
+	}
+	for _, tst := range tests {
+		found, err := expand(tst.spec, ctx)
+		if err != nil {
+			t.Errorf("%s: %s", tst.spec, err)
+		} else if found != tst.expected {
+			t.Errorf("%s: expected %s, got %s", tst.spec, tst.expected, found)
+		}
+	}
+
+	ctx.ip = net.ParseIP("2001:db8::cb01")
+	found, _ := expand("%{ir}.%{v}._spf.%{d2}", ctx)
+	if expected := "1.0.b.c.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6._spf.example.com"; found != expected {
+		t.Errorf("expected %s, got %s", expected, found)
+	}
+
+	for _, bad := range []string{"%{x}", "%{d0}", "%{d", "%{}", "%x", "%", "%{d2*}"} {
+		if _, err := expand(bad, ctx); err == nil {
+			t.Errorf("%s: expected an error", bad)
+		}
+	}
+}
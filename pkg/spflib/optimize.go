@@ -0,0 +1,308 @@
+package spflib
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+// MaxLookups is the limit of DNS lookups of RFC 7208.
+const MaxLookups = 10
+
+// OptimizeReport describes what Optimize did.
+type OptimizeReport struct {
+	LookupsBefore, LookupsAfter int
+	BytesBefore, BytesAfter     int
+	Flattened                   []string // The includes that were inlined.
+}
+
+func (r *OptimizeReport) String() string {
+	flattened := "none"
+	if len(r.Flattened) > 0 {
+		flattened = strings.Join(r.Flattened, ", ")
+	}
+	return fmt.Sprintf("lookups: %d -> %d, bytes: %d -> %d, flattened: %s",
+		r.LookupsBefore, r.LookupsAfter, r.BytesBefore, r.BytesAfter, flattened)
+}
+
+// Optimize returns s with the includes it needs to inline to use at most
+// maxLookups lookups, choosing those that add the fewest bytes. The ip4 and
+// ip6 ranges are then aggregated and the duplicate mechanisms removed.
+func (s *SPFRecord) Optimize(maxLookups int) (*SPFRecord, *OptimizeReport, error) {
+	report := &OptimizeReport{LookupsBefore: s.Lookups(), BytesBefore: len(s.TXT())}
+
+	type candidate struct {
+		index       int
+		parts       []*SPFPart
+		saved, cost int
+	}
+	candidates := []*candidate{}
+	for i, p := range s.Parts {
+		parts, ok := inline(p)
+		if !ok {
+			continue
+		}
+		c := &candidate{index: i, parts: parts, saved: 1 + p.IncludeRecord.Lookups(), cost: -len(p.Text) - 1}
+		for _, ip := range parts {
+			if ip.IsLookup {
+				c.saved--
+			}
+			c.cost += len(ip.Text) + 1
+		}
+		candidates = append(candidates, c)
+	}
+
+	// Choose the includes to inline.
+	needed := report.LookupsBefore - maxLookups
+	chosen := []*candidate{}
+	if needed > 0 {
+		best := -1
+		bestCost := 0
+		if len(candidates) <= 20 {
+			// Try every combination.
+			for set := 0; set < 1<<uint(len(candidates)); set++ {
+				saved, cost := 0, 0
+				for i, c := range candidates {
+					if set&(1<<uint(i)) != 0 {
+						saved += c.saved
+						cost += c.cost
+					}
+				}
+				if saved >= needed && (best < 0 || cost < bestCost) {
+					best, bestCost = set, cost
+				}
+			}
+			for i, c := range candidates {
+				if best >= 0 && best&(1<<uint(i)) != 0 {
+					chosen = append(chosen, c)
+				}
+			}
+		} else {
+			// Take the cheapest lookups first.
+			sorted := append([]*candidate{}, candidates...)
+			sort.SliceStable(sorted, func(i, j int) bool {
+				return sorted[i].cost*sorted[j].saved < sorted[j].cost*sorted[i].saved
+			})
+			saved := 0
+			for _, c := range sorted {
+				if saved >= needed {
+					break
+				}
+				if c.saved > 0 {
+					chosen = append(chosen, c)
+					saved += c.saved
+				}
+			}
+			if saved >= needed {
+				best = 0
+			}
+		}
+		if best < 0 {
+			all := 0
+			for _, c := range candidates {
+				all += c.saved
+			}
+			return nil, nil, fmt.Errorf("the SPF record needs %d lookups, and at least %d after inlining every include it can: the limit is %d",
+				report.LookupsBefore, report.LookupsBefore-all, maxLookups)
+		}
+	}
+
+	inlined := map[int][]*SPFPart{}
+	for _, c := range chosen {
+		inlined[c.index] = c.parts
+		report.Flattened = append(report.Flattened, s.Parts[c.index].IncludeDomain)
+	}
+	newRec := &SPFRecord{}
+	for i, p := range s.Parts {
+		if parts, ok := inlined[i]; ok {
+			newRec.Parts = append(newRec.Parts, parts...)
+		} else {
+			newRec.Parts = append(newRec.Parts, p)
+		}
+	}
+	newRec = newRec.aggregate()
+	report.LookupsAfter = newRec.Lookups()
+	report.BytesAfter = len(newRec.TXT())
+	return newRec, report, nil
+}
+
+// qualifier returns the qualifier of the mechanism text and the mechanism.
+func qualifier(text string) (byte, string) {
+	if text != "" && qualifiers[text[0]] {
+		return text[0], text[1:]
+	}
+	return '+', text
+}
+
+// inline returns the parts that replace the include p, or false if it can't
+// be inlined. The mechanisms of the included record that don't pass would
+// change the result of the record, and so would a "+all", a redirect or a
+// macro, which refers to the included domain.
+func inline(p *SPFPart) ([]*SPFPart, bool) {
+	if p.IncludeRecord == nil || p.Name != "include" || p.Qualifier != '+' {
+		return nil, false
+	}
+	parts := []*SPFPart{}
+	for _, child := range p.IncludeRecord.Parts {
+		switch {
+		case child.Name == "all":
+			if child.Qualifier == '+' {
+				return nil, false
+			}
+			continue
+		case child.Name == "redirect":
+			return nil, false
+		case child.IsModifier():
+			// exp= is only used for failures.
+			continue
+		case child.Qualifier != '+', hasMacro(child.DomainSpec):
+			return nil, false
+		case child.Name == "include":
+			sub, ok := inline(child)
+			if !ok {
+				return nil, false
+			}
+			parts = append(parts, sub...)
+			continue
+		case child.DomainSpec == "" && (child.Name == "a" || child.Name == "mx" || child.Name == "ptr"):
+			// a, mx and ptr without a domain refer to the included domain.
+			_, mech := qualifier(child.Text)
+			text := child.Name + ":" + p.IncludeDomain + mech[len(child.Name):]
+			t, err := parseTerm(text)
+			if err != nil {
+				return nil, false
+			}
+			child = t
+		}
+		parts = append(parts, child)
+	}
+	return parts, true
+}
+
+type ipRange struct {
+	ip   net.IP
+	bits int
+}
+
+func (r ipRange) String() string {
+	if r.ip.To4() != nil {
+		if r.bits == 32 {
+			return "ip4:" + r.ip.String()
+		}
+		return fmt.Sprintf("ip4:%s/%d", r.ip, r.bits)
+	}
+	if r.bits == 128 {
+		return "ip6:" + r.ip.String()
+	}
+	return fmt.Sprintf("ip6:%s/%d", r.ip, r.bits)
+}
+
+// parseRange parses a pass ip4 or ip6 mechanism.
+func parseRange(text string) (ipRange, bool) {
+	q, mech := qualifier(text)
+	if q != '+' {
+		return ipRange{}, false
+	}
+	var size int
+	switch {
+	case strings.HasPrefix(mech, "ip4:"):
+		size = 32
+	case strings.HasPrefix(mech, "ip6:"):
+		size = 128
+	default:
+		return ipRange{}, false
+	}
+	addr := mech[4:]
+	if !strings.Contains(addr, "/") {
+		addr += fmt.Sprintf("/%d", size)
+	}
+	ip, n, err := net.ParseCIDR(addr)
+	if err != nil || (size == 32) != (ip.To4() != nil) {
+		return ipRange{}, false
+	}
+	bits, total := n.Mask.Size()
+	if total != size {
+		return ipRange{}, false
+	}
+	if size == 32 {
+		n.IP = n.IP.To4()
+	}
+	return ipRange{n.IP, bits}, true
+}
+
+// aggregateRanges removes the ranges contained in others and merges the
+// adjacent ones.
+func aggregateRanges(ranges []ipRange) []ipRange {
+	sort.Slice(ranges, func(i, j int) bool {
+		if len(ranges[i].ip) != len(ranges[j].ip) {
+			return len(ranges[i].ip) < len(ranges[j].ip)
+		}
+		if c := bytes.Compare(ranges[i].ip, ranges[j].ip); c != 0 {
+			return c < 0
+		}
+		return ranges[i].bits < ranges[j].bits
+	})
+	size := func(r ipRange) int { return len(r.ip) * 8 }
+	contains := func(a, b ipRange) bool {
+		return a.bits <= b.bits && b.ip.Mask(net.CIDRMask(a.bits, size(a))).Equal(a.ip)
+	}
+	merged := []ipRange{}
+	for _, r := range ranges {
+		if len(merged) > 0 && len(merged[len(merged)-1].ip) == len(r.ip) && contains(merged[len(merged)-1], r) {
+			continue
+		}
+		merged = append(merged, r)
+		// Merge the two halves of a range.
+		for len(merged) >= 2 {
+			a, b := merged[len(merged)-2], merged[len(merged)-1]
+			if len(a.ip) != len(b.ip) || a.bits != b.bits || a.bits == 0 {
+				break
+			}
+			parent := ipRange{a.ip.Mask(net.CIDRMask(a.bits-1, size(a))), a.bits - 1}
+			if !contains(parent, b) {
+				break
+			}
+			merged = append(merged[:len(merged)-2], parent)
+		}
+	}
+	return merged
+}
+
+// aggregate returns s with its ranges aggregated and its duplicate
+// mechanisms removed. Only consecutive pass mechanisms can be reordered
+// without changing the result, so each run of them is handled alone: its
+// ranges come first, then its other mechanisms.
+func (s *SPFRecord) aggregate() *SPFRecord {
+	newRec := &SPFRecord{}
+	var ranges []ipRange
+	var others []*SPFPart
+	seen := map[string]bool{}
+	flush := func() {
+		for _, r := range aggregateRanges(ranges) {
+			p, _ := parseTerm(r.String())
+			newRec.Parts = append(newRec.Parts, p)
+		}
+		newRec.Parts = append(newRec.Parts, others...)
+		ranges, others, seen = nil, nil, map[string]bool{}
+	}
+	for _, p := range s.Parts {
+		q, mech := qualifier(p.Text)
+		if q != '+' || mech == "all" {
+			flush()
+			newRec.Parts = append(newRec.Parts, p)
+			continue
+		}
+		if r, ok := parseRange(p.Text); ok {
+			ranges = append(ranges, r)
+			continue
+		}
+		if key := strings.ToLower(mech); !seen[key] {
+			seen[key] = true
+			others = append(others, p)
+		}
+	}
+	flush()
+	return newRec
+}
@@ -0,0 +1,89 @@
+package spflib
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type mapResolver map[string]string
+
+func (m mapResolver) GetSPF(name string) (string, error) {
+	if spf, ok := m[name]; ok {
+		return spf, nil
+	}
+	return "", NoSPFError{name}
+}
+
+func TestAggregateRanges(t *testing.T) {
+	rec, err := Parse("v=spf1 ip4:10.0.0.0/25 ip4:10.0.0.128/25 ip4:10.0.1.0/24 ip4:10.0.1.7 ip6:2001:db8::/33 ip6:2001:db8:8000::/33 ip4:192.168.0.1 include:a.com mx include:a.com -ip4:10.0.0.0/8 ip4:10.0.0.1 ~all", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "v=spf1 ip4:10.0.0.0/23 ip4:192.168.0.1 ip6:2001:db8::/32 include:a.com mx -ip4:10.0.0.0/8 ip4:10.0.0.1 ~all"
+	if found := rec.aggregate().TXT(); found != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, found)
+	}
+}
+
+var optimizeDNS = mapResolver{
+	"small.com":  "v=spf1 ip4:1.2.3.0/25 ~all",
+	"big.com":    "v=spf1 ip4:5.5.5.1 ip4:5.5.5.2 ip4:5.5.5.3 ip4:5.5.5.4 ip4:5.5.5.5 ip4:5.5.5.6 ~all",
+	"nested.com": "v=spf1 include:small.com include:small2.com a ~all",
+	"small2.com": "v=spf1 ip4:1.2.3.128/25 -all",
+	"fail.com":   "v=spf1 -ip4:9.9.9.9 ip4:8.8.8.8 ~all",
+}
+
+func TestOptimize(t *testing.T) {
+	rec, err := Parse("v=spf1 include:small.com include:big.com include:nested.com include:fail.com a mx ~all", optimizeDNS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Lookups() != 9 {
+		t.Fatalf("expected 9 lookups, got %d", rec.Lookups())
+	}
+
+	tests := []struct {
+		max      int
+		expected string
+		err      bool
+	}{
+		{9, "v=spf1 include:small.com include:big.com include:nested.com include:fail.com a mx ~all", false},
+		// Inlining small.com is the cheapest.
+		{8, "v=spf1 ip4:1.2.3.0/25 include:big.com include:nested.com include:fail.com a mx ~all", false},
+		// nested.com saves 3 lookups, and its "a" becomes "a:nested.com".
+		// Inlining small.com too makes the record shorter.
+		{6, "v=spf1 ip4:1.2.3.0/24 include:big.com a:nested.com include:fail.com a mx ~all", false},
+		{4, "v=spf1 ip4:1.2.3.0/24 ip4:5.5.5.1 ip4:5.5.5.2/31 ip4:5.5.5.4/31 ip4:5.5.5.6 a:nested.com include:fail.com a mx ~all", false},
+		// fail.com can't be inlined.
+		{3, "", true},
+	}
+	for _, tst := range tests {
+		t.Run(fmt.Sprint(tst.max), func(t *testing.T) {
+			opt, report, err := rec.Optimize(tst.max)
+			if tst.err {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if opt.TXT() != tst.expected {
+				t.Errorf("expected\n%s\ngot\n%s", tst.expected, opt.TXT())
+			}
+			if report.LookupsAfter > tst.max || report.LookupsAfter != opt.Lookups() || report.BytesAfter != len(tst.expected) {
+				t.Errorf("unexpected report %s", report)
+			}
+		})
+	}
+
+	_, report, err := rec.Optimize(6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(report.Flattened, ",") != "small.com,nested.com" || report.LookupsBefore != 9 || report.LookupsAfter != 5 {
+		t.Errorf("unexpected report %s", report)
+	}
+}
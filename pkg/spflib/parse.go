@@ -1,12 +1,12 @@
 package spflib
 
 import (
-	"fmt"
-	"strings"
-
 	"bytes"
-
+	"fmt"
 	"io"
+	"net"
+	"strconv"
+	"strings"
 )
 
 // SPFRecord stores the parts of an SPF record.
@@ -32,8 +32,20 @@ func (s *SPFRecord) Lookups() int {
 type SPFPart struct {
 	Text          string
 	IsLookup      bool
-	IncludeRecord *SPFRecord
+	IncludeRecord *SPFRecord // The record of an include, or of a redirect.
 	IncludeDomain string
+
+	Qualifier  byte       // '+', '-', '~' or '?' for a mechanism, 0 for a modifier.
+	Name       string     // The mechanism or modifier, in lower case.
+	DomainSpec string     // The target of include, a, mx, ptr, exists and redirect. It may have macros.
+	Network    *net.IPNet // The network of ip4 and ip6.
+	CIDR4      int        // The prefix lengths of a and mx.
+	CIDR6      int
+}
+
+// IsModifier returns true if p is a modifier, such as redirect= or exp=.
+func (p *SPFPart) IsModifier() bool {
+	return p.Qualifier == 0
 }
 
 var qualifiers = map[byte]bool{
@@ -43,48 +55,222 @@ var qualifiers = map[byte]bool{
 	'+': true,
 }
 
+// maxDepth limits the nesting of includes and redirects, which catches loops.
+const maxDepth = 20
+
 // Parse parses a raw SPF record.
 func Parse(text string, dnsres Resolver) (*SPFRecord, error) {
-	if !strings.HasPrefix(text, "v=spf1 ") {
+	return parse(text, dnsres, 0)
+}
+
+func parse(text string, dnsres Resolver, depth int) (*SPFRecord, error) {
+	if depth > maxDepth {
+		return nil, fmt.Errorf("Too many nested includes")
+	}
+	fields := strings.Fields(text)
+	if len(fields) == 0 || !strings.EqualFold(fields[0], "v=spf1") {
 		return nil, fmt.Errorf("Not an spf record")
 	}
-	parts := strings.Split(text, " ")
 	rec := &SPFRecord{}
-	for _, part := range parts[1:] {
-		p := &SPFPart{Text: part}
-		if qualifiers[part[0]] {
-			part = part[1:]
+	var redirect *SPFPart
+	hasAll := false
+	for _, part := range fields[1:] {
+		p, err := parseTerm(part)
+		if err != nil {
+			return nil, err
 		}
 		rec.Parts = append(rec.Parts, p)
-		if part == "all" {
-			// all. nothing else matters.
-			break
-		} else if strings.HasPrefix(part, "a") || strings.HasPrefix(part, "mx") {
-			p.IsLookup = true
-		} else if strings.HasPrefix(part, "ip4:") || strings.HasPrefix(part, "ip6:") {
-			// ip address, 0 lookups
-			continue
-		} else if strings.HasPrefix(part, "include:") {
-			p.IsLookup = true
-			p.IncludeDomain = strings.TrimPrefix(part, "include:")
-			if dnsres != nil {
-				subRecord, err := dnsres.GetSPF(p.IncludeDomain)
+		switch p.Name {
+		case "all":
+			hasAll = true
+		case "redirect":
+			if redirect != nil {
+				return nil, fmt.Errorf("Multiple redirect modifiers")
+			}
+			redirect = p
+		case "include":
+			p.IncludeDomain = p.DomainSpec
+			if dnsres != nil && !hasMacro(p.DomainSpec) {
+				p.IncludeRecord, err = resolve(p.DomainSpec, dnsres, depth)
 				if err != nil {
 					return nil, err
 				}
-				p.IncludeRecord, err = Parse(subRecord, dnsres)
-				if err != nil {
-					return nil, fmt.Errorf("In included spf: %s", err)
-				}
 			}
-		} else {
-			return nil, fmt.Errorf("Unsupported spf part %s", part)
 		}
+	}
+	// redirect is ignored when there is an all mechanism.
+	if redirect != nil && !hasAll {
+		redirect.IsLookup = true
+		redirect.IncludeDomain = redirect.DomainSpec
+		if dnsres != nil && !hasMacro(redirect.DomainSpec) {
+			var err error
+			if redirect.IncludeRecord, err = resolve(redirect.DomainSpec, dnsres, depth); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return rec, nil
+}
 
+func resolve(domain string, dnsres Resolver, depth int) (*SPFRecord, error) {
+	subRecord, err := dnsres.GetSPF(domain)
+	if err != nil {
+		return nil, err
+	}
+	rec, err := parse(subRecord, dnsres, depth+1)
+	if err != nil {
+		return nil, fmt.Errorf("In included spf: %s", err)
 	}
 	return rec, nil
 }
 
+// parseTerm parses a mechanism or a modifier.
+func parseTerm(text string) (*SPFPart, error) {
+	p := &SPFPart{Text: text, Qualifier: '+', CIDR4: 32, CIDR6: 128}
+	term := text
+	if qualifiers[term[0]] {
+		p.Qualifier = term[0]
+		term = term[1:]
+	}
+	// A modifier is name=value, where the name can't have a ':' or a '/'.
+	if i := strings.IndexAny(term, "=:/"); i > 0 && term[i] == '=' {
+		if p.Qualifier != '+' || term != text {
+			return nil, fmt.Errorf("Qualifier on spf modifier %s", text)
+		}
+		p.Qualifier = 0
+		p.Name = strings.ToLower(term[:i])
+		value := term[i+1:]
+		if !validName(p.Name) {
+			return nil, fmt.Errorf("Unsupported spf part %s", text)
+		}
+		if p.Name == "redirect" || p.Name == "exp" {
+			if err := checkDomainSpec(value); err != nil {
+				return nil, fmt.Errorf("Invalid spf part %s: %s", text, err)
+			}
+			p.DomainSpec = value
+		}
+		return p, nil
+	}
+	name, arg := term, ""
+	if i := strings.IndexAny(term, ":/"); i >= 0 {
+		name, arg = term[:i], term[i:]
+	}
+	p.Name = strings.ToLower(name)
+	var err error
+	switch p.Name {
+	case "all":
+		if arg != "" {
+			err = fmt.Errorf("all takes no argument")
+		}
+	case "include", "exists":
+		p.IsLookup = true
+		if !strings.HasPrefix(arg, ":") {
+			err = fmt.Errorf("%s needs a domain", p.Name)
+		} else {
+			p.DomainSpec = arg[1:]
+			err = checkDomainSpec(p.DomainSpec)
+		}
+	case "a", "mx":
+		p.IsLookup = true
+		err = p.parseDomainCIDR(arg)
+	case "ptr":
+		p.IsLookup = true
+		if strings.HasPrefix(arg, ":") {
+			p.DomainSpec = arg[1:]
+			err = checkDomainSpec(p.DomainSpec)
+		} else if arg != "" {
+			err = fmt.Errorf("ptr takes no prefix length")
+		}
+	case "ip4", "ip6":
+		err = p.parseNetwork(arg)
+	default:
+		return nil, fmt.Errorf("Unsupported spf part %s", text)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Invalid spf part %s: %s", text, err)
+	}
+	return p, nil
+}
+
+// parseDomainCIDR parses [":" domain-spec] ["/" ip4-cidr-length] ["//" ip6-cidr-length].
+func (p *SPFPart) parseDomainCIDR(arg string) error {
+	if strings.HasPrefix(arg, ":") {
+		i := strings.Index(arg, "/")
+		if i < 0 {
+			i = len(arg)
+		}
+		p.DomainSpec = arg[1:i]
+		if err := checkDomainSpec(p.DomainSpec); err != nil {
+			return err
+		}
+		arg = arg[i:]
+	}
+	var v4, v6 string
+	if i := strings.Index(arg, "//"); i >= 0 {
+		v4, v6 = arg[:i], arg[i+2:]
+	} else {
+		v4 = arg
+	}
+	var err error
+	if v4 != "" {
+		if p.CIDR4, err = prefixLength(strings.TrimPrefix(v4, "/"), 32); err != nil || !strings.HasPrefix(v4, "/") {
+			return fmt.Errorf("invalid ip4 prefix length %s", v4)
+		}
+	}
+	if v6 != "" || strings.Contains(arg, "//") {
+		if p.CIDR6, err = prefixLength(v6, 128); err != nil {
+			return fmt.Errorf("invalid ip6 prefix length %s", v6)
+		}
+	}
+	return nil
+}
+
+func prefixLength(s string, max int) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 || n > max || (len(s) > 1 && s[0] == '0') {
+		return 0, fmt.Errorf("invalid prefix length %s", s)
+	}
+	return n, nil
+}
+
+func (p *SPFPart) parseNetwork(arg string) error {
+	if !strings.HasPrefix(arg, ":") {
+		return fmt.Errorf("%s needs an address", p.Name)
+	}
+	addr, size := arg[1:], 32
+	if p.Name == "ip6" {
+		size = 128
+	}
+	if !strings.Contains(addr, "/") {
+		addr += "/" + strconv.Itoa(size)
+	}
+	i := strings.Index(addr, "/")
+	ip := net.ParseIP(addr[:i])
+	if ip == nil || (p.Name == "ip4") != (ip.To4() != nil && !strings.Contains(addr[:i], ":")) {
+		return fmt.Errorf("invalid address %s", addr[:i])
+	}
+	bits, err := prefixLength(addr[i+1:], size)
+	if err != nil {
+		return err
+	}
+	if size == 32 {
+		ip = ip.To4()
+	}
+	p.Network = &net.IPNet{IP: ip.Mask(net.CIDRMask(bits, size)), Mask: net.CIDRMask(bits, size)}
+	return nil
+}
+
+// validName checks the name of a modifier: ALPHA *( ALPHA / DIGIT / "-" / "_" / "." ).
+func validName(name string) bool {
+	for i, c := range name {
+		alpha := c >= 'a' && c <= 'z'
+		if !alpha && (i == 0 || !(c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.')) {
+			return false
+		}
+	}
+	return name != ""
+}
+
 func dump(rec *SPFRecord, indent string, w io.Writer) {
 
 	fmt.Fprintf(w, "%sTotal Lookups: %d\n", indent, rec.Lookups())
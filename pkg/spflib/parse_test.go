@@ -26,3 +26,78 @@ func TestParse(t *testing.T) {
 	}
 	t.Log(rec.Print())
 }
+
+func TestParseTerms(t *testing.T) {
+	rec, err := Parse("v=spf1 a mx/24 a:%{d}.example.com/24//64 -ptr:example.com ip4:10.1.2.3/8 ip6:2001:db8::1 exists:%{ir}.%{v}._spf.%{d} ?include:_spf.example.com ~all exp=explain.%{d} foo=bar", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := rec.Parts
+	if p[1].Name != "mx" || p[1].CIDR4 != 24 || p[1].CIDR6 != 128 || p[1].DomainSpec != "" {
+		t.Errorf("unexpected mx %+v", p[1])
+	}
+	if p[2].DomainSpec != "%{d}.example.com" || p[2].CIDR4 != 24 || p[2].CIDR6 != 64 {
+		t.Errorf("unexpected a %+v", p[2])
+	}
+	if p[3].Qualifier != '-' || p[3].Name != "ptr" || p[3].DomainSpec != "example.com" {
+		t.Errorf("unexpected ptr %+v", p[3])
+	}
+	if p[4].Network.String() != "10.0.0.0/8" || p[5].Network.String() != "2001:db8::1/128" {
+		t.Errorf("unexpected networks %s %s", p[4].Network, p[5].Network)
+	}
+	if !p[9].IsModifier() || p[9].Name != "exp" || !p[10].IsModifier() {
+		t.Errorf("unexpected modifiers %+v %+v", p[9], p[10])
+	}
+	// a, mx, a, ptr, exists, include
+	if rec.Lookups() != 6 {
+		t.Errorf("expected 6 lookups, got %d", rec.Lookups())
+	}
+
+	for _, bad := range []string{
+		"v=spf1 ip4:1.2.3.4/33",
+		"v=spf1 ip4:2001:db8::1",
+		"v=spf1 ip6:1.2.3.4",
+		"v=spf1 a:example.com/24//129",
+		"v=spf1 include",
+		"v=spf1 exists:%{x}.example.com",
+		"v=spf1 include:%{d",
+		"v=spf1 foo:bar",
+		"v=spf1 -redirect=example.com",
+		"v=spf1 redirect=a.com redirect=b.com",
+		"v=spf2 -all",
+	} {
+		if _, err := Parse(bad, nil); err == nil {
+			t.Errorf("%s: expected an error", bad)
+		}
+	}
+}
+
+func TestParseRedirect(t *testing.T) {
+	dnsres := mapResolver{
+		"_spf.example.com": "v=spf1 include:a.example.com mx -all",
+		"a.example.com":    "v=spf1 a ~all",
+	}
+	rec, err := Parse("v=spf1 ip4:1.2.3.4 redirect=_spf.example.com", dnsres)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// redirect, include, mx, a
+	if rec.Lookups() != 4 {
+		t.Errorf("expected 4 lookups, got %d", rec.Lookups())
+	}
+	// redirect is ignored with all.
+	rec, err = Parse("v=spf1 redirect=missing.example.com -all", dnsres)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Lookups() != 0 {
+		t.Errorf("expected no lookups, got %d", rec.Lookups())
+	}
+	if _, err := Parse("v=spf1 redirect=missing.example.com", dnsres); err == nil {
+		t.Error("expected an error for a redirect without SPF record")
+	}
+	loop := mapResolver{"a.com": "v=spf1 include:b.com -all", "b.com": "v=spf1 include:a.com -all"}
+	if _, err := Parse("v=spf1 include:a.com -all", loop); err == nil {
+		t.Error("expected an error for an include loop")
+	}
+}
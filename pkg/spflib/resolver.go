@@ -6,7 +6,11 @@ import (
 	"io/ioutil"
 	"net"
 	"os"
+	"sort"
 	"strings"
+	"time"
+
+	"github.com/miekg/dns"
 )
 
 // Resolver looks up spf txt records associated with a FQDN.
@@ -14,33 +18,138 @@ type Resolver interface {
 	GetSPF(string) (string, error)
 }
 
+// NoSPFError is returned by resolvers for the names that have no SPF record.
+type NoSPFError struct {
+	Name string
+}
+
+func (e NoSPFError) Error() string {
+	return fmt.Sprintf("%s has no SPF record", e.Name)
+}
+
 // LiveResolver simply queries DNS to resolve SPF records.
 type LiveResolver struct{}
 
+// notFound returns true if err means that the name or its records don't exist.
+func notFound(err error) bool {
+	de, ok := err.(*net.DNSError)
+	return ok && !de.Timeout() && !de.Temporary()
+}
+
 // GetSPF looks up the SPF record named "name".
 func (l LiveResolver) GetSPF(name string) (string, error) {
-	vals, err := net.LookupTXT(name)
+	spf, _, err := l.GetSPFTTL(name)
+	return spf, err
+}
+
+// GetSPFTTL looks up the SPF record named "name", and returns its TTL.
+func (l LiveResolver) GetSPFTTL(name string) (string, uint32, error) {
+	vals, ttl, err := lookupTXT(name)
 	if err != nil {
-		return "", err
+		if notFound(err) {
+			return "", 0, NoSPFError{name}
+		}
+		return "", 0, err
 	}
 	spf := ""
 	for _, v := range vals {
-		if strings.HasPrefix(v, "v=spf1") {
+		if v == "v=spf1" || strings.HasPrefix(v, "v=spf1 ") {
 			if spf != "" {
-				return "", fmt.Errorf("%s has multiple SPF records", name)
+				return "", 0, permError("%s has multiple SPF records", name)
 			}
 			spf = v
 		}
 	}
 	if spf == "" {
-		return "", fmt.Errorf("%s has no SPF record", name)
+		return "", 0, NoSPFError{name}
+	}
+	return spf, ttl, nil
+}
+
+// lookupTXT returns the TXT records of name and their TTL. It asks the
+// servers of /etc/resolv.conf, since the net package doesn't give the TTL,
+// and falls back to net.LookupTXT (with a TTL of 0) without them.
+func lookupTXT(name string) ([]string, uint32, error) {
+	conf, err := dns.ClientConfigFromFile("/etc/resolv.conf")
+	if err != nil || len(conf.Servers) == 0 {
+		vals, err := net.LookupTXT(name)
+		return vals, 0, err
+	}
+	m := new(dns.Msg)
+	m.SetQuestion(dns.Fqdn(name), dns.TypeTXT)
+	var in *dns.Msg
+	for _, server := range conf.Servers {
+		c := &dns.Client{}
+		in, _, err = c.Exchange(m, net.JoinHostPort(server, conf.Port))
+		if err == nil && in.Truncated {
+			c.Net = "tcp"
+			in, _, err = c.Exchange(m, net.JoinHostPort(server, conf.Port))
+		}
+		if err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, 0, &net.DNSError{Err: err.Error(), Name: name, IsTemporary: true}
+	}
+	switch in.Rcode {
+	case dns.RcodeSuccess:
+	case dns.RcodeNameError:
+		return nil, 0, &net.DNSError{Err: "no such host", Name: name}
+	default:
+		return nil, 0, &net.DNSError{Err: dns.RcodeToString[in.Rcode], Name: name, IsTemporary: true}
+	}
+	vals := []string{}
+	var ttl uint32
+	for _, rr := range in.Answer {
+		if txt, ok := rr.(*dns.TXT); ok {
+			vals = append(vals, strings.Join(txt.Txt, ""))
+			if ttl == 0 || txt.Hdr.Ttl < ttl {
+				ttl = txt.Hdr.Ttl
+			}
+		}
+	}
+	return vals, ttl, nil
+}
+
+// LookupIP returns the A and AAAA records of name.
+func (l LiveResolver) LookupIP(name string) ([]net.IP, error) {
+	ips, err := net.LookupIP(name)
+	if err != nil && notFound(err) {
+		return nil, nil
+	}
+	return ips, err
+}
+
+// LookupMX returns the names of the MX records of name.
+func (l LiveResolver) LookupMX(name string) ([]string, error) {
+	mxs, err := net.LookupMX(name)
+	if err != nil {
+		if notFound(err) {
+			return nil, nil
+		}
+		return nil, err
 	}
-	return spf, nil
+	names := make([]string, len(mxs))
+	for i, mx := range mxs {
+		names[i] = mx.Host
+	}
+	return names, nil
+}
+
+// LookupAddr returns the names of the PTR records of ip.
+func (l LiveResolver) LookupAddr(ip net.IP) ([]string, error) {
+	names, err := net.LookupAddr(ip.String())
+	if err != nil && notFound(err) {
+		return nil, nil
+	}
+	return names, err
 }
 
 // CachingResolver wraps a live resolver and adds caching to it.
 // GetSPF will always return the cached value, if present.
-// It will also query the inner resolver and compare results.
+// Unless the cached value is still fresh (younger than its TTL), it will
+// also query the inner resolver and compare results.
 // If a given lookup has inconsistencies between cache and live,
 // GetSPF will return the cached result.
 // All records queries will be stored for the lifetime of the resolver,
@@ -49,46 +158,84 @@ func (l LiveResolver) GetSPF(name string) (string, error) {
 type CachingResolver interface {
 	Resolver
 	ChangedRecords() []string
+	Changes() []CacheChange
 	ResolveErrors() []error
 	Save(filename string) error
 }
 
+// CacheChange is a record whose live value differs from the cache. Old is
+// empty for a new record, and New for a record that isn't used anymore.
+type CacheChange struct {
+	Name     string
+	Old, New string
+}
+
+// CacheMode chooses when a cache looks records up.
+type CacheMode int
+
+const (
+	// CacheTTL looks up the records missing from the cache, or older than their TTL.
+	CacheTTL CacheMode = iota
+	// CacheOffline only uses the cache, and never looks records up.
+	CacheOffline
+	// CacheRefresh looks up every record.
+	CacheRefresh
+)
+
 type cacheEntry struct {
-	SPF string
+	SPF     string
+	Fetched time.Time `json:"fetched"`
+	TTL     uint32    `json:"ttl"`
 
 	// value we have looked up this run
 	resolvedSPF  string
+	resolvedTTL  uint32
+	resolved     bool
 	resolveError error
+	used         bool
+}
+
+func (e *cacheEntry) fresh(now time.Time) bool {
+	return e.SPF != "" && now.Before(e.Fetched.Add(time.Duration(e.TTL)*time.Second))
 }
 
 type cache struct {
 	records map[string]*cacheEntry
+	mode    CacheMode
+	now     func() time.Time
 
 	inner Resolver
 }
 
 // NewCache creates a new cache file named filename.
+// Only the records older than their TTL are looked up again.
 func NewCache(filename string) (CachingResolver, error) {
+	return NewCacheMode(filename, CacheTTL)
+}
+
+// NewCacheMode creates a new cache file named filename, that looks records
+// up according to mode.
+func NewCacheMode(filename string, mode CacheMode) (CachingResolver, error) {
+	c := &cache{
+		records: map[string]*cacheEntry{},
+		mode:    mode,
+		now:     time.Now,
+		inner:   LiveResolver{},
+	}
 	f, err := os.Open(filename)
 	if err != nil {
 		if os.IsNotExist(err) {
 			// doesn't exist, just make a new one
-			return &cache{
-				records: map[string]*cacheEntry{},
-				inner:   LiveResolver{},
-			}, nil
+			return c, nil
 		}
 		return nil, err
 	}
+	defer f.Close()
 	dec := json.NewDecoder(f)
-	recs := map[string]*cacheEntry{}
-	if err := dec.Decode(&recs); err != nil {
+	if err := dec.Decode(&c.records); err != nil {
 		return nil, err
 	}
-	return &cache{
-		records: recs,
-		inner:   LiveResolver{},
-	}, nil
+	return c, nil
 }
 
 func (c *cache) GetSPF(name string) (string, error) {
@@ -97,11 +244,28 @@ func (c *cache) GetSPF(name string) (string, error) {
 		entry = &cacheEntry{}
 		c.records[name] = entry
 	}
-	if entry.resolvedSPF == "" && entry.resolveError == nil {
-		entry.resolvedSPF, entry.resolveError = c.inner.GetSPF(name)
+	entry.used = true
+	switch {
+	case c.mode == CacheOffline:
+		if entry.SPF == "" {
+			return "", fmt.Errorf("%s is not in the SPF cache. Run dnscontrol update-spf-cache", name)
+		}
+		return entry.SPF, nil
+	case c.mode == CacheTTL && entry.fresh(c.now()):
+		return entry.SPF, nil
+	}
+	if !entry.resolved {
+		entry.resolved = true
+		if r, ok := c.inner.(interface {
+			GetSPFTTL(string) (string, uint32, error)
+		}); ok {
+			entry.resolvedSPF, entry.resolvedTTL, entry.resolveError = r.GetSPFTTL(name)
+		} else {
+			entry.resolvedSPF, entry.resolveError = c.inner.GetSPF(name)
+		}
 	}
 	// return cached value
-	if entry.SPF != "" {
+	if entry.SPF != "" && c.mode != CacheRefresh {
 		return entry.SPF, nil
 	}
 	// if not cached, return results of inner resolver
@@ -110,14 +274,29 @@ func (c *cache) GetSPF(name string) (string, error) {
 
 func (c *cache) ChangedRecords() []string {
 	names := []string{}
-	for name, entry := range c.records {
-		if entry.resolvedSPF != entry.SPF {
-			names = append(names, name)
+	for _, ch := range c.Changes() {
+		if ch.New != "" {
+			names = append(names, ch.Name)
 		}
 	}
 	return names
 }
 
+func (c *cache) Changes() []CacheChange {
+	changes := []CacheChange{}
+	for name, entry := range c.records {
+		if entry.resolved && entry.resolveError == nil && entry.resolvedSPF != entry.SPF {
+			changes = append(changes, CacheChange{Name: name, Old: entry.SPF, New: entry.resolvedSPF})
+		}
+		if c.mode == CacheRefresh && !entry.used {
+			// Save drops it.
+			changes = append(changes, CacheChange{Name: name, Old: entry.SPF})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	return changes
+}
+
 func (c *cache) ResolveErrors() (errs []error) {
 	for _, entry := range c.records {
 		if entry.resolveError != nil {
@@ -126,13 +305,21 @@ func (c *cache) ResolveErrors() (errs []error) {
 	}
 	return
 }
+
+// Save writes the records used by this run to filename, with the values
+// looked up, and the time and TTL of the lookups.
 func (c *cache) Save(filename string) error {
 	outRecs := make(map[string]*cacheEntry, len(c.records))
+	now := c.now().UTC().Truncate(time.Second)
 	for k, entry := range c.records {
-		// move resolved data into cached field
-		// only take those we actually resolved
-		if entry.resolvedSPF != "" {
-			entry.SPF = entry.resolvedSPF
+		if !entry.used {
+			continue
+		}
+		if entry.resolved && entry.resolvedSPF != "" {
+			// move resolved data into cached field
+			entry.SPF, entry.TTL, entry.Fetched = entry.resolvedSPF, entry.resolvedTTL, now
+		}
+		if entry.SPF != "" {
 			outRecs[k] = entry
 		}
 	}
@@ -0,0 +1,102 @@
+package spflib
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// ttlResolver counts its lookups, and answers with a TTL of 300.
+type ttlResolver struct {
+	mapResolver
+	lookups int
+}
+
+func (r *ttlResolver) GetSPFTTL(name string) (string, uint32, error) {
+	r.lookups++
+	spf, err := r.GetSPF(name)
+	return spf, 300, err
+}
+
+func newTestCache(t *testing.T, filename string, mode CacheMode, res *ttlResolver, now time.Time) *cache {
+	c, err := NewCacheMode(filename, mode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cc := c.(*cache)
+	cc.inner = res
+	cc.now = func() time.Time { return now }
+	return cc
+}
+
+func TestCacheModes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spfcache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "spfcache.json")
+	res := &ttlResolver{mapResolver: mapResolver{
+		"a.com": "v=spf1 ip4:10.0.0.1 -all",
+		"b.com": "v=spf1 ip4:10.0.0.2 -all",
+	}}
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// An empty offline cache has nothing.
+	c := newTestCache(t, filename, CacheOffline, res, now)
+	if _, err := c.GetSPF("a.com"); err == nil || res.lookups != 0 {
+		t.Fatalf("expected an error without lookups, got %v and %d lookups", err, res.lookups)
+	}
+
+	c = newTestCache(t, filename, CacheRefresh, res, now)
+	c.GetSPF("a.com")
+	c.GetSPF("b.com")
+	expected := []CacheChange{{"a.com", "", "v=spf1 ip4:10.0.0.1 -all"}, {"b.com", "", "v=spf1 ip4:10.0.0.2 -all"}}
+	if changes := c.Changes(); !reflect.DeepEqual(changes, expected) {
+		t.Errorf("expected %v, got %v", expected, changes)
+	}
+	if err := c.Save(filename); err != nil {
+		t.Fatal(err)
+	}
+
+	// The entries are fresh for 300 seconds.
+	res.lookups = 0
+	c = newTestCache(t, filename, CacheTTL, res, now.Add(time.Minute))
+	if spf, _ := c.GetSPF("a.com"); spf != "v=spf1 ip4:10.0.0.1 -all" || res.lookups != 0 {
+		t.Errorf("expected a cached record without lookups, got %q and %d lookups", spf, res.lookups)
+	}
+	res.mapResolver["a.com"] = "v=spf1 ip4:10.0.0.3 -all"
+	c = newTestCache(t, filename, CacheTTL, res, now.Add(time.Hour))
+	if spf, _ := c.GetSPF("a.com"); spf != "v=spf1 ip4:10.0.0.1 -all" || res.lookups != 1 {
+		t.Errorf("expected the cached record after a lookup, got %q and %d lookups", spf, res.lookups)
+	}
+	if changed := c.ChangedRecords(); !reflect.DeepEqual(changed, []string{"a.com"}) {
+		t.Errorf("expected a.com to change, got %v", changed)
+	}
+
+	// b.com isn't used anymore.
+	c = newTestCache(t, filename, CacheRefresh, res, now.Add(time.Hour))
+	c.GetSPF("a.com")
+	expected = []CacheChange{{"a.com", "v=spf1 ip4:10.0.0.1 -all", "v=spf1 ip4:10.0.0.3 -all"}, {"b.com", "v=spf1 ip4:10.0.0.2 -all", ""}}
+	if changes := c.Changes(); !reflect.DeepEqual(changes, expected) {
+		t.Errorf("expected %v, got %v", expected, changes)
+	}
+	if err := c.Save(filename); err != nil {
+		t.Fatal(err)
+	}
+
+	res.lookups = 0
+	c = newTestCache(t, filename, CacheOffline, res, now.Add(24*time.Hour))
+	if spf, _ := c.GetSPF("a.com"); spf != "v=spf1 ip4:10.0.0.3 -all" || res.lookups != 0 {
+		t.Errorf("expected the cached record without lookups, got %q and %d lookups", spf, res.lookups)
+	}
+	if _, err := c.GetSPF("b.com"); err == nil {
+		t.Errorf("expected b.com to be dropped from the cache")
+	}
+	if entry := c.records["a.com"]; entry.TTL != 300 || !entry.Fetched.Equal(now.Add(time.Hour)) {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+}
@@ -0,0 +1,467 @@
+// Package yamlconfig reads the declarative (YAML or JSON) configuration format,
+// an alternative to dnsconfig.js for people who would rather not write javascript.
+// The result is the same *models.DNSConfig that the javascript produces, so it
+// must still be passed through normalize.NormalizeAndValidateConfig.
+package yamlconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/StackExchange/dnscontrol/models"
+	"gopkg.in/yaml.v2"
+)
+
+// File is the top level of a declarative configuration file.
+type File struct {
+	Registrars map[string]*Provider `yaml:"registrars"`
+	Providers  map[string]*Provider `yaml:"providers"`
+	Defaults   *Domain              `yaml:"defaults"`
+	Domains    []*Domain            `yaml:"domains"`
+}
+
+// Provider declares a registrar or a DNS provider.
+type Provider struct {
+	Type string                 `yaml:"type"`
+	Meta map[string]interface{} `yaml:"meta"`
+}
+
+// Domain declares a domain. The same structure is used for the defaults,
+// which are applied to every domain.
+type Domain struct {
+	Name        string            `yaml:"name"`
+	Registrar   string            `yaml:"registrar"`
+	Providers   ProviderList      `yaml:"providers"`
+	TTL         Duration          `yaml:"ttl"`
+	Meta        map[string]string `yaml:"meta"`
+	NoPurge     *bool             `yaml:"no_purge"`
+	Nameservers []string          `yaml:"nameservers"`
+	Records     []*Record         `yaml:"records"`
+}
+
+// Record declares a DNS record. Which fields are required depends on the type.
+type Record struct {
+	Type         string                 `yaml:"type"`
+	Name         string                 `yaml:"name"`
+	Target       StringList             `yaml:"target"`
+	TTL          Duration               `yaml:"ttl"`
+	Meta         map[string]string      `yaml:"meta"`
+	Priority     *uint16                `yaml:"priority"`
+	Weight       *uint16                `yaml:"weight"`
+	Port         *uint16                `yaml:"port"`
+	Tag          string                 `yaml:"tag"`
+	Flag         uint8                  `yaml:"flag"`
+	Usage        *uint8                 `yaml:"usage"`
+	Selector     *uint8                 `yaml:"selector"`
+	MatchingType *uint8                 `yaml:"matchingtype"`
+	Mbox         string                 `yaml:"mbox"`
+	Refresh      *uint32                `yaml:"refresh"`
+	Retry        *uint32                `yaml:"retry"`
+	Expire       *uint32                `yaml:"expire"`
+	Minttl       *uint32                `yaml:"minttl"`
+	Actions      map[string]interface{} `yaml:"actions"`
+}
+
+// ProviderList maps DNS provider names to the number of their nameservers to use (-1 means all).
+// It may be written as a list of names, or as a map of name to count.
+type ProviderList map[string]int
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (p *ProviderList) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var names []string
+	if err := unmarshal(&names); err == nil {
+		*p = ProviderList{}
+		for _, n := range names {
+			(*p)[n] = -1
+		}
+		return nil
+	}
+	var counts map[string]int
+	if err := unmarshal(&counts); err != nil {
+		return fmt.Errorf("providers must be a list of names or a map of name to nameserver count")
+	}
+	*p = ProviderList(counts)
+	return nil
+}
+
+// StringList is a string, or a list of strings (only valid for TXT records).
+type StringList []string
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (s *StringList) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var one string
+	if err := unmarshal(&one); err == nil {
+		*s = StringList{one}
+		return nil
+	}
+	var many []string
+	if err := unmarshal(&many); err != nil {
+		return fmt.Errorf("target must be a string or a list of strings")
+	}
+	*s = StringList(many)
+	return nil
+}
+
+// Duration is a TTL in seconds. It may be written as a number, or as a
+// string with a unit suffix, the same as TTL() in dnsconfig.js: "5m", "1h", "2d".
+type Duration uint32
+
+var durationRe = regexp.MustCompile(`^(\d+)([smhdwny]?)$`)
+
+var durationUnits = map[string]uint64{
+	"":  1,
+	"s": 1,
+	"m": 60,
+	"h": 60 * 60,
+	"d": 24 * 60 * 60,
+	"w": 7 * 24 * 60 * 60,
+	"n": 30 * 24 * 60 * 60,
+	"y": 365 * 24 * 60 * 60,
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (d *Duration) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	m := durationRe.FindStringSubmatch(s)
+	if m == nil {
+		return fmt.Errorf("%q is not a valid duration", s)
+	}
+	n, err := strconv.ParseUint(m[1], 10, 32)
+	if err != nil {
+		return fmt.Errorf("%q is not a valid duration", s)
+	}
+	n *= durationUnits[m[2]]
+	if n > 1<<32-1 {
+		return fmt.Errorf("%q is too large", s)
+	}
+	*d = Duration(n)
+	return nil
+}
+
+// ParseFile reads a declarative configuration file and compiles it to a DNSConfig.
+func ParseFile(filename string) (*models.DNSConfig, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", filename, err)
+	}
+	return cfg, nil
+}
+
+// Parse compiles the YAML (or JSON) text of a declarative configuration file to a DNSConfig.
+// Unknown keys and structurally invalid declarations are reported as errors.
+func Parse(data []byte) (*models.DNSConfig, error) {
+	f := &File{}
+	if err := yaml.UnmarshalStrict(data, f); err != nil {
+		return nil, err
+	}
+	return f.Compile()
+}
+
+// Compile validates the declarations and converts them to a DNSConfig.
+func (f *File) Compile() (*models.DNSConfig, error) {
+	cfg := &models.DNSConfig{
+		Registrars:   []*models.RegistrarConfig{},
+		DNSProviders: []*models.DNSProviderConfig{},
+		Domains:      []*models.DomainConfig{},
+	}
+	for _, name := range sortedKeys(f.Registrars) {
+		p := f.Registrars[name]
+		meta, err := p.metadata("registrar " + name)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Registrars = append(cfg.Registrars, &models.RegistrarConfig{Name: name, Type: p.Type, Metadata: meta})
+	}
+	for _, name := range sortedKeys(f.Providers) {
+		p := f.Providers[name]
+		meta, err := p.metadata("provider " + name)
+		if err != nil {
+			return nil, err
+		}
+		cfg.DNSProviders = append(cfg.DNSProviders, &models.DNSProviderConfig{Name: name, Type: p.Type, Metadata: meta})
+	}
+
+	defaults := f.Defaults
+	if defaults == nil {
+		defaults = &Domain{}
+	}
+	if defaults.Name != "" {
+		return nil, fmt.Errorf("defaults: name can not be set")
+	}
+	seen := map[string]bool{}
+	for i, d := range f.Domains {
+		if d == nil || d.Name == "" {
+			return nil, fmt.Errorf("domains[%d]: name is required", i)
+		}
+		if seen[d.Name] {
+			return nil, fmt.Errorf("domain %s is declared more than once", d.Name)
+		}
+		seen[d.Name] = true
+		dc, err := d.compile(defaults, f)
+		if err != nil {
+			return nil, fmt.Errorf("domain %s: %s", d.Name, err)
+		}
+		cfg.Domains = append(cfg.Domains, dc)
+	}
+	return cfg, nil
+}
+
+func (p *Provider) metadata(desc string) (json.RawMessage, error) {
+	if p == nil || p.Type == "" {
+		return nil, fmt.Errorf("%s: type is required", desc)
+	}
+	if len(p.Meta) == 0 {
+		return nil, nil
+	}
+	m, err := json.Marshal(jsonCompatible(p.Meta))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", desc, err)
+	}
+	return m, nil
+}
+
+// jsonCompatible converts the map[interface{}]interface{} values produced by
+// the yaml decoder into map[string]interface{} so that they can be marshaled to json.
+func jsonCompatible(v interface{}) interface{} {
+	switch x := v.(type) {
+	case map[interface{}]interface{}:
+		m := map[string]interface{}{}
+		for k, val := range x {
+			m[fmt.Sprint(k)] = jsonCompatible(val)
+		}
+		return m
+	case map[string]interface{}:
+		m := map[string]interface{}{}
+		for k, val := range x {
+			m[k] = jsonCompatible(val)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(x))
+		for i, val := range x {
+			l[i] = jsonCompatible(val)
+		}
+		return l
+	default:
+		return v
+	}
+}
+
+func (d *Domain) compile(defaults *Domain, f *File) (*models.DomainConfig, error) {
+	dc := &models.DomainConfig{
+		Name:         d.Name,
+		Registrar:    d.Registrar,
+		DNSProviders: map[string]int{},
+		Metadata:     map[string]string{},
+		Records:      models.Records{},
+		Nameservers:  []*models.Nameserver{},
+	}
+	if dc.Registrar == "" {
+		dc.Registrar = defaults.Registrar
+	}
+	if dc.Registrar == "" {
+		return nil, fmt.Errorf("registrar is required")
+	}
+	if _, ok := f.Registrars[dc.Registrar]; !ok {
+		return nil, fmt.Errorf("registrar %s is not declared", dc.Registrar)
+	}
+
+	provs := d.Providers
+	if provs == nil {
+		provs = defaults.Providers
+	}
+	for name, n := range provs {
+		if _, ok := f.Providers[name]; !ok {
+			return nil, fmt.Errorf("provider %s is not declared", name)
+		}
+		dc.DNSProviders[name] = n
+	}
+
+	for k, v := range defaults.Meta {
+		dc.Metadata[k] = v
+	}
+	for k, v := range d.Meta {
+		dc.Metadata[k] = v
+	}
+
+	if d.NoPurge != nil {
+		dc.KeepUnknown = *d.NoPurge
+	} else if defaults.NoPurge != nil {
+		dc.KeepUnknown = *defaults.NoPurge
+	}
+
+	nameservers := d.Nameservers
+	if nameservers == nil {
+		nameservers = defaults.Nameservers
+	}
+	for _, ns := range nameservers {
+		dc.Nameservers = append(dc.Nameservers, &models.Nameserver{Name: ns})
+	}
+
+	ttl := d.TTL
+	if ttl == 0 {
+		ttl = defaults.TTL
+	}
+	records := append(append([]*Record{}, defaults.Records...), d.Records...)
+	for i, r := range records {
+		rc, err := r.compile(d.Name, uint32(ttl))
+		if err != nil {
+			return nil, fmt.Errorf("records[%d]: %s", i, err)
+		}
+		dc.Records = append(dc.Records, rc)
+	}
+	return dc, nil
+}
+
+// recordTypes lists the record types that may be declared, along with the
+// fields (other than name and target) that each requires.
+var recordTypes = map[string][]string{
+	"A":                nil,
+	"AAAA":             nil,
+	"ALIAS":            nil,
+	"CAA":              {"tag"},
+	"CNAME":            nil,
+	"MX":               {"priority"},
+	"NS":               nil,
+	"PTR":              nil,
+	"SOA":              {"mbox", "refresh", "retry", "expire", "minttl"},
+	"SRV":              {"priority", "weight", "port"},
+	"TLSA":             {"usage", "selector", "matchingtype"},
+	"TXT":              nil,
+	"CF_PAGE_RULE":     {"priority", "actions"},
+	"CF_REDIRECT":      nil,
+	"CF_TEMP_REDIRECT": nil,
+	"R53_HEALTH_CHECK": nil,
+	"URL":              nil,
+	"URL301":           nil,
+	"FRAME":            nil,
+}
+
+func (r *Record) compile(domain string, defaultTTL uint32) (*models.RecordConfig, error) {
+	if r == nil {
+		return nil, fmt.Errorf("empty record")
+	}
+	required, ok := recordTypes[r.Type]
+	if !ok {
+		return nil, fmt.Errorf("unsupported record type %q", r.Type)
+	}
+	if r.Name == "" {
+		return nil, fmt.Errorf("%s record requires a name", r.Type)
+	}
+	if len(r.Target) == 0 {
+		return nil, fmt.Errorf("%s record %s requires a target", r.Type, r.Name)
+	}
+	if len(r.Target) > 1 && r.Type != "TXT" {
+		return nil, fmt.Errorf("%s record %s: only TXT records may have a list of targets", r.Type, r.Name)
+	}
+	set := map[string]bool{
+		"priority":     r.Priority != nil,
+		"weight":       r.Weight != nil,
+		"port":         r.Port != nil,
+		"tag":          r.Tag != "",
+		"usage":        r.Usage != nil,
+		"selector":     r.Selector != nil,
+		"matchingtype": r.MatchingType != nil,
+		"mbox":         r.Mbox != "",
+		"refresh":      r.Refresh != nil,
+		"retry":        r.Retry != nil,
+		"expire":       r.Expire != nil,
+		"minttl":       r.Minttl != nil,
+		"actions":      r.Actions != nil,
+	}
+	for _, field := range required {
+		if !set[field] {
+			return nil, fmt.Errorf("%s record %s requires %s", r.Type, r.Name, field)
+		}
+		delete(set, field)
+	}
+	for field, isSet := range set {
+		if isSet {
+			return nil, fmt.Errorf("%s record %s does not take %s", r.Type, r.Name, field)
+		}
+	}
+	if r.Flag != 0 && r.Type != "CAA" {
+		return nil, fmt.Errorf("%s record %s does not take flag", r.Type, r.Name)
+	}
+
+	rc := &models.RecordConfig{
+		Type:     r.Type,
+		Name:     shortName(r.Name, domain),
+		Target:   r.Target[0],
+		TTL:      uint32(r.TTL),
+		Metadata: map[string]string{},
+	}
+	if rc.TTL == 0 {
+		rc.TTL = defaultTTL
+	}
+	for k, v := range r.Meta {
+		rc.Metadata[k] = v
+	}
+	switch r.Type {
+	case "MX":
+		rc.MxPreference = *r.Priority
+	case "SRV":
+		rc.SrvPriority, rc.SrvWeight, rc.SrvPort = *r.Priority, *r.Weight, *r.Port
+	case "CAA":
+		rc.CaaTag, rc.CaaFlag = r.Tag, r.Flag
+	case "SOA":
+		rc.SoaMbox = r.Mbox
+		rc.SoaRefresh, rc.SoaRetry, rc.SoaExpire, rc.SoaMinttl = *r.Refresh, *r.Retry, *r.Expire, *r.Minttl
+	case "TLSA":
+		rc.TlsaUsage, rc.TlsaSelector, rc.TlsaMatchingType = *r.Usage, *r.Selector, *r.MatchingType
+	case "TXT":
+		rc.TxtStrings = []string(r.Target)
+	case "CF_PAGE_RULE":
+		if rc.Name != "@" {
+			return nil, fmt.Errorf(`%s must be declared on "@" with the URL pattern as target`, r.Type)
+		}
+		actions, err := json.Marshal(jsonCompatible(r.Actions))
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %s", r.Type, rc.Target, err)
+		}
+		rc.Metadata["cloudflare_page_rule_actions"] = string(actions)
+		rc.Metadata["cloudflare_page_rule_priority"] = strconv.Itoa(int(*r.Priority))
+	case "CF_REDIRECT", "CF_TEMP_REDIRECT":
+		if rc.Name != "@" || strings.Count(rc.Target, ",") != 1 {
+			return nil, fmt.Errorf(`%s must be declared on "@" with a target of "source,destination"`, r.Type)
+		}
+	}
+	return rc, nil
+}
+
+// shortName converts a fully qualified name (one ending in a dot) to a name
+// relative to the domain. Names without a trailing dot are returned unchanged.
+func shortName(name, domain string) string {
+	if !strings.HasSuffix(name, ".") {
+		return name
+	}
+	fqdn := strings.TrimSuffix(name, ".")
+	if strings.EqualFold(fqdn, domain) {
+		return "@"
+	}
+	if strings.HasSuffix(strings.ToLower(fqdn), "."+strings.ToLower(domain)) {
+		return fqdn[:len(fqdn)-len(domain)-1]
+	}
+	return name
+}
+
+func sortedKeys(m map[string]*Provider) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
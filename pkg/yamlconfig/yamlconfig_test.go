@@ -0,0 +1,154 @@
+package yamlconfig
+
+import (
+	"testing"
+
+	"github.com/StackExchange/dnscontrol/pkg/normalize"
+)
+
+const example = `
+registrars:
+  none:
+    type: NONE
+providers:
+  bind:
+    type: BIND
+    meta:
+      default_soa:
+        master: ns1.example.com.
+defaults:
+  registrar: none
+  providers: [bind]
+  ttl: 1h
+  records:
+    - {type: TXT, name: "@", target: "v=spf1 -all"}
+domains:
+  - name: example.com
+    meta:
+      cloudflare_proxy_default: on
+    records:
+      - {type: A, name: "@", target: 1.2.3.4}
+      - {type: A, name: www.example.com., target: 1.2.3.5, ttl: 300}
+      - {type: MX, name: "@", priority: 10, target: mx}
+  - name: example.net
+    providers: {bind: 0}
+    no_purge: true
+`
+
+func TestParse(t *testing.T) {
+	cfg, err := Parse([]byte(example))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Registrars) != 1 || len(cfg.DNSProviders) != 1 || len(cfg.Domains) != 2 {
+		t.Fatalf("unexpected config: %d registrars, %d providers, %d domains", len(cfg.Registrars), len(cfg.DNSProviders), len(cfg.Domains))
+	}
+	if string(cfg.DNSProviders[0].Metadata) != `{"default_soa":{"master":"ns1.example.com."}}` {
+		t.Errorf("provider meta = %s", cfg.DNSProviders[0].Metadata)
+	}
+
+	com := cfg.Domains[0]
+	if com.Registrar != "none" || com.DNSProviders["bind"] != -1 {
+		t.Errorf("defaults not applied: %s %v", com.Registrar, com.DNSProviders)
+	}
+	if com.Metadata["cloudflare_proxy_default"] != "on" {
+		t.Errorf("meta = %v", com.Metadata)
+	}
+	if len(com.Records) != 4 {
+		t.Fatalf("expected 4 records, got %d", len(com.Records))
+	}
+	if com.Records[0].Type != "TXT" || com.Records[0].TTL != 3600 {
+		t.Errorf("default record = %v", com.Records[0])
+	}
+	if r := com.Records[2]; r.Name != "www" || r.TTL != 300 {
+		t.Errorf("fqdn record name = %s ttl = %d", r.Name, r.TTL)
+	}
+	if r := com.Records[3]; r.MxPreference != 10 {
+		t.Errorf("mx preference = %d", r.MxPreference)
+	}
+
+	net := cfg.Domains[1]
+	if net.DNSProviders["bind"] != 0 || !net.KeepUnknown {
+		t.Errorf("example.net = %v %v", net.DNSProviders, net.KeepUnknown)
+	}
+
+	if errs := normalize.NormalizeAndValidateConfig(cfg); len(errs) != 0 {
+		t.Fatalf("validation failed: %v", errs)
+	}
+	if com.Records[3].Target != "mx.example.com." {
+		t.Errorf("mx target not normalized: %s", com.Records[3].Target)
+	}
+}
+
+func TestParseRecordFields(t *testing.T) {
+	text := `
+registrars: {none: {type: NONE}}
+domains:
+  - name: example.com
+    registrar: none
+    records:
+      - {type: SRV, name: _sip._tcp, priority: 1, weight: 2, port: 5060, target: sip.example.com.}
+      - {type: TXT, name: multi, target: [one, two]}
+      - {type: CAA, name: "@", tag: issue, flag: 128, target: letsencrypt.org}
+      - {type: TLSA, name: _443._tcp, usage: 3, selector: 1, matchingtype: 1, target: abcdef}
+      - {type: CNAME, name: example.com., target: foo.example.net.}
+      - {type: CF_PAGE_RULE, name: "@", target: "example.com/*", priority: 2, actions: {cache_level: bypass, minify: {css: "on"}}}
+`
+	cfg, err := Parse([]byte(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	recs := cfg.Domains[0].Records
+	if r := recs[0]; r.SrvPriority != 1 || r.SrvWeight != 2 || r.SrvPort != 5060 {
+		t.Errorf("srv = %v", r)
+	}
+	if r := recs[1]; len(r.TxtStrings) != 2 || r.Target != "one" {
+		t.Errorf("txt = %v", r.TxtStrings)
+	}
+	if r := recs[2]; r.CaaTag != "issue" || r.CaaFlag != 128 {
+		t.Errorf("caa = %v", r)
+	}
+	if r := recs[3]; r.TlsaUsage != 3 || r.TlsaSelector != 1 || r.TlsaMatchingType != 1 {
+		t.Errorf("tlsa = %v", r)
+	}
+	if r := recs[4]; r.Name != "@" {
+		t.Errorf("apex fqdn name = %s", r.Name)
+	}
+	if m := recs[5].Metadata; m["cloudflare_page_rule_actions"] != `{"cache_level":"bypass","minify":{"css":"on"}}` || m["cloudflare_page_rule_priority"] != "2" {
+		t.Errorf("page rule = %v", m)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct{ desc, text string }{
+		{"unknown key", "domainz: []"},
+		{"provider type", "providers: {bind: {}}"},
+		{"no registrar", "registrars: {none: {type: NONE}}\ndomains: [{name: a.com}]"},
+		{"undeclared registrar", "domains: [{name: a.com, registrar: none}]"},
+		{"undeclared provider", "registrars: {none: {type: NONE}}\ndomains: [{name: a.com, registrar: none, providers: [bind]}]"},
+		{"dup domain", "registrars: {none: {type: NONE}}\ndomains: [{name: a.com, registrar: none}, {name: a.com, registrar: none}]"},
+		{"bad type", "registrars: {none: {type: NONE}}\ndomains: [{name: a.com, registrar: none, records: [{type: FOO, name: x, target: y}]}]"},
+		{"mx without priority", "registrars: {none: {type: NONE}}\ndomains: [{name: a.com, registrar: none, records: [{type: MX, name: x, target: y}]}]"},
+		{"a with priority", "registrars: {none: {type: NONE}}\ndomains: [{name: a.com, registrar: none, records: [{type: A, name: x, target: 1.2.3.4, priority: 1}]}]"},
+		{"list target", "registrars: {none: {type: NONE}}\ndomains: [{name: a.com, registrar: none, records: [{type: A, name: x, target: [1.2.3.4, 1.2.3.5]}]}]"},
+		{"bad ttl", "registrars: {none: {type: NONE}}\ndomains: [{name: a.com, registrar: none, ttl: 5q}]"},
+	}
+	for _, tst := range tests {
+		t.Run(tst.desc, func(t *testing.T) {
+			if _, err := Parse([]byte(tst.text)); err == nil {
+				t.Fatal("Expected error but found none")
+			}
+		})
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	text := `{"registrars": {"none": {"type": "NONE"}}, "domains": [{"name": "a.com", "registrar": "none", "records": [{"type": "A", "name": "@", "target": "1.2.3.4", "ttl": 60}]}]}`
+	cfg, err := Parse([]byte(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := cfg.Domains[0].Records[0]; r.Target != "1.2.3.4" || r.TTL != 60 {
+		t.Errorf("record = %v", r)
+	}
+}
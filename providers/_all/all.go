@@ -4,6 +4,7 @@ package all
 import (
 	// Define all known providers here. They should each register themselves with the providers package via init function.
 	_ "github.com/StackExchange/dnscontrol/providers/activedir"
+	_ "github.com/StackExchange/dnscontrol/providers/azuredns"
 	_ "github.com/StackExchange/dnscontrol/providers/bind"
 	_ "github.com/StackExchange/dnscontrol/providers/cloudflare"
 	_ "github.com/StackExchange/dnscontrol/providers/digitalocean"
@@ -11,10 +12,13 @@ import (
 	_ "github.com/StackExchange/dnscontrol/providers/gandi"
 	_ "github.com/StackExchange/dnscontrol/providers/gcloud"
 	_ "github.com/StackExchange/dnscontrol/providers/linode"
+	_ "github.com/StackExchange/dnscontrol/providers/memory"
 	_ "github.com/StackExchange/dnscontrol/providers/namecheap"
 	_ "github.com/StackExchange/dnscontrol/providers/namedotcom"
 	_ "github.com/StackExchange/dnscontrol/providers/ns1"
 	_ "github.com/StackExchange/dnscontrol/providers/ovh"
+	_ "github.com/StackExchange/dnscontrol/providers/powerdns"
+	_ "github.com/StackExchange/dnscontrol/providers/rfc2136"
 	_ "github.com/StackExchange/dnscontrol/providers/route53"
 	_ "github.com/StackExchange/dnscontrol/providers/softlayer"
 	_ "github.com/StackExchange/dnscontrol/providers/vultr"
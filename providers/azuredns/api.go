@@ -0,0 +1,271 @@
+package azuredns
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	apiVersion           = "2018-05-01"
+	defaultManagementURL = "https://management.azure.com"
+	defaultLoginURL      = "https://login.microsoftonline.com"
+)
+
+// servicePrincipal fetches tokens for the management API with the
+// OAuth2 client credentials grant.
+type servicePrincipal struct {
+	loginURL     string
+	tenantID     string
+	clientID     string
+	clientSecret string
+	scope        string
+}
+
+// Token implements oauth2.TokenSource.
+func (s *servicePrincipal) Token() (*oauth2.Token, error) {
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {s.clientID},
+		"client_secret": {s.clientSecret},
+		"scope":         {s.scope},
+	}
+	u := fmt.Sprintf("%s/%s/oauth2/v2.0/token", strings.TrimSuffix(s.loginURL, "/"), url.PathEscape(s.tenantID))
+	resp, err := http.PostForm(u, form)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	tok := &struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int64  `json:"expires_in"`
+		Error       string `json:"error_description"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(tok); err != nil {
+		return nil, fmt.Errorf("Azure login failed: %s", resp.Status)
+	}
+	if resp.StatusCode != http.StatusOK || tok.AccessToken == "" {
+		return nil, fmt.Errorf("Azure login failed: %s: %s", resp.Status, tok.Error)
+	}
+	return &oauth2.Token{
+		AccessToken: tok.AccessToken,
+		TokenType:   tok.TokenType,
+		Expiry:      time.Now().Add(time.Duration(tok.ExpiresIn) * time.Second),
+	}, nil
+}
+
+type api struct {
+	client         *http.Client
+	managementURL  string
+	subscriptionID string
+	resourceGroup  string
+}
+
+func newAPI(hc *http.Client, sp *servicePrincipal, managementURL, subscriptionID, resourceGroup string) *api {
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, hc)
+	return &api{
+		client:         oauth2.NewClient(ctx, oauth2.ReuseTokenSource(nil, sp)),
+		managementURL:  managementURL,
+		subscriptionID: subscriptionID,
+		resourceGroup:  resourceGroup,
+	}
+}
+
+type zone struct {
+	Name       string `json:"name,omitempty"`
+	Location   string `json:"location"`
+	Properties struct {
+		NameServers []string `json:"nameServers,omitempty"`
+	} `json:"properties"`
+}
+
+type zoneList struct {
+	Value    []zone `json:"value"`
+	NextLink string `json:"nextLink"`
+}
+
+// recordSet is a Microsoft.Network/dnszones record set. Only the list
+// matching the type of the set is populated.
+type recordSet struct {
+	Name       string `json:"name,omitempty"`
+	Type       string `json:"type,omitempty"`
+	Properties struct {
+		TTL         uint32           `json:"TTL"`
+		FQDN        string           `json:"fqdn,omitempty"`
+		ARecords    []aRecord        `json:"ARecords,omitempty"`
+		AAAARecords []aaaaRecord     `json:"AAAARecords,omitempty"`
+		CAARecords  []caaRecord      `json:"caaRecords,omitempty"`
+		CNAMERecord *cnameRecord     `json:"CNAMERecord,omitempty"`
+		MXRecords   []mxRecord       `json:"MXRecords,omitempty"`
+		NSRecords   []nsRecord       `json:"NSRecords,omitempty"`
+		PTRRecords  []ptrRecord      `json:"PTRRecords,omitempty"`
+		SRVRecords  []srvRecord      `json:"SRVRecords,omitempty"`
+		TXTRecords  []txtRecord      `json:"TXTRecords,omitempty"`
+		SOARecord   *json.RawMessage `json:"SOARecord,omitempty"`
+	} `json:"properties"`
+}
+
+type aRecord struct {
+	IPv4Address string `json:"ipv4Address"`
+}
+
+type aaaaRecord struct {
+	IPv6Address string `json:"ipv6Address"`
+}
+
+type caaRecord struct {
+	Flags uint8  `json:"flags"`
+	Tag   string `json:"tag"`
+	Value string `json:"value"`
+}
+
+type cnameRecord struct {
+	CNAME string `json:"cname"`
+}
+
+type mxRecord struct {
+	Preference uint16 `json:"preference"`
+	Exchange   string `json:"exchange"`
+}
+
+type nsRecord struct {
+	NSDName string `json:"nsdname"`
+}
+
+type ptrRecord struct {
+	PTRDName string `json:"ptrdname"`
+}
+
+type srvRecord struct {
+	Priority uint16 `json:"priority"`
+	Weight   uint16 `json:"weight"`
+	Port     uint16 `json:"port"`
+	Target   string `json:"target"`
+}
+
+type txtRecord struct {
+	Value []string `json:"value"`
+}
+
+type recordSetList struct {
+	Value    []recordSet `json:"value"`
+	NextLink string      `json:"nextLink"`
+}
+
+type errorResponse struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (c *api) zonesURL() string {
+	return fmt.Sprintf("%s/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Network/dnsZones",
+		strings.TrimSuffix(c.managementURL, "/"), url.PathEscape(c.subscriptionID), url.PathEscape(c.resourceGroup))
+}
+
+func (c *api) zoneURL(name string) string {
+	return c.zonesURL() + "/" + url.PathEscape(name)
+}
+
+func (c *api) recordSetURL(zoneName, rtype, name string) string {
+	return fmt.Sprintf("%s/%s/%s", c.zoneURL(zoneName), rtype, url.PathEscape(name))
+}
+
+func withVersion(u string) string {
+	return u + "?api-version=" + apiVersion
+}
+
+func (c *api) do(method, u string, body, target interface{}) (int, error) {
+	buf := &bytes.Buffer{}
+	if body != nil {
+		if err := json.NewEncoder(buf).Encode(body); err != nil {
+			return 0, err
+		}
+	}
+	req, err := http.NewRequest(method, u, buf)
+	if err != nil {
+		return 0, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		e := &errorResponse{}
+		if err := json.NewDecoder(resp.Body).Decode(e); err != nil || e.Error.Message == "" {
+			return resp.StatusCode, fmt.Errorf("Azure DNS %s %s: %s", method, u, resp.Status)
+		}
+		return resp.StatusCode, fmt.Errorf("Azure DNS %s %s: %s: %s", method, u, e.Error.Code, e.Error.Message)
+	}
+	if target != nil {
+		return resp.StatusCode, json.NewDecoder(resp.Body).Decode(target)
+	}
+	return resp.StatusCode, nil
+}
+
+func (c *api) listZones() ([]zone, error) {
+	zones := []zone{}
+	for u := withVersion(c.zonesURL()); u != ""; {
+		page := &zoneList{}
+		if _, err := c.do(http.MethodGet, u, nil, page); err != nil {
+			return nil, err
+		}
+		zones = append(zones, page.Value...)
+		u = page.NextLink
+	}
+	return zones, nil
+}
+
+// getZone returns the zone, or nil if it does not exist.
+func (c *api) getZone(name string) (*zone, error) {
+	z := &zone{}
+	status, err := c.do(http.MethodGet, withVersion(c.zoneURL(name)), nil, z)
+	if status == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return z, nil
+}
+
+func (c *api) createZone(name string) error {
+	_, err := c.do(http.MethodPut, withVersion(c.zoneURL(name)), &zone{Location: "global"}, nil)
+	return err
+}
+
+func (c *api) listRecordSets(zoneName string) ([]recordSet, error) {
+	sets := []recordSet{}
+	for u := withVersion(c.zoneURL(zoneName) + "/recordsets"); u != ""; {
+		page := &recordSetList{}
+		if _, err := c.do(http.MethodGet, u, nil, page); err != nil {
+			return nil, err
+		}
+		sets = append(sets, page.Value...)
+		u = page.NextLink
+	}
+	return sets, nil
+}
+
+func (c *api) putRecordSet(zoneName, rtype, name string, set *recordSet) error {
+	_, err := c.do(http.MethodPut, withVersion(c.recordSetURL(zoneName, rtype, name)), set, nil)
+	return err
+}
+
+func (c *api) deleteRecordSet(zoneName, rtype, name string) error {
+	_, err := c.do(http.MethodDelete, withVersion(c.recordSetURL(zoneName, rtype, name)), nil, nil)
+	return err
+}
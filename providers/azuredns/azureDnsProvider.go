@@ -0,0 +1,259 @@
+package azuredns
+
+/*
+
+Azure DNS provider:
+
+Info required in `creds.json`:
+   - tenant_id
+   - client_id
+   - client_secret
+   - subscription_id
+   - resource_group
+
+*/
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/miekg/dns/dnsutil"
+
+	"github.com/StackExchange/dnscontrol/models"
+	"github.com/StackExchange/dnscontrol/providers"
+	"github.com/StackExchange/dnscontrol/providers/diff"
+)
+
+var features = providers.DocumentationNotes{
+	providers.CanUseCAA:              providers.Can(),
+	providers.CanUsePTR:              providers.Can(),
+	providers.CanUseSRV:              providers.Can(),
+	providers.CanUseTLSA:             providers.Cannot(),
+	providers.CanUseTXTMulti:         providers.Can(),
+	providers.DocCreateDomains:       providers.Can(),
+	providers.DocDualHost:            providers.Can("Azure does not allow changing the apex NS records to other providers' nameservers, but extra ones can be added"),
+	providers.DocOfficiallySupported: providers.Cannot(),
+}
+
+func init() {
+	providers.RegisterDomainServiceProviderType("AZURE_DNS", newProvider, features)
+}
+
+// Provider is the provider handle for the Azure DNS driver.
+type Provider struct {
+	*api
+}
+
+func newProvider(creds map[string]string, meta json.RawMessage) (providers.DNSServiceProvider, error) {
+	for _, key := range []string{"tenant_id", "client_id", "client_secret", "subscription_id", "resource_group"} {
+		if creds[key] == "" {
+			return nil, fmt.Errorf("%s required for AZURE_DNS", key)
+		}
+	}
+	sp := &servicePrincipal{
+		loginURL:     defaultLoginURL,
+		tenantID:     creds["tenant_id"],
+		clientID:     creds["client_id"],
+		clientSecret: creds["client_secret"],
+		scope:        defaultManagementURL + "/.default",
+	}
+	hc, err := providers.NewHTTPClient("AZURE_DNS", creds)
+	if err != nil {
+		return nil, err
+	}
+	return &Provider{newAPI(hc, sp, defaultManagementURL, creds["subscription_id"], creds["resource_group"])}, nil
+}
+
+// GetNameservers returns the nameservers Azure assigned to the zone.
+func (p *Provider) GetNameservers(domain string) ([]*models.Nameserver, error) {
+	z, err := p.getZone(domain)
+	if err != nil {
+		return nil, err
+	}
+	if z == nil {
+		// The zone will get its nameservers when it is created.
+		return nil, nil
+	}
+	nss := []string{}
+	for _, ns := range z.Properties.NameServers {
+		nss = append(nss, strings.TrimSuffix(ns, "."))
+	}
+	return models.StringsToNameservers(nss), nil
+}
+
+// EnsureDomainExists creates the zone if it does not exist.
+func (p *Provider) EnsureDomainExists(domain string) error {
+	zones, err := p.listZones()
+	if err != nil {
+		return err
+	}
+	for _, z := range zones {
+		if strings.EqualFold(z.Name, domain) {
+			return nil
+		}
+	}
+	fmt.Printf("Adding zone for %s to Azure DNS in resource group %s\n", domain, p.resourceGroup)
+	return p.createZone(domain)
+}
+
+// GetDomainCorrections returns a list of corrections to update a domain.
+func (p *Provider) GetDomainCorrections(dc *models.DomainConfig) ([]*models.Correction, error) {
+	dc.Punycode()
+	z, err := p.getZone(dc.Name)
+	if err != nil {
+		return nil, err
+	}
+	if z == nil {
+		return nil, fmt.Errorf("%s not found in Azure resource group %s", dc.Name, p.resourceGroup)
+	}
+	sets, err := p.listRecordSets(dc.Name)
+	if err != nil {
+		return nil, err
+	}
+	desiredGrouped := dc.Records.Grouped()
+	// Without NAMESERVER(), the apex NS records Azure created with the zone
+	// are left alone rather than deleted.
+	_, manageApexNS := desiredGrouped[models.RecordKey{Name: "@", Type: "NS"}]
+
+	existing := models.Records{}
+	for _, set := range sets {
+		for _, rc := range toRecordConfigs(dc.Name, set) {
+			if rc.Type == "NS" && rc.Name == "@" && !manageApexNS {
+				continue
+			}
+			existing = append(existing, rc)
+		}
+	}
+	models.PostProcessRecords(existing)
+
+	changedGroups := diff.New(dc).ChangedGroups(existing)
+	keys := make([]models.RecordKey, 0, len(changedGroups))
+	for k := range changedGroups {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Name == keys[j].Name {
+			return keys[i].Type < keys[j].Type
+		}
+		return keys[i].Name < keys[j].Name
+	})
+
+	corrections := []*models.Correction{}
+	// Each name/type is a record set in Azure.
+	for _, k := range keys {
+		key := k
+		msg := strings.Join(changedGroups[k], "\n")
+		recs, ok := desiredGrouped[k]
+		if !ok {
+			corrections = append(corrections, &models.Correction{
+				Msg: msg,
+				F:   func() error { return p.deleteRecordSet(dc.Name, key.Type, key.Name) },
+			})
+			continue
+		}
+		set, err := toRecordSet(recs)
+		if err != nil {
+			return nil, err
+		}
+		corrections = append(corrections, &models.Correction{
+			Msg: msg,
+			F:   func() error { return p.putRecordSet(dc.Name, key.Type, key.Name, set) },
+		})
+	}
+	return corrections, nil
+}
+
+// toRecordConfigs converts an Azure record set into records.
+func toRecordConfigs(origin string, set recordSet) []*models.RecordConfig {
+	rtype := set.Type[strings.LastIndex(set.Type, "/")+1:]
+	props := set.Properties
+	recs := []*models.RecordConfig{}
+	add := func(rc *models.RecordConfig) {
+		rc.Type = rtype
+		rc.Name = strings.ToLower(set.Name)
+		rc.NameFQDN = dnsutil.AddOrigin(rc.Name, origin)
+		rc.TTL = props.TTL
+		rc.Original = set
+		recs = append(recs, rc)
+	}
+	switch rtype { // #rtype_variations
+	case "A":
+		for _, r := range props.ARecords {
+			add(&models.RecordConfig{Target: r.IPv4Address})
+		}
+	case "AAAA":
+		for _, r := range props.AAAARecords {
+			add(&models.RecordConfig{Target: r.IPv6Address})
+		}
+	case "CAA":
+		for _, r := range props.CAARecords {
+			add(&models.RecordConfig{Target: r.Value, CaaTag: r.Tag, CaaFlag: r.Flags})
+		}
+	case "CNAME":
+		if props.CNAMERecord != nil {
+			add(&models.RecordConfig{Target: fqdn(props.CNAMERecord.CNAME)})
+		}
+	case "MX":
+		for _, r := range props.MXRecords {
+			add(&models.RecordConfig{Target: fqdn(r.Exchange), MxPreference: r.Preference})
+		}
+	case "NS":
+		for _, r := range props.NSRecords {
+			add(&models.RecordConfig{Target: fqdn(r.NSDName)})
+		}
+	case "PTR":
+		for _, r := range props.PTRRecords {
+			add(&models.RecordConfig{Target: fqdn(r.PTRDName)})
+		}
+	case "SRV":
+		for _, r := range props.SRVRecords {
+			add(&models.RecordConfig{Target: fqdn(r.Target), SrvPriority: r.Priority, SrvWeight: r.Weight, SrvPort: r.Port})
+		}
+	case "TXT":
+		for _, r := range props.TXTRecords {
+			add(&models.RecordConfig{Target: strings.Join(r.Value, " "), TxtStrings: r.Value})
+		}
+	case "SOA":
+		// Maintained by Azure.
+	}
+	return recs
+}
+
+// toRecordSet builds the Azure record set holding recs, which all share a name and type.
+func toRecordSet(recs models.Records) (*recordSet, error) {
+	set := &recordSet{}
+	set.Properties.TTL = recs[0].TTL
+	props := &set.Properties
+	for _, rc := range recs {
+		switch rc.Type { // #rtype_variations
+		case "A":
+			props.ARecords = append(props.ARecords, aRecord{IPv4Address: rc.Target})
+		case "AAAA":
+			props.AAAARecords = append(props.AAAARecords, aaaaRecord{IPv6Address: rc.Target})
+		case "CAA":
+			props.CAARecords = append(props.CAARecords, caaRecord{Flags: rc.CaaFlag, Tag: rc.CaaTag, Value: rc.Target})
+		case "CNAME":
+			props.CNAMERecord = &cnameRecord{CNAME: rc.Target}
+		case "MX":
+			props.MXRecords = append(props.MXRecords, mxRecord{Preference: rc.MxPreference, Exchange: rc.Target})
+		case "NS":
+			props.NSRecords = append(props.NSRecords, nsRecord{NSDName: rc.Target})
+		case "PTR":
+			props.PTRRecords = append(props.PTRRecords, ptrRecord{PTRDName: rc.Target})
+		case "SRV":
+			props.SRVRecords = append(props.SRVRecords, srvRecord{Priority: rc.SrvPriority, Weight: rc.SrvWeight, Port: rc.SrvPort, Target: rc.Target})
+		case "TXT":
+			props.TXTRecords = append(props.TXTRecords, txtRecord{Value: rc.TxtStrings})
+		default:
+			return nil, fmt.Errorf("Azure DNS does not support %s records", rc.Type)
+		}
+	}
+	return set, nil
+}
+
+// fqdn adds the trailing dot Azure omits from the targets it returns.
+func fqdn(s string) string {
+	return strings.TrimSuffix(s, ".") + "."
+}
@@ -0,0 +1,221 @@
+package azuredns
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/StackExchange/dnscontrol/models"
+)
+
+const zonesPath = "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/dnsZones"
+
+// fakeAzure stands in for the Azure login endpoint and the DNS management API.
+type fakeAzure struct {
+	sync.Mutex
+	logins int
+	zones  map[string]map[string]recordSet // zone -> "TYPE/name" -> set
+	puts   int
+}
+
+func (f *fakeAzure) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+	if r.URL.Path == "/tenant/oauth2/v2.0/token" {
+		if r.FormValue("client_secret") != "secret" || r.FormValue("grant_type") != "client_credentials" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"error_description": "invalid client secret"})
+			return
+		}
+		f.logins++
+		json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "token", "token_type": "Bearer", "expires_in": 3600})
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer token" || r.URL.Query().Get("api-version") != apiVersion {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if !strings.HasPrefix(r.URL.Path, zonesPath) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	parts := strings.Split(strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, zonesPath), "/"), "/")
+	zoneName := parts[0]
+	sets, exists := f.zones[zoneName]
+	switch {
+	case zoneName == "" && r.Method == http.MethodGet:
+		list := zoneList{}
+		for name := range f.zones {
+			list.Value = append(list.Value, zone{Name: name})
+		}
+		json.NewEncoder(w).Encode(list)
+	case len(parts) == 1 && r.Method == http.MethodPut:
+		f.zones[zoneName] = map[string]recordSet{}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(zone{Name: zoneName})
+	case !exists:
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(errorResponse{})
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		z := zone{Name: zoneName, Location: "global"}
+		z.Properties.NameServers = []string{"ns1-01.azure-dns.com.", "ns2-01.azure-dns.net."}
+		json.NewEncoder(w).Encode(z)
+	case len(parts) == 2 && parts[1] == "recordsets":
+		list := recordSetList{}
+		for _, set := range sets {
+			list.Value = append(list.Value, set)
+		}
+		json.NewEncoder(w).Encode(list)
+	case len(parts) == 3 && r.Method == http.MethodPut:
+		set := recordSet{}
+		json.NewDecoder(r.Body).Decode(&set)
+		set.Name = parts[2]
+		set.Type = "Microsoft.Network/dnszones/" + parts[1]
+		sets[parts[1]+"/"+parts[2]] = set
+		f.puts++
+		json.NewEncoder(w).Encode(set)
+	case len(parts) == 3 && r.Method == http.MethodDelete:
+		delete(sets, parts[1]+"/"+parts[2])
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func newTestProvider(t *testing.T, secret string) (*Provider, *fakeAzure, func()) {
+	f := &fakeAzure{zones: map[string]map[string]recordSet{}}
+	srv := httptest.NewServer(f)
+	sp := &servicePrincipal{
+		loginURL:     srv.URL,
+		tenantID:     "tenant",
+		clientID:     "client",
+		clientSecret: secret,
+		scope:        srv.URL + "/.default",
+	}
+	return &Provider{newAPI(http.DefaultClient, sp, srv.URL, "sub", "rg")}, f, srv.Close
+}
+
+func desired() *models.DomainConfig {
+	return &models.DomainConfig{
+		Name: "example.com",
+		Records: []*models.RecordConfig{
+			{Type: "A", Name: "www", NameFQDN: "www.example.com", Target: "1.2.3.4", TTL: 300},
+			{Type: "A", Name: "www", NameFQDN: "www.example.com", Target: "1.2.3.5", TTL: 300},
+			{Type: "MX", Name: "@", NameFQDN: "example.com", Target: "mx.example.com.", MxPreference: 10, TTL: 300},
+			{Type: "TXT", Name: "@", NameFQDN: "example.com", Target: "one", TxtStrings: []string{"one", "two"}, TTL: 300},
+			{Type: "CAA", Name: "@", NameFQDN: "example.com", Target: "letsencrypt.org", CaaTag: "issue", TTL: 300},
+			{Type: "SRV", Name: "_sip._tcp", NameFQDN: "_sip._tcp.example.com", Target: "sip.example.com.", SrvPriority: 1, SrvWeight: 2, SrvPort: 5060, TTL: 300},
+		},
+	}
+}
+
+func TestCorrections(t *testing.T) {
+	p, f, stop := newTestProvider(t, "secret")
+	defer stop()
+
+	if err := p.EnsureDomainExists("example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := f.zones["example.com"]; !ok {
+		t.Fatal("zone was not created")
+	}
+	old := recordSet{Name: "old", Type: "Microsoft.Network/dnszones/CNAME"}
+	old.Properties.TTL = 300
+	old.Properties.CNAMERecord = &cnameRecord{CNAME: "www.example.com"}
+	f.zones["example.com"]["CNAME/old"] = old
+
+	nss, err := p.GetNameservers("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nss) != 2 || nss[0].Name != "ns1-01.azure-dns.com" {
+		t.Errorf("unexpected nameservers %v", nss)
+	}
+
+	corrections, err := p.GetDomainCorrections(desired())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A, MX, TXT, CAA and SRV record sets are created and the CNAME deleted.
+	if len(corrections) != 6 {
+		t.Fatalf("expected 6 corrections, got %d", len(corrections))
+	}
+	for _, c := range corrections {
+		if err := c.F(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if f.puts != 5 || len(f.zones["example.com"]) != 5 {
+		t.Errorf("expected 5 record sets, got %d puts and %v", f.puts, f.zones["example.com"])
+	}
+	if f.logins != 1 {
+		t.Errorf("expected the token to be reused, got %d logins", f.logins)
+	}
+
+	corrections, err = p.GetDomainCorrections(desired())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(corrections) != 0 {
+		t.Fatalf("expected no corrections on second run, got %v", corrections[0].Msg)
+	}
+}
+
+func TestApexNSWithoutNameservers(t *testing.T) {
+	p, f, stop := newTestProvider(t, "secret")
+	defer stop()
+	if err := p.EnsureDomainExists("example.com"); err != nil {
+		t.Fatal(err)
+	}
+	ns := recordSet{Name: "@", Type: "Microsoft.Network/dnszones/NS"}
+	ns.Properties.TTL = 172800
+	ns.Properties.NSRecords = []nsRecord{{NSDName: "ns1-01.azure-dns.com."}, {NSDName: "ns2-01.azure-dns.net."}}
+	f.zones["example.com"]["NS/@"] = ns
+
+	dc := &models.DomainConfig{
+		Name: "example.com",
+		Records: []*models.RecordConfig{
+			{Type: "A", Name: "www", NameFQDN: "www.example.com", Target: "1.2.3.4", TTL: 300},
+		},
+	}
+	corrections, err := p.GetDomainCorrections(dc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only the www A record, not the apex NS.
+	if len(corrections) != 1 {
+		t.Fatalf("expected 1 correction, got %d", len(corrections))
+	}
+
+	// With nameservers, the apex NS records are managed.
+	dc.Records = append(dc.Records, &models.RecordConfig{Type: "NS", Name: "@", NameFQDN: "example.com", Target: "ns1-01.azure-dns.com.", TTL: 172800})
+	if corrections, err = p.GetDomainCorrections(dc); err != nil || len(corrections) != 2 {
+		t.Fatalf("expected 2 corrections, got %d, %v", len(corrections), err)
+	}
+}
+
+func TestMissingZone(t *testing.T) {
+	p, _, stop := newTestProvider(t, "secret")
+	defer stop()
+	if _, err := p.GetDomainCorrections(desired()); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestBadCredentials(t *testing.T) {
+	p, _, stop := newTestProvider(t, "wrong")
+	defer stop()
+	err := p.EnsureDomainExists("example.com")
+	if err == nil || !strings.Contains(err.Error(), "invalid client secret") {
+		t.Fatalf("expected a login error, got %v", err)
+	}
+}
+
+func TestUnsupportedType(t *testing.T) {
+	recs := models.Records{{Type: "TLSA", Name: "_443._tcp", Target: "abcdef"}}
+	if _, err := toRecordSet(recs); err == nil {
+		t.Fatal("expected an error")
+	}
+}
@@ -5,18 +5,27 @@ package bind
 bind -
   Generate zonefiles suitiable for BIND.
 
-	The zonefiles are read and written to the directory -bind_dir
+	The zonefiles are read and written to the directory -bind_dir,
+	with names following "filenameformat".
 
 	If the old zonefiles are readable, we read them to determine
 	if an update is actually needed. The old zonefile is also used
 	as the basis for generating the new SOA serial number.
 
+	If "namedconf" is set, new zones are added to that include file
+	for named.conf. Commands can be run after writing either file.
+
+	If "dnssec" is set in the metadata, the zones are signed with the
+	keys it names. Signatures are only replaced when their RRset
+	changes or when they are about to expire.
+
 */
 
 import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
@@ -33,6 +42,7 @@ import (
 var features = providers.DocumentationNotes{
 	providers.CanUseCAA:              providers.Can(),
 	providers.CanUsePTR:              providers.Can(),
+	providers.CanUseSOA:              providers.Can(),
 	providers.CanUseSRV:              providers.Can(),
 	providers.CanUseTLSA:             providers.Can(),
 	providers.CanUseTXTMulti:         providers.Can(),
@@ -57,6 +67,14 @@ func initBind(config map[string]string, providermeta json.RawMessage) (providers
 			return nil, err
 		}
 	}
+	switch api.SerialPolicy {
+	case "", serialDate, serialUnixtime, serialCounter:
+	default:
+		return nil, fmt.Errorf("BIND: unknown serial_policy %q", api.SerialPolicy)
+	}
+	if api.SerialFile == "" {
+		api.SerialFile = filepath.Join(api.directory, "serials.json")
+	}
 	api.nameservers = models.StringsToNameservers(api.DefaultNS)
 	return api, nil
 }
@@ -82,10 +100,23 @@ func (s SoaInfo) String() string {
 
 // Bind is the provider handle for the Bind driver.
 type Bind struct {
-	DefaultNS   []string `json:"default_ns"`
-	DefaultSoa  SoaInfo  `json:"default_soa"`
-	nameservers []*models.Nameserver
-	directory   string
+	DefaultNS  []string    `json:"default_ns"`
+	DefaultSoa SoaInfo     `json:"default_soa"`
+	DNSSEC     *DNSSECInfo `json:"dnssec"`
+	// SerialPolicy is "date" (the default), "unixtime" or "counter".
+	SerialPolicy string `json:"serial_policy"`
+	// SerialFile stores the counters of the "counter" policy.
+	SerialFile string `json:"serial_file"`
+	// FilenameFormat is the name of zonefiles in the directory. See expandFormat.
+	FilenameFormat string `json:"filenameformat"`
+	// NamedConf is the name of the include file that lists the zones, if any.
+	NamedConf string `json:"namedconf"`
+	// ReloadCommand runs after a zonefile is written, ReconfigCommand after
+	// the include file changes.
+	ReloadCommand   []string `json:"reload_command"`
+	ReconfigCommand []string `json:"reconfig_command"`
+	nameservers     []*models.Nameserver
+	directory       string
 }
 
 // var bindSkeletin = flag.String("bind_skeletin", "skeletin/master/var/named/chroot/var/named/master", "")
@@ -132,8 +163,13 @@ func rrToRecord(rr dns.RR, origin string, replaceSerial uint32) (models.RecordCo
 		if (dnsutil.TrimDomainName(rc.Name, origin+".") == "@") && replaceSerial != 0 {
 			newSerial = replaceSerial
 		}
-		rc.Target = fmt.Sprintf("%v %v %v %v %v %v %v",
-			v.Ns, v.Mbox, newSerial, v.Refresh, v.Retry, v.Expire, v.Minttl)
+		rc.Target = v.Ns
+		rc.SoaMbox = v.Mbox
+		rc.SoaSerial = newSerial
+		rc.SoaRefresh = v.Refresh
+		rc.SoaRetry = v.Retry
+		rc.SoaExpire = v.Expire
+		rc.SoaMinttl = v.Minttl
 	case *dns.SRV:
 		rc.Target = v.Target
 		rc.SrvPort = v.Port
@@ -181,7 +217,13 @@ func makeDefaultSOA(info SoaInfo, origin string) *models.RecordConfig {
 	if info.Minttl == 0 {
 		info.Minttl = 1440
 	}
-	soaRec.Target = info.String()
+	soaRec.Target = info.Ns
+	soaRec.SoaMbox = info.Mbox
+	soaRec.SoaSerial = info.Serial
+	soaRec.SoaRefresh = info.Refresh
+	soaRec.SoaRetry = info.Retry
+	soaRec.SoaExpire = info.Expire
+	soaRec.SoaMinttl = info.Minttl
 
 	return &soaRec
 }
@@ -210,10 +252,19 @@ func (c *Bind) GetDomainCorrections(dc *models.DomainConfig) ([]*models.Correcti
 	// Default SOA record.  If we see one in the zone, this will be replaced.
 	soaRec := makeDefaultSOA(c.DefaultSoa, dc.Name)
 
+	var signer *zoneSigner
+	if c.DNSSEC != nil {
+		var err error
+		if signer, err = newZoneSigner(c.DNSSEC, dc.Name, c.directory); err != nil {
+			return nil, err
+		}
+	}
+
 	// Read foundRecords:
 	foundRecords := make([]*models.RecordConfig, 0)
+	foundDNSSEC := []dns.RR{}
 	var oldSerial, newSerial uint32
-	zonefile := filepath.Join(c.directory, strings.Replace(strings.ToLower(dc.Name), "/", "_", -1)+".zone")
+	zonefile := c.zoneFilePath(dc)
 	foundFH, err := os.Open(zonefile)
 	zoneFileFound := err == nil
 	if err != nil && !os.IsNotExist(os.ErrNotExist) {
@@ -224,6 +275,9 @@ func (c *Bind) GetDomainCorrections(dc *models.DomainConfig) ([]*models.Correcti
 		for x := range dns.ParseZone(foundFH, dc.Name, zonefile) {
 			if x.Error != nil {
 				log.Println("Error in zonefile:", x.Error)
+			} else if isDNSSECType(x.RR.Header().Rrtype) {
+				// Signatures are not part of the diff. They are kept to be reused.
+				foundDNSSEC = append(foundDNSSEC, x.RR)
 			} else {
 				rec, serial := rrToRecord(x.RR, dc.Name, oldSerial)
 				if serial != 0 && oldSerial != 0 {
@@ -232,7 +286,10 @@ func (c *Bind) GetDomainCorrections(dc *models.DomainConfig) ([]*models.Correcti
 				if serial != 0 {
 					// This was an SOA record. Update the serial.
 					oldSerial = serial
-					newSerial = generateSerial(oldSerial)
+					newSerial, err = c.nextSerial(dc.GetUniqueName(), oldSerial)
+					if err != nil {
+						return nil, err
+					}
 					// Regenerate with new serial:
 					*soaRec, _ = rrToRecord(x.RR, dc.Name, newSerial)
 					rec = *soaRec
@@ -242,8 +299,27 @@ func (c *Bind) GetDomainCorrections(dc *models.DomainConfig) ([]*models.Correcti
 		}
 	}
 
-	// Add SOA record to expected set:
-	if !dc.HasRecordTypeName("SOA", "@") {
+	if newSerial == 0 {
+		// A new zone, or one without SOA: start from the default serial.
+		newSerial, err = c.nextSerial(dc.GetUniqueName(), c.DefaultSoa.Serial)
+		if err != nil {
+			return nil, err
+		}
+		soaRec.SoaSerial = newSerial
+	}
+
+	// Add SOA record to expected set. A SOA() declared in dnsconfig.js
+	// replaces the one found in the zonefile, but the serial is ours.
+	hasSOA := false
+	for i, r := range dc.Records {
+		if r.Type == "SOA" {
+			soa := *r
+			soa.SoaSerial = newSerial
+			dc.Records[i] = &soa
+			hasSOA = true
+		}
+	}
+	if !hasSOA {
 		dc.Records = append(dc.Records, soaRec)
 	}
 
@@ -274,26 +350,57 @@ func (c *Bind) GetDomainCorrections(dc *models.DomainConfig) ([]*models.Correcti
 			fmt.Fprintln(buf, i)
 		}
 	}
+	if zoneFileFound {
+		reason := ""
+		if signer != nil {
+			reason = signer.resignReason(foundDNSSEC, nowFunc())
+		} else if len(foundDNSSEC) != 0 {
+			reason = "removing DNSSEC records"
+		}
+		if reason != "" {
+			changes = true
+			fmt.Fprintf(buf, "DNSSEC: %s\n", reason)
+		}
+	}
 	msg := fmt.Sprintf("GENERATE_ZONEFILE: %s\n", dc.Name)
 	if !zoneFileFound {
 		msg = msg + fmt.Sprintf(" (%d records)\n", len(create))
 	}
 	msg += buf.String()
+	confCorrection, err := c.namedConfCorrection(dc, zonefile)
+	if err != nil {
+		return nil, err
+	}
 	corrections := []*models.Correction{}
 	if changes {
+		// The zonefile as it was before F, for Undo.
+		var previous []byte
+		var existed bool
 		corrections = append(corrections,
 			&models.Correction{
 				Msg: msg,
 				F: func() error {
+					zonefilerecords := make([]dns.RR, 0, len(dc.Records))
+					for _, r := range dc.Records {
+						zonefilerecords = append(zonefilerecords, r.ToRR())
+					}
+					if signer != nil {
+						var err error
+						zonefilerecords, err = signer.sign(zonefilerecords, foundDNSSEC, nowFunc())
+						if err != nil {
+							return err
+						}
+					}
 					fmt.Printf("CREATING ZONEFILE: %v\n", zonefile)
+					if err := os.MkdirAll(filepath.Dir(zonefile), 0755); err != nil {
+						return err
+					}
+					data, readErr := ioutil.ReadFile(zonefile)
+					previous, existed = data, readErr == nil
 					zf, err := os.Create(zonefile)
 					if err != nil {
 						log.Fatalf("Could not create zonefile: %v", err)
 					}
-					zonefilerecords := make([]dns.RR, 0, len(dc.Records))
-					for _, r := range dc.Records {
-						zonefilerecords = append(zonefilerecords, r.ToRR())
-					}
 					err = WriteZoneFile(zf, zonefilerecords, dc.Name)
 
 					if err != nil {
@@ -303,10 +410,32 @@ func (c *Bind) GetDomainCorrections(dc *models.DomainConfig) ([]*models.Correcti
 					if err != nil {
 						log.Fatalf("Closing: %v", err)
 					}
-					return nil
+					if c.SerialPolicy == serialCounter {
+						if err := c.saveCounter(dc.GetUniqueName(), newSerial); err != nil {
+							return err
+						}
+					}
+					if confCorrection != nil {
+						// The zone is loaded by the reconfig command.
+						return nil
+					}
+					return c.runCommand(c.ReloadCommand, dc, zonefile)
+				},
+				Undo: func() error {
+					if !existed {
+						return os.Remove(zonefile)
+					}
+					if err := ioutil.WriteFile(zonefile, previous, 0644); err != nil {
+						return err
+					}
+					return c.runCommand(c.ReloadCommand, dc, zonefile)
 				},
 			})
 	}
 
+	if confCorrection != nil {
+		corrections = append(corrections, confCorrection)
+	}
+
 	return corrections, nil
 }
@@ -0,0 +1,115 @@
+package bind
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/miekg/dns"
+
+	"github.com/StackExchange/dnscontrol/models"
+)
+
+func soaDomain(refresh uint32) *models.DomainConfig {
+	return &models.DomainConfig{
+		Name: "example.com",
+		Records: []*models.RecordConfig{
+			{Type: "SOA", Name: "@", NameFQDN: "example.com", Target: "ns1.example.com.", SoaMbox: "hostmaster.example.com.",
+				SoaRefresh: refresh, SoaRetry: 600, SoaExpire: 604800, SoaMinttl: 300, TTL: 300},
+			{Type: "A", Name: "www", NameFQDN: "www.example.com", Target: "1.2.3.4", TTL: 300},
+		},
+	}
+}
+
+func readSOA(t *testing.T, path string) *dns.SOA {
+	for _, rr := range readZone(t, path) {
+		if soa, ok := rr.(*dns.SOA); ok {
+			return soa
+		}
+	}
+	t.Fatal("no SOA in the zonefile")
+	return nil
+}
+
+func TestSOARecord(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bind")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	prv, err := initBind(map[string]string{"directory": dir}, json.RawMessage(`{"serial_policy": "counter"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := prv.(*Bind)
+	zonefile := filepath.Join(dir, "example.com.zone")
+
+	push(t, p, soaDomain(3600))
+	soa := readSOA(t, zonefile)
+	if soa.Serial != 1 || soa.Ns != "ns1.example.com." || soa.Mbox != "hostmaster.example.com." || soa.Refresh != 3600 {
+		t.Fatalf("unexpected SOA %v", soa)
+	}
+
+	if c := push(t, p, soaDomain(3600)); len(c) != 0 {
+		t.Fatalf("expected no corrections, got %s", c[0].Msg)
+	}
+
+	if c := push(t, p, soaDomain(7200)); len(c) != 1 {
+		t.Fatal("expected the change of the SOA to be written")
+	}
+	if soa := readSOA(t, zonefile); soa.Serial != 2 || soa.Refresh != 7200 {
+		t.Fatalf("unexpected SOA %v", soa)
+	}
+
+	// The counter survives the zonefile.
+	os.Remove(zonefile)
+	push(t, p, soaDomain(7200))
+	if soa := readSOA(t, zonefile); soa.Serial != 3 {
+		t.Fatalf("expected serial 3, got %v", soa)
+	}
+}
+
+func TestUnknownSerialPolicy(t *testing.T) {
+	if _, err := initBind(map[string]string{}, json.RawMessage(`{"serial_policy": "random"}`)); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestUndo(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bind")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	prv, err := initBind(map[string]string{"directory": dir}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := prv.(*Bind)
+	zonefile := filepath.Join(dir, "example.com.zone")
+
+	// A new zonefile is removed.
+	c := push(t, p, soaDomain(3600))
+	if err := c[0].Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(zonefile); !os.IsNotExist(err) {
+		t.Fatalf("expected the zonefile to be removed, got %v", err)
+	}
+
+	// A changed zonefile is restored.
+	push(t, p, soaDomain(3600))
+	before, err := ioutil.ReadFile(zonefile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c = push(t, p, soaDomain(7200))
+	if err := c[0].Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if after, _ := ioutil.ReadFile(zonefile); string(after) != string(before) {
+		t.Errorf("zonefile not restored:\n%s", after)
+	}
+}
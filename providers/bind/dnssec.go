@@ -0,0 +1,449 @@
+package bind
+
+// Inline DNSSEC signing of the generated zonefiles.
+
+import (
+	"crypto"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// DNSSECInfo configures inline signing of the zonefiles.
+type DNSSECInfo struct {
+	// KeyDirectory is searched for K<zone>.+<alg>+<tag>.key files, as
+	// written by dnssec-keygen. Keys with the SEP flag are KSKs, the others
+	// are ZSKs. Defaults to the zonefile directory.
+	KeyDirectory string `json:"key_directory"`
+	// KSK and ZSK name key files explicitly, without the .key/.private
+	// extension. "%s" is replaced by the zone name.
+	KSK string `json:"ksk"`
+	ZSK string `json:"zsk"`
+
+	// NSEC3 selects NSEC3 instead of NSEC for authenticated denial.
+	NSEC3           bool   `json:"nsec3"`
+	NSEC3Iterations uint16 `json:"nsec3_iterations"`
+	NSEC3Salt       string `json:"nsec3_salt"`
+
+	// Validity is how long new signatures are valid, in days (default 30).
+	Validity uint32 `json:"signature_validity"`
+	// Refresh re-signs signatures that expire within that many days (default 7).
+	Refresh uint32 `json:"signature_refresh"`
+}
+
+func (d *DNSSECInfo) validity() time.Duration {
+	if d.Validity == 0 {
+		return 30 * 24 * time.Hour
+	}
+	return time.Duration(d.Validity) * 24 * time.Hour
+}
+
+func (d *DNSSECInfo) refresh() time.Duration {
+	if d.Refresh == 0 {
+		return 7 * 24 * time.Hour
+	}
+	return time.Duration(d.Refresh) * 24 * time.Hour
+}
+
+// isDNSSECType returns true for the types that are generated by signing.
+// They are never part of the desired records.
+func isDNSSECType(t uint16) bool {
+	switch t {
+	case dns.TypeDNSKEY, dns.TypeRRSIG, dns.TypeNSEC, dns.TypeNSEC3, dns.TypeNSEC3PARAM:
+		return true
+	}
+	return false
+}
+
+// signingKey is a DNSKEY and its private key. signer is nil for keys that
+// are only published, for example ahead of a rollover.
+type signingKey struct {
+	pub    *dns.DNSKEY
+	signer crypto.Signer
+}
+
+func (k *signingKey) isKSK() bool {
+	return k.pub.Flags&dns.SEP != 0
+}
+
+// zoneSigner signs one zone.
+type zoneSigner struct {
+	config *DNSSECInfo
+	keys   []*signingKey
+	apex   string
+}
+
+func readKey(base, zone string) (*signingKey, error) {
+	base = strings.TrimSuffix(strings.TrimSuffix(base, ".key"), ".private")
+	f, err := os.Open(base + ".key")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	rr, err := dns.ReadRR(f, base+".key")
+	if err != nil {
+		return nil, err
+	}
+	pub, ok := rr.(*dns.DNSKEY)
+	if !ok {
+		return nil, fmt.Errorf("%s.key does not contain a DNSKEY", base)
+	}
+	if !strings.EqualFold(pub.Hdr.Name, dns.Fqdn(zone)) {
+		return nil, fmt.Errorf("%s.key is a key for %s, not %s", base, pub.Hdr.Name, zone)
+	}
+	k := &signingKey{pub: pub}
+	p, err := os.Open(base + ".private")
+	if os.IsNotExist(err) {
+		return k, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer p.Close()
+	priv, err := pub.ReadPrivateKey(p, base+".private")
+	if err != nil {
+		return nil, err
+	}
+	if k.signer, ok = priv.(crypto.Signer); !ok {
+		return nil, fmt.Errorf("%s.private: unsupported key type", base)
+	}
+	return k, nil
+}
+
+// newZoneSigner loads the keys of a zone. directory is the zonefile directory.
+func newZoneSigner(config *DNSSECInfo, zone, directory string) (*zoneSigner, error) {
+	zone = strings.ToLower(zone)
+	z := &zoneSigner{config: config, apex: dns.Fqdn(zone)}
+	var files []string
+	if config.KSK != "" || config.ZSK != "" {
+		for _, tmpl := range []string{config.KSK, config.ZSK} {
+			if tmpl != "" {
+				files = append(files, strings.Replace(tmpl, "%s", zone, -1))
+			}
+		}
+	} else {
+		dir := config.KeyDirectory
+		if dir == "" {
+			dir = directory
+		}
+		var err error
+		files, err = filepath.Glob(filepath.Join(dir, "K"+z.apex+"+*.key"))
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, f := range files {
+		k, err := readKey(f, zone)
+		if err != nil {
+			return nil, err
+		}
+		// The same key may be configured as KSK and ZSK.
+		if z.hasKey(k.pub.KeyTag()) {
+			continue
+		}
+		z.keys = append(z.keys, k)
+	}
+	if len(z.signers(true)) == 0 || len(z.signers(false)) == 0 {
+		return nil, fmt.Errorf("DNSSEC is enabled but no usable keys were found for %s", zone)
+	}
+	return z, nil
+}
+
+func (z *zoneSigner) hasKey(tag uint16) bool {
+	for _, k := range z.keys {
+		if k.pub.KeyTag() == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// signers returns the keys that sign the DNSKEY RRset (ksk true) or the
+// other RRsets. Without a separate KSK or ZSK, the same keys sign everything.
+func (z *zoneSigner) signers(ksk bool) []*signingKey {
+	var all, matching []*signingKey
+	for _, k := range z.keys {
+		if k.signer == nil {
+			continue
+		}
+		all = append(all, k)
+		if k.isKSK() == ksk {
+			matching = append(matching, k)
+		}
+	}
+	if len(matching) == 0 {
+		return all
+	}
+	return matching
+}
+
+// resignReason explains why a zonefile with the DNSSEC records existing must
+// be signed again, or returns "" if its signatures are still good.
+func (z *zoneSigner) resignReason(existing []dns.RR, now time.Time) string {
+	var sigs []*dns.RRSIG
+	tags := map[uint16]bool{}
+	var param *dns.NSEC3PARAM
+	for _, rr := range existing {
+		switch v := rr.(type) {
+		case *dns.RRSIG:
+			sigs = append(sigs, v)
+		case *dns.DNSKEY:
+			tags[v.KeyTag()] = true
+		case *dns.NSEC3PARAM:
+			param = v
+		}
+	}
+	if len(sigs) == 0 {
+		return "zone is not signed"
+	}
+	if len(tags) != len(z.keys) {
+		return "DNSKEYs changed"
+	}
+	for tag := range tags {
+		if !z.hasKey(tag) {
+			return "DNSKEYs changed"
+		}
+	}
+	if z.config.NSEC3 != (param != nil) ||
+		(param != nil && (param.Iterations != z.config.NSEC3Iterations || !strings.EqualFold(param.Salt, z.config.NSEC3Salt))) {
+		return "NSEC settings changed"
+	}
+	deadline := now.Add(z.config.refresh())
+	for _, s := range sigs {
+		if !s.ValidityPeriod(deadline) {
+			return fmt.Sprintf("signatures expire before %s", deadline.UTC().Format("2006-01-02"))
+		}
+	}
+	return ""
+}
+
+// canonicalLess orders names as in RFC 4034 section 6.1.
+func canonicalLess(a, b string) bool {
+	la, lb := dns.SplitDomainName(strings.ToLower(a)), dns.SplitDomainName(strings.ToLower(b))
+	for i, j := len(la)-1, len(lb)-1; i >= 0 && j >= 0; i, j = i-1, j-1 {
+		if la[i] != lb[j] {
+			return la[i] < lb[j]
+		}
+	}
+	return len(la) < len(lb)
+}
+
+type rrsetKey struct {
+	name   string
+	rrtype uint16
+}
+
+// sign returns the records of the zone with DNSKEY, RRSIG and NSEC or NSEC3
+// records added. Signatures in existing that still cover an RRset and stay
+// valid long enough are kept, so that unchanged RRsets are not re-signed.
+func (z *zoneSigner) sign(records, existing []dns.RR, now time.Time) ([]dns.RR, error) {
+	var soa *dns.SOA
+	for _, rr := range records {
+		if s, ok := rr.(*dns.SOA); ok && strings.EqualFold(s.Hdr.Name, z.apex) {
+			soa = s
+		}
+	}
+	if soa == nil {
+		return nil, fmt.Errorf("can not sign %s without a SOA record", z.apex)
+	}
+
+	out := append([]dns.RR{}, records...)
+	for _, k := range z.keys {
+		key := *k.pub
+		key.Hdr.Name = z.apex
+		if key.Hdr.Ttl == 0 {
+			key.Hdr.Ttl = soa.Minttl
+		}
+		out = append(out, &key)
+	}
+
+	// Group the RRsets and find the delegations.
+	sets := map[rrsetKey][]dns.RR{}
+	types := map[string][]uint16{}
+	for _, rr := range out {
+		hdr := rr.Header()
+		hdr.Name = strings.ToLower(hdr.Name)
+		k := rrsetKey{hdr.Name, hdr.Rrtype}
+		if _, ok := sets[k]; !ok {
+			types[hdr.Name] = append(types[hdr.Name], hdr.Rrtype)
+		}
+		sets[k] = append(sets[k], rr)
+	}
+	delegation := func(name string) bool {
+		return name != z.apex && sets[rrsetKey{name, dns.TypeNS}] != nil
+	}
+	// Names below a delegation are glue. They are neither signed nor part of the chain.
+	names := []string{}
+	for name := range types {
+		glue := false
+		for parent := name; parent != z.apex; {
+			i, end := dns.NextLabel(parent, 0)
+			if end {
+				break
+			}
+			parent = parent[i:]
+			if delegation(parent) {
+				glue = true
+				break
+			}
+		}
+		if !glue {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool { return canonicalLess(names[i], names[j]) })
+
+	var denial []dns.RR
+	if z.config.NSEC3 {
+		denial = z.nsec3Chain(names, types, delegation, soa.Minttl)
+	} else {
+		denial = z.nsecChain(names, types, soa.Minttl)
+	}
+	for _, rr := range denial {
+		hdr := rr.Header()
+		k := rrsetKey{hdr.Name, hdr.Rrtype}
+		if _, ok := sets[k]; !ok {
+			types[hdr.Name] = append(types[hdr.Name], hdr.Rrtype)
+		}
+		sets[k] = append(sets[k], rr)
+		if hdr.Rrtype == dns.TypeNSEC3 {
+			names = append(names, hdr.Name)
+		}
+	}
+	out = append(out, denial...)
+
+	oldSigs := map[rrsetKey][]*dns.RRSIG{}
+	for _, rr := range existing {
+		if s, ok := rr.(*dns.RRSIG); ok {
+			k := rrsetKey{strings.ToLower(s.Hdr.Name), s.TypeCovered}
+			oldSigs[k] = append(oldSigs[k], s)
+		}
+	}
+	deadline := now.Add(z.config.refresh())
+	for _, name := range names {
+		for _, t := range types[name] {
+			if delegation(name) && t != dns.TypeNSEC {
+				continue
+			}
+			k := rrsetKey{name, t}
+			for _, key := range z.signers(t == dns.TypeDNSKEY) {
+				sig := reuseSignature(oldSigs[k], key, sets[k], deadline)
+				if sig == nil {
+					sig = &dns.RRSIG{
+						Hdr:        dns.RR_Header{Ttl: sets[k][0].Header().Ttl},
+						Algorithm:  key.pub.Algorithm,
+						KeyTag:     key.pub.KeyTag(),
+						SignerName: z.apex,
+						// Allow for some clock skew on the validators.
+						Inception:  uint32(now.Add(-time.Hour).Unix()),
+						Expiration: uint32(now.Add(z.config.validity()).Unix()),
+					}
+					if err := sig.Sign(key.signer, sets[k]); err != nil {
+						return nil, fmt.Errorf("signing %s %s: %s", name, dns.TypeToString[t], err)
+					}
+				}
+				out = append(out, sig)
+			}
+		}
+	}
+	return out, nil
+}
+
+// reuseSignature returns the signature made by key in sigs if it still
+// matches rrset and is valid until deadline.
+func reuseSignature(sigs []*dns.RRSIG, key *signingKey, rrset []dns.RR, deadline time.Time) *dns.RRSIG {
+	for _, s := range sigs {
+		if s.KeyTag != key.pub.KeyTag() || s.Algorithm != key.pub.Algorithm || s.OrigTtl != rrset[0].Header().Ttl {
+			continue
+		}
+		if s.ValidityPeriod(deadline) && s.Verify(key.pub, rrset) == nil {
+			return s
+		}
+	}
+	return nil
+}
+
+// bitmap returns the sorted types for an NSEC or NSEC3 record.
+func bitmap(types []uint16, extra ...uint16) []uint16 {
+	b := append(append([]uint16{}, types...), extra...)
+	sort.Slice(b, func(i, j int) bool { return b[i] < b[j] })
+	return b
+}
+
+func (z *zoneSigner) nsecChain(names []string, types map[string][]uint16, ttl uint32) []dns.RR {
+	chain := make([]dns.RR, 0, len(names))
+	for i, name := range names {
+		chain = append(chain, &dns.NSEC{
+			Hdr:        dns.RR_Header{Name: name, Rrtype: dns.TypeNSEC, Class: dns.ClassINET, Ttl: ttl},
+			NextDomain: names[(i+1)%len(names)],
+			TypeBitMap: bitmap(types[name], dns.TypeNSEC, dns.TypeRRSIG),
+		})
+	}
+	return chain
+}
+
+func (z *zoneSigner) nsec3Chain(names []string, types map[string][]uint16, delegation func(string) bool, ttl uint32) []dns.RR {
+	c := z.config
+	param := &dns.NSEC3PARAM{
+		Hdr:        dns.RR_Header{Name: z.apex, Rrtype: dns.TypeNSEC3PARAM, Class: dns.ClassINET, Ttl: ttl},
+		Hash:       dns.SHA1,
+		Iterations: c.NSEC3Iterations,
+		SaltLength: uint8(len(c.NSEC3Salt) / 2),
+		Salt:       c.NSEC3Salt,
+	}
+
+	// Empty non-terminals get an NSEC3 record too.
+	all := map[string]bool{}
+	for _, name := range names {
+		for n := name; n != z.apex && !all[n]; {
+			all[n] = true
+			i, end := dns.NextLabel(n, 0)
+			if end {
+				break
+			}
+			n = n[i:]
+		}
+	}
+	all[z.apex] = true
+
+	type hashed struct {
+		hash  string
+		types []uint16
+	}
+	hashes := []hashed{}
+	for name := range all {
+		var b []uint16
+		if len(types[name]) != 0 {
+			b = bitmap(types[name], dns.TypeRRSIG)
+			if delegation(name) {
+				b = bitmap(types[name])
+			}
+		}
+		if name == z.apex {
+			b = bitmap(b, dns.TypeNSEC3PARAM)
+		}
+		hashes = append(hashes, hashed{strings.ToLower(dns.HashName(name, dns.SHA1, c.NSEC3Iterations, c.NSEC3Salt)), b})
+	}
+	sort.Slice(hashes, func(i, j int) bool { return hashes[i].hash < hashes[j].hash })
+
+	chain := []dns.RR{param}
+	for i, h := range hashes {
+		next := hashes[(i+1)%len(hashes)].hash
+		chain = append(chain, &dns.NSEC3{
+			Hdr:        dns.RR_Header{Name: h.hash + "." + z.apex, Rrtype: dns.TypeNSEC3, Class: dns.ClassINET, Ttl: ttl},
+			Hash:       dns.SHA1,
+			Iterations: c.NSEC3Iterations,
+			SaltLength: param.SaltLength,
+			Salt:       c.NSEC3Salt,
+			HashLength: uint8(len(next) * 5 / 8),
+			NextDomain: next,
+			TypeBitMap: h.types,
+		})
+	}
+	return chain
+}
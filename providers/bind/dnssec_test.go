@@ -0,0 +1,261 @@
+package bind
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"github.com/StackExchange/dnscontrol/models"
+)
+
+// writeKey generates a key pair for example.com in dir, as dnssec-keygen would.
+func writeKey(t *testing.T, dir string, flags uint16) *dns.DNSKEY {
+	key := &dns.DNSKEY{
+		Hdr:       dns.RR_Header{Name: "example.com.", Rrtype: dns.TypeDNSKEY, Class: dns.ClassINET, Ttl: 3600},
+		Flags:     flags,
+		Protocol:  3,
+		Algorithm: dns.ECDSAP256SHA256,
+	}
+	priv, err := key.Generate(256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := filepath.Join(dir, fmt.Sprintf("Kexample.com.+%03d+%05d", key.Algorithm, key.KeyTag()))
+	if err := ioutil.WriteFile(base+".key", []byte(key.String()+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(base+".private", []byte(key.PrivateKeyString(priv)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func signedDomain(ip string) *models.DomainConfig {
+	recs := []*models.RecordConfig{
+		{Type: "NS", Name: "@", NameFQDN: "example.com", Target: "ns1.example.com.", TTL: 300},
+		{Type: "A", Name: "ns1", NameFQDN: "ns1.example.com", Target: "1.2.3.4", TTL: 300},
+		{Type: "A", Name: "www", NameFQDN: "www.example.com", Target: ip, TTL: 300},
+		{Type: "MX", Name: "@", NameFQDN: "example.com", Target: "mx.example.com.", MxPreference: 10, TTL: 300},
+		{Type: "A", Name: "a.b", NameFQDN: "a.b.example.com", Target: "1.2.3.6", TTL: 300},
+		// A delegation with glue.
+		{Type: "NS", Name: "sub", NameFQDN: "sub.example.com", Target: "ns.sub.example.com.", TTL: 300},
+		{Type: "A", Name: "ns.sub", NameFQDN: "ns.sub.example.com", Target: "1.2.3.7", TTL: 300},
+	}
+	return &models.DomainConfig{Name: "example.com", Records: recs}
+}
+
+func push(t *testing.T, p *Bind, dc *models.DomainConfig) []*models.Correction {
+	corrections, err := p.GetDomainCorrections(dc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range corrections {
+		if err := c.F(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return corrections
+}
+
+func readZone(t *testing.T, path string) []dns.RR {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var rrs []dns.RR
+	for x := range dns.ParseZone(f, "example.com.", path) {
+		if x.Error != nil {
+			t.Fatal(x.Error)
+		}
+		if p, ok := x.RR.(*dns.NSEC3PARAM); ok {
+			// The parser stores the length of the hex string.
+			p.SaltLength = uint8(len(p.Salt) / 2)
+		}
+		rrs = append(rrs, x.RR)
+	}
+	return rrs
+}
+
+// verifyZone checks that every RRSIG verifies and returns the signatures by RRset.
+func verifyZone(t *testing.T, rrs []dns.RR, keys ...*dns.DNSKEY) map[rrsetKey]string {
+	sets := map[rrsetKey][]dns.RR{}
+	var sigs []*dns.RRSIG
+	for _, rr := range rrs {
+		if s, ok := rr.(*dns.RRSIG); ok {
+			sigs = append(sigs, s)
+			continue
+		}
+		k := rrsetKey{rr.Header().Name, rr.Header().Rrtype}
+		sets[k] = append(sets[k], rr)
+	}
+	signed := map[rrsetKey]string{}
+	for _, s := range sigs {
+		k := rrsetKey{s.Hdr.Name, s.TypeCovered}
+		var key *dns.DNSKEY
+		for _, candidate := range keys {
+			if candidate.KeyTag() == s.KeyTag {
+				key = candidate
+			}
+		}
+		if key == nil {
+			t.Fatalf("unknown key tag in %v", s)
+		}
+		if err := s.Verify(key, sets[k]); err != nil {
+			t.Errorf("%s %s: %s", k.name, dns.TypeToString[k.rrtype], err)
+		}
+		signed[k] = s.Signature
+	}
+	return signed
+}
+
+func TestSignZone(t *testing.T) {
+	defer func() { nowFunc = time.Now }()
+	now := time.Date(2018, 3, 1, 12, 0, 0, 0, time.UTC)
+	nowFunc = func() time.Time { return now }
+
+	dir, err := ioutil.TempDir("", "bind")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ksk, zsk := writeKey(t, dir, 257), writeKey(t, dir, 256)
+	prv, err := initBind(map[string]string{"directory": dir}, json.RawMessage(`{"dnssec": {}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := prv.(*Bind)
+	zonefile := filepath.Join(dir, "example.com.zone")
+
+	if len(push(t, p, signedDomain("1.2.3.5"))) != 1 {
+		t.Fatal("expected the zonefile to be written")
+	}
+	rrs := readZone(t, zonefile)
+	sigs := verifyZone(t, rrs, ksk, zsk)
+	if _, ok := sigs[rrsetKey{"sub.example.com.", dns.TypeNS}]; ok {
+		t.Error("the NS records of a delegation must not be signed")
+	}
+	if _, ok := sigs[rrsetKey{"ns.sub.example.com.", dns.TypeA}]; ok {
+		t.Error("glue must not be signed")
+	}
+	next := map[string]string{}
+	for _, rr := range rrs {
+		if n, ok := rr.(*dns.NSEC); ok {
+			next[n.Hdr.Name] = n.NextDomain
+		}
+	}
+	chain := []string{"example.com."}
+	for n := next["example.com."]; n != "example.com." && len(chain) < 10; n = next[n] {
+		chain = append(chain, n)
+	}
+	if got := strings.Join(chain, " "); got != "example.com. a.b.example.com. ns1.example.com. sub.example.com. www.example.com." {
+		t.Errorf("unexpected NSEC chain %s", got)
+	}
+
+	// Nothing changed, the signatures are still good.
+	if c := push(t, p, signedDomain("1.2.3.5")); len(c) != 0 {
+		t.Fatalf("expected no corrections, got %s", c[0].Msg)
+	}
+
+	// A change only re-signs what it touches.
+	push(t, p, signedDomain("1.2.3.9"))
+	resigned := verifyZone(t, readZone(t, zonefile), ksk, zsk)
+	if resigned[rrsetKey{"example.com.", dns.TypeMX}] != sigs[rrsetKey{"example.com.", dns.TypeMX}] {
+		t.Error("the unchanged MX RRset was signed again")
+	}
+	if resigned[rrsetKey{"www.example.com.", dns.TypeA}] == sigs[rrsetKey{"www.example.com.", dns.TypeA}] {
+		t.Error("the changed A RRset was not signed again")
+	}
+
+	// Signatures are refreshed before they expire.
+	now = now.Add(25 * 24 * time.Hour)
+	c := push(t, p, signedDomain("1.2.3.9"))
+	if len(c) != 1 || !strings.Contains(c[0].Msg, "signatures expire") {
+		t.Fatalf("expected the zone to be signed again, got %v", c)
+	}
+	for _, rr := range readZone(t, zonefile) {
+		if s, ok := rr.(*dns.RRSIG); ok && !s.ValidityPeriod(now.Add(20*24*time.Hour)) {
+			t.Errorf("signature was not refreshed: %v", s)
+		}
+	}
+
+	// Turning signing off removes the DNSSEC records.
+	p.DNSSEC = nil
+	if c := push(t, p, signedDomain("1.2.3.9")); len(c) != 1 {
+		t.Fatal("expected the DNSSEC records to be removed")
+	}
+	for _, rr := range readZone(t, zonefile) {
+		if isDNSSECType(rr.Header().Rrtype) {
+			t.Fatalf("unexpected %v", rr)
+		}
+	}
+}
+
+func TestSignZoneNSEC3(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bind")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	csk := writeKey(t, dir, 257)
+	base := filepath.Join(dir, fmt.Sprintf("Kexample.com.+%03d+%05d", csk.Algorithm, csk.KeyTag()))
+	meta := fmt.Sprintf(`{"dnssec": {"ksk": %q, "nsec3": true, "nsec3_iterations": 5, "nsec3_salt": "ABCD"}}`, base)
+	prv, err := initBind(map[string]string{"directory": dir}, json.RawMessage(meta))
+	if err != nil {
+		t.Fatal(err)
+	}
+	push(t, prv.(*Bind), signedDomain("1.2.3.5"))
+	rrs := readZone(t, filepath.Join(dir, "example.com.zone"))
+	verifyZone(t, rrs, csk)
+
+	var nsec3 []*dns.NSEC3
+	params := 0
+	for _, rr := range rrs {
+		switch v := rr.(type) {
+		case *dns.NSEC:
+			t.Errorf("unexpected NSEC record %v", v)
+		case *dns.NSEC3:
+			nsec3 = append(nsec3, v)
+		case *dns.NSEC3PARAM:
+			params++
+		}
+	}
+	if params != 1 {
+		t.Errorf("expected one NSEC3PARAM, got %d", params)
+	}
+	// The apex, ns1, www, a.b, sub and the empty non-terminal b.
+	if len(nsec3) != 6 {
+		t.Fatalf("expected 6 NSEC3 records, got %d", len(nsec3))
+	}
+	for _, name := range []string{"b.example.com.", "a.b.example.com."} {
+		found := false
+		for _, n := range nsec3 {
+			found = found || n.Match(name)
+		}
+		if !found {
+			t.Errorf("no NSEC3 record for %s", name)
+		}
+	}
+}
+
+func TestSignZoneWithoutKeys(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bind")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	prv, err := initBind(map[string]string{"directory": dir}, json.RawMessage(`{"dnssec": {}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := prv.(*Bind).GetDomainCorrections(signedDomain("1.2.3.5")); err == nil {
+		t.Fatal("expected an error")
+	}
+}
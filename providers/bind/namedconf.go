@@ -0,0 +1,144 @@
+package bind
+
+// File layout, named.conf include files and the commands run after writing.
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/StackExchange/dnscontrol/models"
+)
+
+const defaultFilenameFormat = "%U.zone"
+
+// expandFormat expands a filename format or a command argument for dc. %D
+// (or %s) is the domain name, %T the split horizon tag or nothing, %U the
+// domain name and tag as in "example.com!internal", and %% a literal %.
+// extra holds more pairs of placeholders and values.
+func expandFormat(format string, dc *models.DomainConfig, extra ...string) string {
+	clean := func(s string) string {
+		return strings.Replace(strings.ToLower(s), "/", "_", -1)
+	}
+	pairs := append([]string{
+		"%%", "%",
+		"%D", clean(dc.Name),
+		"%s", clean(dc.Name),
+		"%T", clean(dc.Tag),
+		"%U", clean(dc.GetUniqueName()),
+	}, extra...)
+	return strings.NewReplacer(pairs...).Replace(format)
+}
+
+// zoneFilePath returns the path of the zonefile of dc.
+func (c *Bind) zoneFilePath(dc *models.DomainConfig) string {
+	format := c.FilenameFormat
+	if format == "" {
+		format = defaultFilenameFormat
+	}
+	return filepath.Join(c.directory, expandFormat(format, dc))
+}
+
+// namedConfPath returns the path of the include file that lists dc, or "".
+func (c *Bind) namedConfPath(dc *models.DomainConfig) string {
+	if c.NamedConf == "" {
+		return ""
+	}
+	return filepath.Join(c.directory, expandFormat(c.NamedConf, dc))
+}
+
+// namedConf is an include file for named.conf with one zone statement per
+// zone. It only understands the files it writes.
+type namedConf struct {
+	path  string
+	zones map[string]string // Zone name to zonefile.
+}
+
+var zoneStatement = regexp.MustCompile(`zone\s+"([^"]+)"\s*{[^}]*file\s+"([^"]+)"`)
+
+func readNamedConf(path string) (*namedConf, error) {
+	n := &namedConf{path: path, zones: map[string]string{}}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return n, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range zoneStatement.FindAllStringSubmatch(string(data), -1) {
+		n.zones[m[1]] = m[2]
+	}
+	return n, nil
+}
+
+func (n *namedConf) write() error {
+	names := make([]string, 0, len(n.zones))
+	for name := range n.zones {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	buf := &bytes.Buffer{}
+	fmt.Fprintln(buf, "// Generated by dnscontrol. Zones are added when they are pushed.")
+	for _, name := range names {
+		fmt.Fprintf(buf, "zone %q {\n\ttype master;\n\tfile %q;\n};\n", name, n.zones[name])
+	}
+	if err := os.MkdirAll(filepath.Dir(n.path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(n.path, buf.Bytes(), 0644)
+}
+
+// namedConfCorrection returns a correction that adds dc to its include file,
+// or nil if it is already listed with the right zonefile.
+func (c *Bind) namedConfCorrection(dc *models.DomainConfig, zonefile string) (*models.Correction, error) {
+	path := c.namedConfPath(dc)
+	if path == "" {
+		return nil, nil
+	}
+	conf, err := readNamedConf(path)
+	if err != nil {
+		return nil, err
+	}
+	zone := strings.ToLower(dc.Name)
+	if conf.zones[zone] == zonefile {
+		return nil, nil
+	}
+	return &models.Correction{
+		Msg: fmt.Sprintf("UPDATE_NAMEDCONF: %s: add zone %s (%s)", path, zone, zonefile),
+		F: func() error {
+			// Read again: other domains may share the file.
+			conf, err := readNamedConf(path)
+			if err != nil {
+				return err
+			}
+			conf.zones[zone] = zonefile
+			if err := conf.write(); err != nil {
+				return err
+			}
+			return c.runCommand(c.ReconfigCommand, dc, zonefile)
+		},
+	}, nil
+}
+
+// runCommand runs a command from the metadata. Its arguments are expanded
+// like the filename format, and %F is the zonefile.
+func (c *Bind) runCommand(command []string, dc *models.DomainConfig, zonefile string) error {
+	if len(command) == 0 {
+		return nil
+	}
+	args := make([]string, len(command))
+	for i, arg := range command {
+		args[i] = expandFormat(arg, dc, "%F", zonefile)
+	}
+	out, err := exec.Command(args[0], args[1:]...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("BIND: %s: %s: %s", strings.Join(args, " "), err, bytes.TrimSpace(out))
+	}
+	return nil
+}
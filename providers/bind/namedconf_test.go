@@ -0,0 +1,121 @@
+package bind
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/StackExchange/dnscontrol/models"
+)
+
+func TestExpandFormat(t *testing.T) {
+	dc := &models.DomainConfig{Name: "Example.com!internal"}
+	dc.UpdateSplitHorizonNames()
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{"%U.zone", "example.com!internal.zone"},
+		{"db.%s", "db.example.com"},
+		{"%T/%D.zone", "internal/example.com.zone"},
+		{"100%%-%D", "100%-example.com"},
+	}
+	for _, tst := range tests {
+		if found := expandFormat(tst.format, dc); found != tst.expected {
+			t.Errorf("%s: expected %q got %q", tst.format, tst.expected, found)
+		}
+	}
+	reverse := &models.DomainConfig{Name: "0/25.2.0.192.in-addr.arpa"}
+	if found := expandFormat("%D.zone", reverse); found != "0_25.2.0.192.in-addr.arpa.zone" {
+		t.Errorf("unexpected name %q", found)
+	}
+}
+
+func viewDomain(name string) *models.DomainConfig {
+	dc := &models.DomainConfig{
+		Name: name,
+		Records: []*models.RecordConfig{
+			{Type: "A", Name: "www", NameFQDN: "www.example.com", Target: "1.2.3.4", TTL: 300},
+		},
+	}
+	dc.UpdateSplitHorizonNames()
+	return dc
+}
+
+func TestNamedConf(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the commands need sh")
+	}
+	dir, err := ioutil.TempDir("", "bind")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	log := filepath.Join(dir, "commands.log")
+	meta := fmt.Sprintf(`{
+		"filenameformat": "%%T/db.%%D",
+		"namedconf": "%%T/zones.conf",
+		"reload_command": ["sh", "-c", "echo reload %%D %%T >> %[1]s"],
+		"reconfig_command": ["sh", "-c", "echo reconfig %%F >> %[1]s"]
+	}`, log)
+	prv, err := initBind(map[string]string{"directory": dir}, json.RawMessage(meta))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := prv.(*Bind)
+
+	// A new zone is written, listed in the include file of its view and loaded with reconfig.
+	for _, name := range []string{"example.com!internal", "example.com!external"} {
+		if c := push(t, p, viewDomain(name)); len(c) != 2 {
+			t.Fatalf("%s: expected a zonefile and a named.conf correction, got %d", name, len(c))
+		}
+	}
+	zonefile := filepath.Join(dir, "internal", "db.example.com")
+	if _, err := os.Stat(zonefile); err != nil {
+		t.Fatal(err)
+	}
+	conf, err := ioutil.ReadFile(filepath.Join(dir, "internal", "zones.conf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := fmt.Sprintf("zone \"example.com\" {\n\ttype master;\n\tfile %q;\n};\n", zonefile)
+	if !strings.Contains(string(conf), expected) {
+		t.Errorf("unexpected include file:\n%s", conf)
+	}
+
+	// A change is loaded with reload.
+	dc := viewDomain("example.com!internal")
+	dc.Records[0].Target = "1.2.3.5"
+	if c := push(t, p, dc); len(c) != 1 {
+		t.Fatalf("expected a zonefile correction, got %d", len(c))
+	}
+	if c := push(t, p, viewDomain("example.com!external")); len(c) != 0 {
+		t.Fatalf("expected no corrections, got %s", c[0].Msg)
+	}
+
+	data, err := ioutil.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = fmt.Sprintf("reconfig %s\nreconfig %s\nreload example.com internal\n",
+		zonefile, filepath.Join(dir, "external", "db.example.com"))
+	if string(data) != expected {
+		t.Errorf("unexpected commands:\n%s\nexpected:\n%s", data, expected)
+	}
+}
+
+func TestCommandFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the commands need sh")
+	}
+	c := &Bind{}
+	err := c.runCommand([]string{"sh", "-c", "echo no such zone; exit 1"}, &models.DomainConfig{Name: "example.com"}, "")
+	if err == nil || !strings.Contains(err.Error(), "no such zone") {
+		t.Fatalf("expected the output of the command in the error, got %v", err)
+	}
+}
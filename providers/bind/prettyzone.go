@@ -36,13 +36,30 @@ func (z *zoneGenData) Less(i, j int) bool {
 		}
 		return zoneLabelLess(compA, compB)
 	}
-	rrtypeA, rrtypeB := a.Header().Rrtype, b.Header().Rrtype
+	rrtypeA, rrtypeB := sortType(a), sortType(b)
 	if rrtypeA != rrtypeB {
 		return zoneRrtypeLess(rrtypeA, rrtypeB)
 	}
-	switch rrtypeA { // #rtype_variations
+	// An RRSIG is listed right after the RRset it covers.
+	if sigA, sigB := a.Header().Rrtype == dns.TypeRRSIG, b.Header().Rrtype == dns.TypeRRSIG; sigA != sigB {
+		return sigB
+	}
+	switch a.Header().Rrtype { // #rtype_variations
 	case dns.TypeNS, dns.TypeTXT, dns.TypeTLSA:
 		// pass through.
+	case dns.TypeNSEC, dns.TypeNSEC3, dns.TypeNSEC3PARAM:
+		// pass through.
+	case dns.TypeDNSKEY:
+		ta2, tb2 := a.(*dns.DNSKEY), b.(*dns.DNSKEY)
+		// KSKs first, then by key tag.
+		fa, fb := ta2.Flags, tb2.Flags
+		if fa != fb {
+			return fa > fb
+		}
+		return ta2.KeyTag() < tb2.KeyTag()
+	case dns.TypeRRSIG:
+		ta2, tb2 := a.(*dns.RRSIG), b.(*dns.RRSIG)
+		return ta2.KeyTag < tb2.KeyTag
 	case dns.TypeA:
 		ta2, tb2 := a.(*dns.A), b.(*dns.A)
 		ipa, ipb := ta2.A.To4(), tb2.A.To4()
@@ -99,6 +116,15 @@ func (z *zoneGenData) Less(i, j int) bool {
 	return a.String() < b.String()
 }
 
+// sortType returns the type a record is sorted by. RRSIGs sort with the
+// type they cover.
+func sortType(rr dns.RR) uint16 {
+	if sig, ok := rr.(*dns.RRSIG); ok {
+		return sig.TypeCovered
+	}
+	return rr.Header().Rrtype
+}
+
 // mostCommonTTL returns the most common TTL in a set of records. If there is
 // a tie, the highest TTL is selected. This makes the results consistent.
 // NS records are not included in the analysis because Tom said so.
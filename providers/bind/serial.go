@@ -1,7 +1,11 @@
 package bind
 
 import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
 	"log"
+	"os"
 	"strconv"
 	"strings"
 	"time"
@@ -9,6 +13,75 @@ import (
 
 var nowFunc = time.Now
 
+// Serial policies, selected with "serial_policy" in the provider metadata.
+const (
+	serialDate     = "date"     // yyyymmddvv, see generateSerial.
+	serialUnixtime = "unixtime" // The current time in seconds since the epoch.
+	serialCounter  = "counter"  // A counter per zone, kept in SerialFile.
+)
+
+// nextSerial returns the serial that replaces oldSerial in zone. Serials
+// never decrease: if the policy would go backwards, oldSerial is incremented.
+func (c *Bind) nextSerial(zone string, oldSerial uint32) (uint32, error) {
+	var serial uint32
+	switch c.SerialPolicy {
+	case "", serialDate:
+		return generateSerial(oldSerial), nil
+	case serialUnixtime:
+		serial = uint32(nowFunc().Unix())
+	case serialCounter:
+		counters, err := c.readCounters()
+		if err != nil {
+			return 0, err
+		}
+		serial = counters[strings.ToLower(zone)] + 1
+	default:
+		return 0, fmt.Errorf("BIND: unknown serial_policy %q", c.SerialPolicy)
+	}
+	if serial <= oldSerial {
+		serial = oldSerial + 1
+	}
+	if serial == 0 {
+		return 0, fmt.Errorf("BIND: the serial of %s would wrap around from %d", zone, oldSerial)
+	}
+	return serial, nil
+}
+
+// readCounters reads the counters of the "counter" policy. A missing file
+// means that no zone has a counter yet.
+func (c *Bind) readCounters() (map[string]uint32, error) {
+	counters := map[string]uint32{}
+	data, err := ioutil.ReadFile(c.SerialFile)
+	if os.IsNotExist(err) {
+		return counters, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &counters); err != nil {
+		return nil, fmt.Errorf("BIND: parsing %s: %s", c.SerialFile, err)
+	}
+	return counters, nil
+}
+
+// saveCounter records the serial last written for zone.
+func (c *Bind) saveCounter(zone string, serial uint32) error {
+	counters, err := c.readCounters()
+	if err != nil {
+		return err
+	}
+	zone = strings.ToLower(zone)
+	if serial < counters[zone] {
+		return fmt.Errorf("BIND: the serial of %s would decrease from %d to %d", zone, counters[zone], serial)
+	}
+	counters[zone] = serial
+	data, err := json.MarshalIndent(counters, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(c.SerialFile, append(data, '\n'), 0644)
+}
+
 // generateSerial takes an old SOA serial number and increments it.
 func generateSerial(oldSerial uint32) uint32 {
 	// Serial numbers are in the format yyyymmddvv
@@ -1,6 +1,9 @@
 package bind
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -49,3 +52,51 @@ func Test_generate_serial_1(t *testing.T) {
 		}
 	}
 }
+
+func TestNextSerial(t *testing.T) {
+	defer func() { nowFunc = time.Now }()
+	now := time.Unix(1500000000, 0)
+	nowFunc = func() time.Time { return now }
+	dir, err := ioutil.TempDir("", "bind")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		policy   string
+		old      uint32
+		expected uint32
+	}{
+		{"unixtime", 0, 1500000000},
+		{"unixtime", 1400000000, 1500000000},
+		// Switching from yyyymmddvv never decreases the serial.
+		{"unixtime", 2017071401, 2017071402},
+		{"counter", 0, 1},
+		{"counter", 41, 42},
+	}
+	for _, tst := range tests {
+		c := &Bind{SerialPolicy: tst.policy, SerialFile: filepath.Join(dir, "serials.json")}
+		found, err := c.nextSerial("example.com", tst.old)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if found != tst.expected {
+			t.Errorf("%s %d: expected %d got %d", tst.policy, tst.old, tst.expected, found)
+		}
+	}
+
+	c := &Bind{SerialPolicy: "counter", SerialFile: filepath.Join(dir, "serials.json")}
+	if err := c.saveCounter("example.com", 7); err != nil {
+		t.Fatal(err)
+	}
+	if found, _ := c.nextSerial("example.com", 3); found != 8 {
+		t.Errorf("expected the counter to continue at 8, got %d", found)
+	}
+	if err := c.saveCounter("example.com", 6); err == nil {
+		t.Error("expected an error when the counter decreases")
+	}
+	if _, err := (&Bind{SerialPolicy: "unixtime"}).nextSerial("example.com", 0xffffffff); err == nil {
+		t.Error("expected an error when the serial wraps around")
+	}
+}
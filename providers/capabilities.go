@@ -34,6 +34,9 @@ const (
 	// provider.
 	CantUseNOPURGE
 
+	// CanUseSOA indicates the provider can manage the SOA record declared with SOA()
+	CanUseSOA
+
 	// DocOfficiallySupported means it is actively used and maintained by stack exchange
 	DocOfficiallySupported
 	// DocDualHost means provider allows full management of apex NS records, so we can safely dual-host with anothe provider
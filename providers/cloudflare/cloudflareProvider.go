@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -28,9 +30,12 @@ Record level metadata available:
 
 Domain level metadata available:
    - cloudflare_proxy_default ("on", "off", or "full")
+   - cloudflare_setting_* (see CF_SETTING)
 
  Provider level metadata available:
    - ip_conversions
+   - manage_redirects
+   - manage_page_rules
 */
 
 var features = providers.DocumentationNotes{
@@ -44,6 +49,7 @@ var features = providers.DocumentationNotes{
 
 func init() {
 	providers.RegisterDomainServiceProviderType("CLOUDFLAREAPI", newCloudflare, features)
+	providers.RegisterCustomRecordType("CF_PAGE_RULE", "CLOUDFLAREAPI", "")
 	providers.RegisterCustomRecordType("CF_REDIRECT", "CLOUDFLAREAPI", "")
 	providers.RegisterCustomRecordType("CF_TEMP_REDIRECT", "CLOUDFLAREAPI", "")
 }
@@ -57,6 +63,8 @@ type CloudflareApi struct {
 	ipConversions   []transform.IpConversion
 	ignoredLabels   []string
 	manageRedirects bool
+	managePageRules bool
+	client          *http.Client
 }
 
 func labelMatches(label string, matches []string) bool {
@@ -97,6 +105,10 @@ func (c *CloudflareApi) GetDomainCorrections(dc *models.DomainConfig) ([]*models
 	if err := c.preprocessConfig(dc); err != nil {
 		return nil, err
 	}
+	desiredRules, err := desiredPageRules(dc)
+	if err != nil {
+		return nil, err
+	}
 	records, err := c.getRecordsForDomain(id, dc.Name)
 	if err != nil {
 		return nil, err
@@ -109,12 +121,23 @@ func (c *CloudflareApi) GetDomainCorrections(dc *models.DomainConfig) ([]*models
 			records = append(records[:i], records[i+1:]...)
 		}
 	}
-	if c.manageRedirects {
-		prs, err := c.getPageRules(id, dc.Name)
+	var existingRules []*cfPageRule
+	if c.manageRedirects || c.managePageRules {
+		prs, err := c.getPageRules(id)
 		if err != nil {
 			return nil, err
 		}
-		records = append(records, prs...)
+		for _, pr := range prs {
+			// With manage_redirects only, the other rules are left alone.
+			if !c.managePageRules && !isRedirect(pr) {
+				continue
+			}
+			r, err := fromAPI(pr)
+			if err != nil {
+				return nil, err
+			}
+			existingRules = append(existingRules, r)
+		}
 	}
 	for _, rec := range dc.Records {
 		if rec.Type == "ALIAS" {
@@ -134,46 +157,32 @@ func (c *CloudflareApi) GetDomainCorrections(dc *models.DomainConfig) ([]*models
 	corrections := []*models.Correction{}
 
 	for _, d := range del {
-		ex := d.Existing
-		if ex.Type == "PAGE_RULE" {
-			corrections = append(corrections, &models.Correction{
-				Msg: d.String(),
-				F:   func() error { return c.deletePageRule(ex.Original.(*pageRule).ID, id) },
-			})
-
-		} else {
-			corrections = append(corrections, c.deleteRec(ex.Original.(*cfRecord), id))
-		}
+		corrections = append(corrections, c.deleteRec(d.Existing.Original.(*cfRecord), id))
 	}
 	for _, d := range create {
-		des := d.Desired
-		if des.Type == "PAGE_RULE" {
-			corrections = append(corrections, &models.Correction{
-				Msg: d.String(),
-				F:   func() error { return c.createPageRule(id, des.Target) },
-			})
-		} else {
-			corrections = append(corrections, c.createRec(des, id)...)
-		}
+		corrections = append(corrections, c.createRec(d.Desired, id)...)
 	}
 
 	for _, d := range mod {
 		rec := d.Desired
-		ex := d.Existing
-		if rec.Type == "PAGE_RULE" {
-			corrections = append(corrections, &models.Correction{
-				Msg: d.String(),
-				F:   func() error { return c.updatePageRule(ex.Original.(*pageRule).ID, id, rec.Target) },
-			})
-		} else {
-			e := ex.Original.(*cfRecord)
-			proxy := e.Proxiable && rec.Metadata[metaProxy] != "off"
-			corrections = append(corrections, &models.Correction{
-				Msg: d.String(),
-				F:   func() error { return c.modifyRecord(id, e.ID, proxy, rec) },
-			})
-		}
+		e := d.Existing.Original.(*cfRecord)
+		proxy := e.Proxiable && rec.Metadata[metaProxy] != "off"
+		corrections = append(corrections, &models.Correction{
+			Msg:  d.String(),
+			F:    func() error { return c.modifyRecord(id, e.ID, proxy, rec) },
+			Undo: func() error { return c.sendRecord("PUT", fmt.Sprintf(singleRecordURL, id, e.ID), e) },
+		})
+	}
+
+	if c.manageRedirects || c.managePageRules {
+		corrections = append(corrections, c.pageRuleCorrections(id, existingRules, desiredRules)...)
 	}
+
+	settings, err := c.settingCorrections(id, dc)
+	if err != nil {
+		return nil, err
+	}
+	corrections = append(corrections, settings...)
 	return corrections, nil
 }
 
@@ -221,6 +230,11 @@ func (c *CloudflareApi) preprocessConfig(dc *models.DomainConfig) error {
 	}
 
 	currentPrPrio := 1
+	nextPrio := func() int {
+		currentPrPrio++
+		return currentPrPrio - 1
+	}
+	var pageRules []*models.RecordConfig
 
 	// Normalize the proxy setting for each record.
 	// A and CNAMEs: Validate. If null, set to default.
@@ -254,22 +268,18 @@ func (c *CloudflareApi) preprocessConfig(dc *models.DomainConfig) error {
 				rec.Metadata[metaProxy] = val
 			}
 		}
-		// CF_REDIRECT record types. Encode target as $FROM,$TO,$PRIO,$CODE
-		if rec.Type == "CF_REDIRECT" || rec.Type == "CF_TEMP_REDIRECT" {
-			if !c.manageRedirects {
-				return fmt.Errorf("you must add 'manage_redirects: true' metadata to cloudflare provider to use CF_REDIRECT records")
-			}
-			parts := strings.Split(rec.Target, ",")
-			if len(parts) != 2 {
-				return fmt.Errorf("Invalid data specified for cloudflare redirect record")
-			}
-			code := 301
-			if rec.Type == "CF_TEMP_REDIRECT" {
-				code = 302
-			}
-			rec.Target = fmt.Sprintf("%s,%d,%d", rec.Target, currentPrPrio, code)
-			currentPrPrio++
-			rec.Type = "PAGE_RULE"
+		if rec.Type == "CF_PAGE_RULE" {
+			pageRules = append(pageRules, rec)
+		}
+		if err := c.preprocessPageRule(rec, nextPrio); err != nil {
+			return err
+		}
+	}
+
+	// CF_REDIRECT records take the first priorities.
+	for _, rec := range pageRules {
+		if p, _ := strconv.Atoi(rec.Metadata[metaPageRulePriority]); p < currentPrPrio {
+			return fmt.Errorf("CF_PAGE_RULE %s: priority %d is used by CF_REDIRECT records, use %d or more", rec.Target, p, currentPrPrio)
 		}
 	}
 
@@ -304,8 +314,13 @@ func newCloudflare(m map[string]string, metadata json.RawMessage) (providers.DNS
 	if api.ApiKey == "" || api.ApiUser == "" {
 		return nil, fmt.Errorf("cloudflare apikey and apiuser must be provided")
 	}
+	client, err := providers.NewHTTPClient("CLOUDFLAREAPI", m)
+	if err != nil {
+		return nil, err
+	}
+	api.client = client
 
-	err := api.fetchDomainList()
+	err = api.fetchDomainList()
 	if err != nil {
 		return nil, err
 	}
@@ -315,12 +330,14 @@ func newCloudflare(m map[string]string, metadata json.RawMessage) (providers.DNS
 			IPConversions   string   `json:"ip_conversions"`
 			IgnoredLabels   []string `json:"ignored_labels"`
 			ManageRedirects bool     `json:"manage_redirects"`
+			ManagePageRules bool     `json:"manage_page_rules"`
 		}{}
 		err := json.Unmarshal([]byte(metadata), parsedMeta)
 		if err != nil {
 			return nil, err
 		}
 		api.manageRedirects = parsedMeta.ManageRedirects
+		api.managePageRules = parsedMeta.ManagePageRules
 		// ignored_labels:
 		for _, l := range parsedMeta.IgnoredLabels {
 			api.ignoredLabels = append(api.ignoredLabels, l)
@@ -0,0 +1,288 @@
+package cloudflare
+
+// Page rules: CF_REDIRECT, CF_TEMP_REDIRECT and CF_PAGE_RULE.
+//
+// Page rules are not diffed like records. A rule is identified by its URL
+// pattern and its priority: a rule whose actions change is updated in place,
+// a rule that only moves to another priority keeps its id, and only rules
+// that are really gone or new are deleted or created.
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/StackExchange/dnscontrol/models"
+)
+
+const (
+	metaPageRuleActions  = "cloudflare_page_rule_actions"
+	metaPageRulePriority = "cloudflare_page_rule_priority"
+)
+
+// flagActions take no value. CF_PAGE_RULE sets them to true.
+var flagActions = map[string]bool{
+	"always_use_https":    true,
+	"disable_apps":        true,
+	"disable_performance": true,
+	"disable_railgun":     true,
+	"disable_security":    true,
+}
+
+// cfPageRule is a page rule as it is compared.
+type cfPageRule struct {
+	ID       string // Only for existing rules.
+	Pattern  string
+	Priority int
+	Actions  string // A JSON object of action ids to values, with sorted keys.
+	Status   string
+}
+
+func (r *cfPageRule) String() string {
+	s := fmt.Sprintf("%s (priority %d) %s", r.Pattern, r.Priority, r.Actions)
+	if r.Status != "active" {
+		s += " " + r.Status
+	}
+	return s
+}
+
+// canonicalActions checks the actions of a CF_PAGE_RULE and returns them
+// in the form used to compare rules.
+func canonicalActions(raw string) (string, error) {
+	actions := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(raw), &actions); err != nil {
+		return "", fmt.Errorf("invalid page rule actions %s: %s", raw, err)
+	}
+	if len(actions) == 0 {
+		return "", fmt.Errorf("a page rule needs at least one action")
+	}
+	for id, v := range actions {
+		if flagActions[id] && v != true {
+			return "", fmt.Errorf("page rule action %s takes no value: set it to true", id)
+		}
+	}
+	// Maps are marshalled with sorted keys.
+	b, err := json.Marshal(actions)
+	return string(b), err
+}
+
+// apiActions converts canonical actions to the list the API expects.
+func apiActions(actions string) ([]pageRuleAction, error) {
+	m := map[string]json.RawMessage{}
+	if err := json.Unmarshal([]byte(actions), &m); err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	list := make([]pageRuleAction, 0, len(ids))
+	for _, id := range ids {
+		a := pageRuleAction{ID: id}
+		if !flagActions[id] {
+			a.Value = m[id]
+		}
+		list = append(list, a)
+	}
+	return list, nil
+}
+
+// fromAPI converts a page rule read from the API.
+func fromAPI(pr *pageRule) (*cfPageRule, error) {
+	if len(pr.Targets) != 1 {
+		return nil, fmt.Errorf("page rule %s has %d targets", pr.ID, len(pr.Targets))
+	}
+	actions := map[string]interface{}{}
+	for _, a := range pr.Actions {
+		if len(a.Value) == 0 || string(a.Value) == "null" {
+			actions[a.ID] = true
+			continue
+		}
+		var v interface{}
+		if err := json.Unmarshal(a.Value, &v); err != nil {
+			return nil, err
+		}
+		actions[a.ID] = v
+	}
+	b, err := json.Marshal(actions)
+	if err != nil {
+		return nil, err
+	}
+	return &cfPageRule{
+		ID:       pr.ID,
+		Pattern:  pr.Targets[0].Constraint.Value,
+		Priority: pr.Priority,
+		Actions:  string(b),
+		Status:   pr.Status,
+	}, nil
+}
+
+// isRedirect reports whether pr is a rule that CF_REDIRECT could have made.
+func isRedirect(pr *pageRule) bool {
+	return len(pr.Targets) == 1 && len(pr.Actions) == 1 && pr.Actions[0].ID == "forwarding_url"
+}
+
+// preprocessPageRule turns a CF_REDIRECT, CF_TEMP_REDIRECT or CF_PAGE_RULE
+// into a PAGE_RULE record. Redirects take priorities from 1 up, given by
+// nextPrio.
+func (c *CloudflareApi) preprocessPageRule(rec *models.RecordConfig, nextPrio func() int) error {
+	switch rec.Type {
+	case "CF_REDIRECT", "CF_TEMP_REDIRECT":
+		if !c.manageRedirects && !c.managePageRules {
+			return fmt.Errorf("you must add 'manage_redirects: true' metadata to cloudflare provider to use CF_REDIRECT records")
+		}
+		parts := strings.Split(rec.Target, ",")
+		if len(parts) != 2 {
+			return fmt.Errorf("Invalid data specified for cloudflare redirect record")
+		}
+		src, dst := parts[0], parts[1]
+		code := 301
+		if rec.Type == "CF_TEMP_REDIRECT" {
+			code = 302
+		}
+		actions, err := json.Marshal(map[string]interface{}{
+			"forwarding_url": map[string]interface{}{"url": dst, "status_code": code},
+		})
+		if err != nil {
+			return err
+		}
+		rec.Target = src
+		rec.Metadata[metaPageRuleActions] = string(actions)
+		rec.Metadata[metaPageRulePriority] = strconv.Itoa(nextPrio())
+	case "CF_PAGE_RULE":
+		if !c.managePageRules {
+			return fmt.Errorf("you must add 'manage_page_rules: true' metadata to cloudflare provider to use CF_PAGE_RULE records")
+		}
+		actions, err := canonicalActions(rec.Metadata[metaPageRuleActions])
+		if err != nil {
+			return fmt.Errorf("CF_PAGE_RULE %s: %s", rec.Target, err)
+		}
+		if p, err := strconv.Atoi(rec.Metadata[metaPageRulePriority]); err != nil || p < 1 {
+			return fmt.Errorf("CF_PAGE_RULE %s: invalid priority %q", rec.Target, rec.Metadata[metaPageRulePriority])
+		}
+		rec.Metadata[metaPageRuleActions] = actions
+	default:
+		return nil
+	}
+	rec.Type = "PAGE_RULE"
+	return nil
+}
+
+// desiredPageRules removes the PAGE_RULE records from dc and returns them
+// as page rules.
+func desiredPageRules(dc *models.DomainConfig) ([]*cfPageRule, error) {
+	rules := []*cfPageRule{}
+	records := []*models.RecordConfig{}
+	byPriority := map[int]*cfPageRule{}
+	for _, rec := range dc.Records {
+		if rec.Type != "PAGE_RULE" {
+			records = append(records, rec)
+			continue
+		}
+		prio, _ := strconv.Atoi(rec.Metadata[metaPageRulePriority])
+		r := &cfPageRule{Pattern: rec.Target, Priority: prio, Actions: rec.Metadata[metaPageRuleActions], Status: "active"}
+		if other := byPriority[prio]; other != nil {
+			return nil, fmt.Errorf("page rules %s and %s have the same priority %d", other.Pattern, r.Pattern, prio)
+		}
+		byPriority[prio] = r
+		rules = append(rules, r)
+	}
+	dc.Records = records
+	return rules, nil
+}
+
+// pageRuleChange is an update of an existing rule to a desired one. Either
+// is nil for a creation or a deletion.
+type pageRuleChange struct {
+	existing, desired *cfPageRule
+}
+
+// diffPageRules matches the existing rules with the desired ones: first by
+// pattern and priority, then by pattern only.
+func diffPageRules(existing, desired []*cfPageRule) []pageRuleChange {
+	changes := []pageRuleChange{}
+	used := map[*cfPageRule]bool{}
+	unmatched := []*cfPageRule{}
+	for _, d := range desired {
+		var match *cfPageRule
+		for _, e := range existing {
+			if !used[e] && e.Pattern == d.Pattern && e.Priority == d.Priority {
+				match = e
+				break
+			}
+		}
+		if match == nil {
+			unmatched = append(unmatched, d)
+			continue
+		}
+		used[match] = true
+		if match.Actions != d.Actions || match.Status != d.Status {
+			changes = append(changes, pageRuleChange{match, d})
+		}
+	}
+	created := []*cfPageRule{}
+	for _, d := range unmatched {
+		var match *cfPageRule
+		for _, e := range existing {
+			if !used[e] && e.Pattern == d.Pattern {
+				match = e
+				break
+			}
+		}
+		if match == nil {
+			created = append(created, d)
+			continue
+		}
+		used[match] = true
+		changes = append(changes, pageRuleChange{match, d})
+	}
+	// Deletions first, so that they free the priorities of the others.
+	result := []pageRuleChange{}
+	for _, e := range existing {
+		if !used[e] {
+			result = append(result, pageRuleChange{existing: e})
+		}
+	}
+	result = append(result, changes...)
+	for _, d := range created {
+		result = append(result, pageRuleChange{desired: d})
+	}
+	return result
+}
+
+// pageRuleCorrections returns the corrections that turn the existing page
+// rules of a zone into the desired ones.
+func (c *CloudflareApi) pageRuleCorrections(domainID string, existing, desired []*cfPageRule) []*models.Correction {
+	corrections := []*models.Correction{}
+	for _, ch := range diffPageRules(existing, desired) {
+		ex, des := ch.existing, ch.desired
+		switch {
+		case des == nil:
+			corrections = append(corrections, &models.Correction{
+				Msg:  fmt.Sprintf("DELETE page rule: %s (id=%s)", ex, ex.ID),
+				F:    func() error { return c.deletePageRule(ex.ID, domainID) },
+				Undo: func() error { _, err := c.createPageRule(domainID, ex); return err },
+			})
+		case ex == nil:
+			var id string
+			corrections = append(corrections, &models.Correction{
+				Msg: fmt.Sprintf("CREATE page rule: %s", des),
+				F: func() (err error) {
+					id, err = c.createPageRule(domainID, des)
+					return err
+				},
+				Undo: func() error { return c.deletePageRule(id, domainID) },
+			})
+		default:
+			corrections = append(corrections, &models.Correction{
+				Msg:  fmt.Sprintf("MODIFY page rule: %s -> %s (id=%s)", ex, des, ex.ID),
+				F:    func() error { return c.updatePageRule(ex.ID, domainID, des) },
+				Undo: func() error { return c.updatePageRule(ex.ID, domainID, ex) },
+			})
+		}
+	}
+	return corrections
+}
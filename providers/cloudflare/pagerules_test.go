@@ -0,0 +1,144 @@
+package cloudflare
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/StackExchange/dnscontrol/models"
+)
+
+func TestCanonicalActions(t *testing.T) {
+	found, err := canonicalActions(`{"minify":{"js":"on","css":"off"},"always_use_https":true,"cache_level":"bypass"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"always_use_https":true,"cache_level":"bypass","minify":{"css":"off","js":"on"}}`
+	if found != expected {
+		t.Errorf("expected %s, got %s", expected, found)
+	}
+	for _, raw := range []string{`{}`, `[1]`, `{"always_use_https":"on"}`} {
+		if _, err := canonicalActions(raw); err == nil {
+			t.Errorf("%s: expected an error", raw)
+		}
+	}
+}
+
+func TestPageRuleRoundTrip(t *testing.T) {
+	actions := `{"always_use_https":true,"forwarding_url":{"status_code":301,"url":"https://example.com/$1"}}`
+	list, err := apiActions(actions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Read back what the API would return.
+	data, _ := json.Marshal(&pageRule{
+		ID:       "abc",
+		Priority: 3,
+		Status:   "active",
+		Targets:  []pageRuleTarget{{Target: "url", Constraint: pageRuleConstraint{Operator: "matches", Value: "http://example.com/*"}}},
+		Actions:  list,
+	})
+	if strings.Contains(string(data), `"always_use_https","value"`) {
+		t.Errorf("a flag action was sent with a value: %s", data)
+	}
+	pr := &pageRule{}
+	if err := json.Unmarshal(data, pr); err != nil {
+		t.Fatal(err)
+	}
+	r, err := fromAPI(pr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.ID != "abc" || r.Pattern != "http://example.com/*" || r.Priority != 3 || r.Actions != actions {
+		t.Errorf("unexpected rule %+v", r)
+	}
+}
+
+func pageRules(rules ...string) []*cfPageRule {
+	list := []*cfPageRule{}
+	for i, s := range rules {
+		// pattern,priority,cache_level
+		parts := strings.Split(s, ",")
+		prio := int(parts[1][0] - '0')
+		list = append(list, &cfPageRule{
+			ID:       string('a' + rune(i)),
+			Pattern:  parts[0],
+			Priority: prio,
+			Actions:  `{"cache_level":"` + parts[2] + `"}`,
+			Status:   "active",
+		})
+	}
+	return list
+}
+
+func TestDiffPageRules(t *testing.T) {
+	existing := pageRules("a/*,1,bypass", "b/*,2,bypass", "c/*,3,bypass", "d/*,4,bypass")
+	desired := pageRules("a/*,1,bypass", "b/*,2,aggressive", "c/*,4,bypass", "e/*,3,bypass")
+	var found []string
+	for _, ch := range diffPageRules(existing, desired) {
+		switch {
+		case ch.desired == nil:
+			found = append(found, "delete "+ch.existing.Pattern)
+		case ch.existing == nil:
+			found = append(found, "create "+ch.desired.Pattern)
+		default:
+			found = append(found, "update "+ch.existing.ID+" "+ch.desired.String())
+		}
+	}
+	expected := []string{
+		"delete d/*",
+		`update b b/* (priority 2) {"cache_level":"aggressive"}`,
+		`update c c/* (priority 4) {"cache_level":"bypass"}`,
+		"create e/*",
+	}
+	if strings.Join(found, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected changes:\n%s\nexpected:\n%s", strings.Join(found, "\n"), strings.Join(expected, "\n"))
+	}
+}
+
+func TestPreprocessPageRules(t *testing.T) {
+	cf := &CloudflareApi{manageRedirects: true}
+	domain := newDomainConfig()
+	domain.Records = append(domain.Records,
+		&models.RecordConfig{Type: "CF_REDIRECT", Target: "a.test.com/*,https://test.com/$1"},
+		&models.RecordConfig{Type: "CF_TEMP_REDIRECT", Target: "b.test.com/*,https://test.com/$1"},
+	)
+	if err := cf.preprocessConfig(domain); err != nil {
+		t.Fatal(err)
+	}
+	rules, err := desiredPageRules(domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The first redirect has the highest priority.
+	if len(rules) != 2 || rules[0].Priority != 2 || rules[1].Priority != 1 ||
+		rules[1].Actions != `{"forwarding_url":{"status_code":302,"url":"https://test.com/$1"}}` {
+		t.Errorf("unexpected rules %v", rules)
+	}
+
+	rule := func(prio string) *models.RecordConfig {
+		return &models.RecordConfig{Type: "CF_PAGE_RULE", Target: "c.test.com/*", Metadata: map[string]string{
+			metaPageRuleActions:  `{"cache_level":"bypass"}`,
+			metaPageRulePriority: prio,
+		}}
+	}
+	domain = newDomainConfig()
+	domain.Records = append(domain.Records, rule("1"))
+	if err := cf.preprocessConfig(domain); err == nil {
+		t.Error("expected an error without manage_page_rules")
+	}
+	cf.managePageRules = true
+	domain = newDomainConfig()
+	domain.Records = append(domain.Records, rule("1"), &models.RecordConfig{Type: "CF_REDIRECT", Target: "a.test.com/*,https://test.com/$1"})
+	if err := cf.preprocessConfig(domain); err == nil {
+		t.Error("expected an error for a priority used by a redirect")
+	}
+	domain = newDomainConfig()
+	domain.Records = append(domain.Records, rule("2"), rule("2"))
+	if err := cf.preprocessConfig(domain); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := desiredPageRules(domain); err == nil {
+		t.Error("expected an error for duplicate priorities")
+	}
+}
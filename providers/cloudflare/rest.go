@@ -9,8 +9,6 @@ import (
 
 	"strings"
 
-	"strconv"
-
 	"github.com/StackExchange/dnscontrol/models"
 )
 
@@ -21,6 +19,8 @@ const (
 	pageRulesURL      = zonesURL + "%s/pagerules/"
 	singlePageRuleURL = pageRulesURL + "%s"
 	singleRecordURL   = recordsURL + "%s"
+	settingsURL       = zonesURL + "%s/settings/"
+	singleSettingURL  = settingsURL + "%s"
 )
 
 // get list of domains for account. Cache so the ids can be looked up from domain name
@@ -83,20 +83,48 @@ func (c *CloudflareApi) getRecordsForDomain(id string, domain string) ([]*models
 // create a correction to delete a record
 func (c *CloudflareApi) deleteRec(rec *cfRecord, domainID string) *models.Correction {
 	return &models.Correction{
-		Msg: fmt.Sprintf("DELETE record: %s %s %d %s (id=%s)", rec.Name, rec.Type, rec.TTL, rec.Content, rec.ID),
-		F: func() error {
-			endpoint := fmt.Sprintf(singleRecordURL, domainID, rec.ID)
-			req, err := http.NewRequest("DELETE", endpoint, nil)
-			if err != nil {
-				return err
-			}
-			c.setHeaders(req)
-			_, err = handleActionResponse(http.DefaultClient.Do(req))
-			return err
-		},
+		Msg:  fmt.Sprintf("DELETE record: %s %s %d %s (id=%s)", rec.Name, rec.Type, rec.TTL, rec.Content, rec.ID),
+		F:    func() error { return c.deleteRecord(domainID, rec.ID) },
+		Undo: func() error { return c.sendRecord("POST", fmt.Sprintf(recordsURL, domainID), rec) },
 	}
 }
 
+func (c *CloudflareApi) deleteRecord(domainID, recID string) error {
+	endpoint := fmt.Sprintf(singleRecordURL, domainID, recID)
+	req, err := http.NewRequest("DELETE", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	c.setHeaders(req)
+	_, err = handleActionResponse(c.client.Do(req))
+	return err
+}
+
+// sendRecord creates or updates a record as it was read from the API. It is
+// used to undo changes.
+func (c *CloudflareApi) sendRecord(method, endpoint string, rec *cfRecord) error {
+	type record struct {
+		Name     string     `json:"name"`
+		Type     string     `json:"type"`
+		Content  string     `json:"content,omitempty"`
+		TTL      uint32     `json:"ttl"`
+		Priority uint16     `json:"priority"`
+		Proxied  bool       `json:"proxied"`
+		Data     *cfRecData `json:"data,omitempty"`
+	}
+	buf := &bytes.Buffer{}
+	if err := json.NewEncoder(buf).Encode(record{rec.Name, rec.Type, rec.Content, rec.TTL, rec.Priority, rec.Proxied, rec.Data}); err != nil {
+		return err
+	}
+	req, err := http.NewRequest(method, endpoint, buf)
+	if err != nil {
+		return err
+	}
+	c.setHeaders(req)
+	_, err = handleActionResponse(c.client.Do(req))
+	return err
+}
+
 func (c *CloudflareApi) createZone(domainName string) (string, error) {
 	type createZone struct {
 		Name string `json:"name"`
@@ -114,7 +142,7 @@ func (c *CloudflareApi) createZone(domainName string) (string, error) {
 		return "", err
 	}
 	c.setHeaders(req)
-	id, err = handleActionResponse(http.DefaultClient.Do(req))
+	id, err = handleActionResponse(c.client.Do(req))
 	return id, err
 }
 
@@ -187,14 +215,16 @@ func (c *CloudflareApi) createRec(rec *models.RecordConfig, domainID string) []*
 				return err
 			}
 			c.setHeaders(req)
-			id, err = handleActionResponse(http.DefaultClient.Do(req))
+			id, err = handleActionResponse(c.client.Do(req))
 			return err
 		},
+		Undo: func() error { return c.deleteRecord(domainID, id) },
 	}}
 	if rec.Metadata[metaProxy] != "off" {
 		arr = append(arr, &models.Correction{
-			Msg: fmt.Sprintf("ACTIVATE PROXY for new record %s %s %d %s", rec.Name, rec.Type, rec.TTL, rec.Target),
-			F:   func() error { return c.modifyRecord(domainID, id, true, rec) },
+			Msg:  fmt.Sprintf("ACTIVATE PROXY for new record %s %s %d %s", rec.Name, rec.Type, rec.TTL, rec.Target),
+			F:    func() error { return c.modifyRecord(domainID, id, true, rec) },
+			Undo: func() error { return c.modifyRecord(domainID, id, false, rec) },
 		})
 	}
 	return arr
@@ -234,7 +264,7 @@ func (c *CloudflareApi) modifyRecord(domainID, recID string, proxied bool, rec *
 		return err
 	}
 	c.setHeaders(req)
-	_, err = handleActionResponse(http.DefaultClient.Do(req))
+	_, err = handleActionResponse(c.client.Do(req))
 	return err
 }
 
@@ -250,7 +280,7 @@ func handleActionResponse(resp *http.Response, err error) (id string, e error) {
 		return "", fmt.Errorf("Unknown error. Status code: %d", resp.StatusCode)
 	}
 	if resp.StatusCode != 200 {
-		return "", fmt.Errorf(stringifyErrors(result.Errors))
+		return "", fmt.Errorf("%s", stringifyErrors(result.Errors))
 	}
 	return result.Result.ID, nil
 }
@@ -267,7 +297,7 @@ func (c *CloudflareApi) get(endpoint string, target interface{}) error {
 		return err
 	}
 	c.setHeaders(req)
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.client.Do(req)
 	if err != nil {
 		return err
 	}
@@ -279,7 +309,7 @@ func (c *CloudflareApi) get(endpoint string, target interface{}) error {
 	return decoder.Decode(target)
 }
 
-func (c *CloudflareApi) getPageRules(id string, domain string) ([]*models.RecordConfig, error) {
+func (c *CloudflareApi) getPageRules(id string) ([]*pageRule, error) {
 	url := fmt.Sprintf(pageRulesURL, id)
 	data := pageRuleResponse{}
 	if err := c.get(url, &data); err != nil {
@@ -288,31 +318,7 @@ func (c *CloudflareApi) getPageRules(id string, domain string) ([]*models.Record
 	if !data.Success {
 		return nil, fmt.Errorf("Error fetching page rule list cloudflare: %s", stringifyErrors(data.Errors))
 	}
-	recs := []*models.RecordConfig{}
-	for _, pr := range data.Result {
-		// only interested in forwarding rules. Lets be very specific, and skip anything else
-		if len(pr.Actions) != 1 || len(pr.Targets) != 1 {
-			continue
-		}
-		if pr.Actions[0].ID != "forwarding_url" {
-			continue
-		}
-		err := json.Unmarshal([]byte(pr.Actions[0].Value), &pr.ForwardingInfo)
-		if err != nil {
-			return nil, err
-		}
-		var thisPr = pr
-		recs = append(recs, &models.RecordConfig{
-			Name:     "@",
-			NameFQDN: domain,
-			Type:     "PAGE_RULE",
-			// $FROM,$TO,$PRIO,$CODE
-			Target:   fmt.Sprintf("%s,%s,%d,%d", pr.Targets[0].Constraint.Value, pr.ForwardingInfo.URL, pr.Priority, pr.ForwardingInfo.StatusCode),
-			Original: thisPr,
-			TTL:      1,
-		})
-	}
-	return recs, nil
+	return data.Result, nil
 }
 
 func (c *CloudflareApi) deletePageRule(recordID, domainID string) error {
@@ -322,53 +328,75 @@ func (c *CloudflareApi) deletePageRule(recordID, domainID string) error {
 		return err
 	}
 	c.setHeaders(req)
-	_, err = handleActionResponse(http.DefaultClient.Do(req))
+	_, err = handleActionResponse(c.client.Do(req))
 	return err
 }
 
-func (c *CloudflareApi) updatePageRule(recordID, domainID string, target string) error {
-	if err := c.deletePageRule(recordID, domainID); err != nil {
-		return err
-	}
-	return c.createPageRule(domainID, target)
+// updatePageRule replaces the rule recordID with rule, keeping its id.
+func (c *CloudflareApi) updatePageRule(recordID, domainID string, rule *cfPageRule) error {
+	endpoint := fmt.Sprintf(singlePageRuleURL, domainID, recordID)
+	_, err := c.sendPageRule(endpoint, "PUT", rule)
+	return err
 }
 
-func (c *CloudflareApi) createPageRule(domainID string, target string) error {
+// createPageRule creates rule and returns its id.
+func (c *CloudflareApi) createPageRule(domainID string, rule *cfPageRule) (string, error) {
 	endpoint := fmt.Sprintf(pageRulesURL, domainID)
-	return c.sendPageRule(endpoint, "POST", target)
+	return c.sendPageRule(endpoint, "POST", rule)
 }
 
-func (c *CloudflareApi) sendPageRule(endpoint, method string, data string) error {
-	// from to priority code
-	parts := strings.Split(data, ",")
-	priority, _ := strconv.Atoi(parts[2])
-	code, _ := strconv.Atoi(parts[3])
-	fwdInfo := &pageRuleFwdInfo{
-		StatusCode: code,
-		URL:        parts[1],
+func (c *CloudflareApi) sendPageRule(endpoint, method string, rule *cfPageRule) (string, error) {
+	actions, err := apiActions(rule.Actions)
+	if err != nil {
+		return "", err
+	}
+	status := rule.Status
+	if status == "" {
+		status = "active"
 	}
-	dat, _ := json.Marshal(fwdInfo)
 	pr := &pageRule{
-		Status:   "active",
-		Priority: priority,
+		Status:   status,
+		Priority: rule.Priority,
 		Targets: []pageRuleTarget{
-			{Target: "url", Constraint: pageRuleConstraint{Operator: "matches", Value: parts[0]}},
-		},
-		Actions: []pageRuleAction{
-			{ID: "forwarding_url", Value: json.RawMessage(dat)},
+			{Target: "url", Constraint: pageRuleConstraint{Operator: "matches", Value: rule.Pattern}},
 		},
+		Actions: actions,
 	}
 	buf := &bytes.Buffer{}
 	enc := json.NewEncoder(buf)
 	if err := enc.Encode(pr); err != nil {
-		return err
+		return "", err
 	}
 	req, err := http.NewRequest(method, endpoint, buf)
+	if err != nil {
+		return "", err
+	}
+	c.setHeaders(req)
+	return handleActionResponse(c.client.Do(req))
+}
+
+func (c *CloudflareApi) getSettings(domainID string) ([]*zoneSetting, error) {
+	data := settingsResponse{}
+	if err := c.get(fmt.Sprintf(settingsURL, domainID), &data); err != nil {
+		return nil, fmt.Errorf("Error fetching zone settings from cloudflare: %s", err)
+	}
+	if !data.Success {
+		return nil, fmt.Errorf("Error fetching zone settings from cloudflare: %s", stringifyErrors(data.Errors))
+	}
+	return data.Result, nil
+}
+
+func (c *CloudflareApi) updateSetting(domainID, setting string, value interface{}) error {
+	buf := &bytes.Buffer{}
+	if err := json.NewEncoder(buf).Encode(map[string]interface{}{"value": value}); err != nil {
+		return err
+	}
+	req, err := http.NewRequest("PATCH", fmt.Sprintf(singleSettingURL, domainID, setting), buf)
 	if err != nil {
 		return err
 	}
 	c.setHeaders(req)
-	_, err = handleActionResponse(http.DefaultClient.Do(req))
+	_, err = handleActionResponse(c.client.Do(req))
 	return err
 }
 
@@ -402,14 +430,13 @@ type pageRuleResponse struct {
 }
 
 type pageRule struct {
-	ID             string           `json:"id,omitempty"`
-	Targets        []pageRuleTarget `json:"targets"`
-	Actions        []pageRuleAction `json:"actions"`
-	Priority       int              `json:"priority"`
-	Status         string           `json:"status"`
-	ModifiedOn     time.Time        `json:"modified_on,omitempty"`
-	CreatedOn      time.Time        `json:"created_on,omitempty"`
-	ForwardingInfo *pageRuleFwdInfo `json:"-"`
+	ID         string           `json:"id,omitempty"`
+	Targets    []pageRuleTarget `json:"targets"`
+	Actions    []pageRuleAction `json:"actions"`
+	Priority   int              `json:"priority"`
+	Status     string           `json:"status"`
+	ModifiedOn time.Time        `json:"modified_on,omitempty"`
+	CreatedOn  time.Time        `json:"created_on,omitempty"`
 }
 
 type pageRuleTarget struct {
@@ -424,12 +451,18 @@ type pageRuleConstraint struct {
 
 type pageRuleAction struct {
 	ID    string          `json:"id"`
-	Value json.RawMessage `json:"value"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+type settingsResponse struct {
+	basicResponse
+	Result []*zoneSetting `json:"result"`
 }
 
-type pageRuleFwdInfo struct {
-	URL        string `json:"url"`
-	StatusCode int    `json:"status_code"`
+type zoneSetting struct {
+	ID       string      `json:"id"`
+	Value    interface{} `json:"value"`
+	Editable bool        `json:"editable"`
 }
 
 type zoneResponse struct {
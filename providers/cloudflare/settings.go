@@ -0,0 +1,118 @@
+package cloudflare
+
+// Zone settings: CF_SETTING.
+//
+// Only the settings declared with CF_SETTING are managed. The others keep
+// whatever value they have in Cloudflare.
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/StackExchange/dnscontrol/models"
+)
+
+// metaSettingPrefix is followed by the id of the setting in the domain metadata.
+const metaSettingPrefix = "cloudflare_setting_"
+
+// desiredSettings returns the settings declared for dc.
+func desiredSettings(dc *models.DomainConfig) map[string]string {
+	settings := map[string]string{}
+	for k, v := range dc.Metadata {
+		if strings.HasPrefix(k, metaSettingPrefix) {
+			settings[strings.TrimPrefix(k, metaSettingPrefix)] = v
+		}
+	}
+	return settings
+}
+
+// settingValue converts the value of a CF_SETTING to the type of the current
+// value of the setting. An object only sets the fields it has: the others
+// keep their current value.
+func settingValue(desired string, current interface{}) (interface{}, error) {
+	switch cur := current.(type) {
+	case float64:
+		return strconv.ParseFloat(desired, 64)
+	case map[string]interface{}:
+		var obj map[string]interface{}
+		if err := json.Unmarshal([]byte(desired), &obj); err != nil {
+			return nil, fmt.Errorf("the value must be a JSON object: %s", err)
+		}
+		return mergeObjects(cur, obj), nil
+	case string, nil:
+		return desired, nil
+	}
+	return nil, fmt.Errorf("unsupported value type %T", current)
+}
+
+func mergeObjects(current, desired map[string]interface{}) map[string]interface{} {
+	merged := map[string]interface{}{}
+	for k, v := range current {
+		merged[k] = v
+	}
+	for k, v := range desired {
+		cur, ok1 := merged[k].(map[string]interface{})
+		des, ok2 := v.(map[string]interface{})
+		if ok1 && ok2 {
+			v = mergeObjects(cur, des)
+		}
+		merged[k] = v
+	}
+	return merged
+}
+
+func formatSetting(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// settingCorrections returns a correction for each setting declared for dc
+// that has another value in Cloudflare.
+func (c *CloudflareApi) settingCorrections(domainID string, dc *models.DomainConfig) ([]*models.Correction, error) {
+	desired := desiredSettings(dc)
+	if len(desired) == 0 {
+		return nil, nil
+	}
+	existing, err := c.getSettings(domainID)
+	if err != nil {
+		return nil, err
+	}
+	byID := map[string]*zoneSetting{}
+	for _, s := range existing {
+		byID[s.ID] = s
+	}
+	ids := make([]string, 0, len(desired))
+	for id := range desired {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	corrections := []*models.Correction{}
+	for _, id := range ids {
+		cur, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("CF_SETTING %s: no such setting for %s", id, dc.Name)
+		}
+		value, err := settingValue(desired[id], cur.Value)
+		if err != nil {
+			return nil, fmt.Errorf("CF_SETTING %s: %s", id, err)
+		}
+		if reflect.DeepEqual(value, cur.Value) {
+			continue
+		}
+		if !cur.Editable {
+			return nil, fmt.Errorf("CF_SETTING %s: the setting can't be changed for %s", id, dc.Name)
+		}
+		id, old := id, cur.Value
+		corrections = append(corrections, &models.Correction{
+			Msg:  fmt.Sprintf("MODIFY setting %s: %s -> %s", id, formatSetting(old), formatSetting(value)),
+			F:    func() error { return c.updateSetting(domainID, id, value) },
+			Undo: func() error { return c.updateSetting(domainID, id, old) },
+		})
+	}
+	return corrections, nil
+}
@@ -0,0 +1,47 @@
+package cloudflare
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSettingValue(t *testing.T) {
+	current := func(s string) interface{} {
+		var v interface{}
+		if err := json.Unmarshal([]byte(s), &v); err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	tests := []struct {
+		desired, current, expected string
+	}{
+		{"1.2", `"1.0"`, `"1.2"`},
+		{"on", `"on"`, `"on"`},
+		{"14400", `7200`, `14400`},
+		{
+			`{"strict_transport_security":{"enabled":true}}`,
+			`{"strict_transport_security":{"enabled":false,"max_age":0}}`,
+			`{"strict_transport_security":{"enabled":true,"max_age":0}}`,
+		},
+	}
+	for _, tst := range tests {
+		found, err := settingValue(tst.desired, current(tst.current))
+		if err != nil {
+			t.Errorf("%s: %s", tst.desired, err)
+			continue
+		}
+		if !reflect.DeepEqual(found, current(tst.expected)) {
+			t.Errorf("%s: expected %s, got %s", tst.desired, tst.expected, formatSetting(found))
+		}
+	}
+	for _, tst := range []struct{ desired, current string }{
+		{"fast", `7200`},
+		{"on", `{"enabled":true}`},
+	} {
+		if _, err := settingValue(tst.desired, current(tst.current)); err == nil {
+			t.Errorf("%s: expected an error", tst.desired)
+		}
+	}
+}
@@ -41,7 +41,11 @@ func NewDo(m map[string]string, metadata json.RawMessage) (providers.DNSServiceP
 		return nil, fmt.Errorf("no Digitalocean token provided")
 	}
 
-	ctx := context.Background()
+	hc, err := providers.NewHTTPClient("DIGITALOCEAN", m)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, hc)
 	oauthClient := oauth2.NewClient(
 		ctx,
 		oauth2.StaticTokenSource(&oauth2.Token{AccessToken: m["token"]}),
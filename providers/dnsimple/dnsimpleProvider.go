@@ -3,9 +3,11 @@ package dnsimple
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/StackExchange/dnscontrol/models"
 	"github.com/StackExchange/dnscontrol/providers"
@@ -45,6 +47,7 @@ type DnsimpleApi struct {
 	AccountToken string // The account access token
 	BaseURL      string // An alternate base URI
 	accountID    string // Account id cache
+	httpClient   *http.Client
 }
 
 // GetNameservers returns the name servers for a domain.
@@ -166,6 +169,7 @@ func (c *DnsimpleApi) GetRegistrarCorrections(dc *models.DomainConfig) ([]*model
 
 func (c *DnsimpleApi) getClient() *dnsimpleapi.Client {
 	client := dnsimpleapi.NewClient(dnsimpleapi.NewOauthTokenCredentials(c.AccountToken))
+	client.HttpClient = c.httpClient
 	if c.BaseURL != "" {
 		client.BaseURL = c.BaseURL
 	}
@@ -242,6 +246,39 @@ func (c *DnsimpleApi) getNameservers(domainName string) ([]string, error) {
 	return defaultNameServerNames, nil
 }
 
+// GetRegistrarStatus returns the expiry date and delegation of a domain registered with DNSimple.
+// The DNSimple API client does not expose the transfer lock, so Locked is unknown.
+func (c *DnsimpleApi) GetRegistrarStatus(domainName string) (*models.RegistrarStatus, error) {
+	client := c.getClient()
+
+	accountID, err := c.getAccountID()
+	if err != nil {
+		return nil, err
+	}
+
+	domainResponse, err := client.Domains.GetDomain(accountID, domainName)
+	if err != nil {
+		return nil, err
+	}
+	if domainResponse.Data.State != stateRegistered {
+		return nil, fmt.Errorf("%s is not registered with DNSimple (state %q)", domainName, domainResponse.Data.State)
+	}
+
+	status := &models.RegistrarStatus{Domain: domainName}
+	if domainResponse.Data.ExpiresOn != "" {
+		expiry, err := time.Parse("2006-01-02", domainResponse.Data.ExpiresOn)
+		if err != nil {
+			return nil, fmt.Errorf("parsing expiry date of %s: %s", domainName, err)
+		}
+		status.SetExpiry(expiry)
+	}
+	status.Nameservers, err = c.getNameservers(domainName)
+	if err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
 // Returns a function that can be invoked to change the delegation of the domain to the given name server names.
 func (c *DnsimpleApi) updateNameserversFunc(nameServerNames []string, domainName string) func() error {
 	return func() error {
@@ -351,6 +388,11 @@ func newProvider(m map[string]string, metadata json.RawMessage) (*DnsimpleApi, e
 	if api.AccountToken == "" {
 		return nil, fmt.Errorf("missing DNSimple token")
 	}
+	hc, err := providers.NewHTTPClient("DNSIMPLE", m)
+	if err != nil {
+		return nil, err
+	}
+	api.httpClient = hc
 
 	if m["baseurl"] != "" {
 		api.BaseURL = m["baseurl"]
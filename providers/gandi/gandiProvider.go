@@ -9,10 +9,12 @@ import (
 	"github.com/StackExchange/dnscontrol/models"
 	"github.com/StackExchange/dnscontrol/providers"
 	"github.com/StackExchange/dnscontrol/providers/diff"
+	"github.com/kolo/xmlrpc"
 	"github.com/pkg/errors"
 
 	"strings"
 
+	gandiclient "github.com/prasmussen/gandi-api/client"
 	gandidomain "github.com/prasmussen/gandi-api/domain"
 	gandirecord "github.com/prasmussen/gandi-api/domain/zone/record"
 )
@@ -43,6 +45,7 @@ func init() {
 // GandiApi is the API handle for this module.
 type GandiApi struct {
 	ApiKey      string
+	rpc         *xmlrpc.Client
 	domainIndex map[string]int64 // Map of domainname to index
 	nameservers map[string][]*models.Nameserver
 	ZoneId      int64
@@ -76,6 +79,31 @@ func (c *GandiApi) GetNameservers(domain string) ([]*models.Nameserver, error) {
 	return ns, nil
 }
 
+// GetRegistrarStatus returns the expiry date, lock state and delegation of a domain registered with gandi.
+func (c *GandiApi) GetRegistrarStatus(domain string) (*models.RegistrarStatus, error) {
+	domaininfo, err := c.getDomainInfo(domain)
+	if err != nil {
+		return nil, err
+	}
+	status := &models.RegistrarStatus{
+		Domain:      domain,
+		Nameservers: domaininfo.Nameservers,
+	}
+	if domaininfo.DomainInfoBase != nil {
+		if !domaininfo.DateRegistryEnd.IsZero() {
+			status.SetExpiry(domaininfo.DateRegistryEnd)
+		}
+		locked := false
+		for _, st := range domaininfo.Status {
+			if st == "lock" || st == "clientTransferProhibited" {
+				locked = true
+			}
+		}
+		status.SetLocked(locked)
+	}
+	return status, nil
+}
+
 // GetDomainCorrections returns a list of corrections recommended for this domain.
 func (c *GandiApi) GetDomainCorrections(dc *models.DomainConfig) ([]*models.Correction, error) {
 	dc.Punycode()
@@ -173,6 +201,16 @@ func newGandi(m map[string]string, metadata json.RawMessage) (*GandiApi, error)
 	if api.ApiKey == "" {
 		return nil, fmt.Errorf("missing Gandi apikey")
 	}
+	// The client of gandi-api can't take a transport, so the XML-RPC client
+	// is built here.
+	hc, err := providers.NewHTTPClient("GANDI", m)
+	if err != nil {
+		return nil, err
+	}
+	api.rpc, err = xmlrpc.NewClient(gandiclient.Production.Url(), hc.Transport)
+	if err != nil {
+		return nil, err
+	}
 
 	return api, nil
 }
@@ -3,32 +3,57 @@ package gandi
 import (
 	"fmt"
 
-	gandiclient "github.com/prasmussen/gandi-api/client"
 	gandidomain "github.com/prasmussen/gandi-api/domain"
-	gandinameservers "github.com/prasmussen/gandi-api/domain/nameservers"
 	gandizone "github.com/prasmussen/gandi-api/domain/zone"
 	gandirecord "github.com/prasmussen/gandi-api/domain/zone/record"
-	gandiversion "github.com/prasmussen/gandi-api/domain/zone/version"
 	gandioperation "github.com/prasmussen/gandi-api/operation"
 
 	"github.com/StackExchange/dnscontrol/models"
 	"github.com/miekg/dns/dnsutil"
 )
 
+// call calls a method of the XML-RPC API. The API key is the first parameter
+// of every method.
+func (c *GandiApi) call(method string, reply interface{}, params ...interface{}) error {
+	return c.rpc.Call(method, append([]interface{}{c.ApiKey}, params...), reply)
+}
+
+// listPages calls a method of the XML-RPC API that returns a list, 100
+// items per page, until the last page. The options of the page are the last
+// parameter.
+func (c *GandiApi) listPages(method string, params ...interface{}) ([]map[string]interface{}, error) {
+	const perPage = 100
+	opts := &struct {
+		Page int `xmlrpc:"page"`
+	}{0}
+	items := []map[string]interface{}{}
+	for {
+		var res []interface{}
+		if err := c.call(method, &res, append(params, opts)...); err != nil {
+			return nil, err
+		}
+		for _, r := range res {
+			items = append(items, r.(map[string]interface{}))
+		}
+		if len(res) < perPage {
+			return items, nil
+		}
+		opts.Page++
+	}
+}
+
 // fetchDomainList gets list of domains for account. Cache ids for easy lookup.
 func (c *GandiApi) fetchDomainList() error {
 	if c.domainIndex != nil {
 		return nil
 	}
 	c.domainIndex = map[string]int64{}
-	gc := gandiclient.New(c.ApiKey, gandiclient.Production)
-	domain := gandidomain.New(gc)
-	domains, err := domain.List()
+	domains, err := c.listPages("domain.list")
 	if err != nil {
-		// fmt.Println(err)
 		return err
 	}
-	for _, d := range domains {
+	for _, r := range domains {
+		d := gandidomain.ToDomainInfoBase(r)
 		c.domainIndex[d.Fqdn] = d.Id
 	}
 	return nil
@@ -36,59 +61,73 @@ func (c *GandiApi) fetchDomainList() error {
 
 // fetchDomainInfo gets information about a domain.
 func (c *GandiApi) fetchDomainInfo(fqdn string) (*gandidomain.DomainInfo, error) {
-	gc := gandiclient.New(c.ApiKey, gandiclient.Production)
-	domain := gandidomain.New(gc)
-	return domain.Info(fqdn)
+	var res map[string]interface{}
+	if err := c.call("domain.info", &res, fqdn); err != nil {
+		return nil, err
+	}
+	return gandidomain.ToDomainInfo(res), nil
 }
 
 // setDomainNameservers updates the nameservers of a domain.
 func (c *GandiApi) setDomainNameservers(fqdn string, nameservers []string) (*gandioperation.OperationInfo, error) {
-	gc := gandiclient.New(c.ApiKey, gandiclient.Production)
-	nameserversapi := gandinameservers.New(gc)
-	return nameserversapi.Set(fqdn, nameservers)
+	var res map[string]interface{}
+	if err := c.call("domain.nameservers.set", &res, fqdn, nameservers); err != nil {
+		return nil, err
+	}
+	return gandioperation.ToOperationInfo(res), nil
 }
 
 // getRecordsForDomain returns a list of records for a zone.
 func (c *GandiApi) getZoneRecords(zoneid int64, origin string) ([]*models.RecordConfig, error) {
-	gc := gandiclient.New(c.ApiKey, gandiclient.Production)
-	record := gandirecord.New(gc)
-	recs, err := record.List(zoneid, 0)
+	recs, err := c.listPages("domain.zone.record.list", zoneid, 0)
 	if err != nil {
 		return nil, err
 	}
 	rcs := make([]*models.RecordConfig, 0, len(recs))
 	for _, r := range recs {
-		rcs = append(rcs, convert(r, origin))
+		rcs = append(rcs, convert(gandirecord.ToRecordInfo(r), origin))
 	}
 	return rcs, nil
 }
 
 // listZones retrieves the list of zones.
 func (c *GandiApi) listZones() ([]*gandizone.ZoneInfoBase, error) {
-	gc := gandiclient.New(c.ApiKey, gandiclient.Production)
-	zone := gandizone.New(gc)
-	return zone.List()
+	var res []interface{}
+	if err := c.call("domain.zone.list", &res); err != nil {
+		return nil, err
+	}
+	zones := make([]*gandizone.ZoneInfoBase, 0, len(res))
+	for _, r := range res {
+		zones = append(zones, gandizone.ToZoneInfoBase(r.(map[string]interface{})))
+	}
+	return zones, nil
 }
 
 // setZone assigns a particular zone to a domain.
 func (c *GandiApi) setZones(domainname string, zoneID int64) (*gandidomain.DomainInfo, error) {
-	gc := gandiclient.New(c.ApiKey, gandiclient.Production)
-	zone := gandizone.New(gc)
-	return zone.Set(domainname, zoneID)
+	var res map[string]interface{}
+	if err := c.call("domain.zone.set", &res, domainname, zoneID); err != nil {
+		return nil, err
+	}
+	return gandidomain.ToDomainInfo(res), nil
 }
 
 // getZoneInfo gets ZoneInfo about a zone.
 func (c *GandiApi) getZoneInfo(zoneid int64) (*gandizone.ZoneInfo, error) {
-	gc := gandiclient.New(c.ApiKey, gandiclient.Production)
-	zone := gandizone.New(gc)
-	return zone.Info(zoneid)
+	var res map[string]interface{}
+	if err := c.call("domain.zone.info", &res, zoneid); err != nil {
+		return nil, err
+	}
+	return gandizone.ToZoneInfo(res), nil
 }
 
 // createZone creates an entirely new zone.
 func (c *GandiApi) createZone(name string) (*gandizone.ZoneInfo, error) {
-	gc := gandiclient.New(c.ApiKey, gandiclient.Production)
-	zone := gandizone.New(gc)
-	return zone.Create(name)
+	var res map[string]interface{}
+	if err := c.call("domain.zone.create", &res, map[string]interface{}{"name": name}); err != nil {
+		return nil, err
+	}
+	return gandizone.ToZoneInfo(res), nil
 }
 
 func (c *GandiApi) getEditableZone(domainname string, zoneinfo *gandizone.ZoneInfo) (int64, error) {
@@ -124,23 +163,31 @@ func (c *GandiApi) getEditableZone(domainname string, zoneinfo *gandizone.ZoneIn
 
 // makeEditableZone
 func (c *GandiApi) makeEditableZone(zoneID int64) (int64, error) {
-	gc := gandiclient.New(c.ApiKey, gandiclient.Production)
-	version := gandiversion.New(gc)
-	return version.New(zoneID, 0)
+	var res int64
+	if err := c.call("domain.zone.version.new", &res, zoneID, 0); err != nil {
+		return -1, err
+	}
+	return res, nil
 }
 
 // setZoneRecords
 func (c *GandiApi) setZoneRecords(zoneID, versionID int64, records []gandirecord.RecordSet) ([]*gandirecord.RecordInfo, error) {
-	gc := gandiclient.New(c.ApiKey, gandiclient.Production)
-	record := gandirecord.New(gc)
-	return record.SetRecords(zoneID, versionID, records)
+	var res []interface{}
+	if err := c.call("domain.zone.record.set", &res, zoneID, versionID, records); err != nil {
+		return nil, err
+	}
+	infos := make([]*gandirecord.RecordInfo, 0, len(res))
+	for _, r := range res {
+		infos = append(infos, gandirecord.ToRecordInfo(r.(map[string]interface{})))
+	}
+	return infos, nil
 }
 
 // activateVersion
 func (c *GandiApi) activateVersion(zoneID, versionID int64) (bool, error) {
-	gc := gandiclient.New(c.ApiKey, gandiclient.Production)
-	version := gandiversion.New(gc)
-	return version.Set(zoneID, versionID)
+	var res bool
+	err := c.call("domain.zone.version.set", &res, zoneID, versionID)
+	return res, err
 }
 
 func (c *GandiApi) createGandiZone(domainname string, zoneID int64, records []gandirecord.RecordSet) error {
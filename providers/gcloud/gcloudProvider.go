@@ -4,8 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"strings"
 
+	"golang.org/x/oauth2"
 	gauth "golang.org/x/oauth2/google"
 	"google.golang.org/api/dns/v1"
 
@@ -28,13 +30,15 @@ func init() {
 }
 
 type gcloud struct {
-	client  *dns.Service
-	project string
-	zones   map[string]*dns.ManagedZone
+	client   *dns.Service
+	hc       *http.Client
+	apiURL   string
+	defaults zoneSettings
+	zones    map[string]map[string][]*managedZone // By project, then by DNS name.
 }
 
 // New creates a new gcloud provider
-func New(cfg map[string]string, _ json.RawMessage) (providers.DNSServiceProvider, error) {
+func New(cfg map[string]string, metadata json.RawMessage) (providers.DNSServiceProvider, error) {
 	raw, err := json.Marshal(cfg)
 	if err != nil {
 		return nil, err
@@ -43,16 +47,37 @@ func New(cfg map[string]string, _ json.RawMessage) (providers.DNSServiceProvider
 	if err != nil {
 		return nil, err
 	}
-	ctx := context.Background()
+	base, err := providers.NewHTTPClient("GCLOUD", cfg)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, base)
 	hc := config.Client(ctx)
 	dcli, err := dns.New(hc)
 	if err != nil {
 		return nil, err
 	}
-	return &gcloud{
-		client:  dcli,
-		project: cfg["project_id"],
-	}, nil
+	g := &gcloud{
+		client: dcli,
+		hc:     hc,
+		apiURL: "https://www.googleapis.com/dns/v1",
+		zones:  map[string]map[string][]*managedZone{},
+	}
+	if len(metadata) > 0 {
+		if err := json.Unmarshal(metadata, &g.defaults); err != nil {
+			return nil, err
+		}
+		if g.defaults.Zone != "" {
+			return nil, fmt.Errorf("gcloud: zone can only be set for a domain, with %s", metaZone)
+		}
+		if err := g.defaults.check(); err != nil {
+			return nil, fmt.Errorf("gcloud: %s", err)
+		}
+	}
+	if g.defaults.Project == "" {
+		g.defaults.Project = cfg["project_id"]
+	}
+	return g, nil
 }
 
 type errNoExist struct {
@@ -63,31 +88,13 @@ func (e errNoExist) Error() string {
 	return fmt.Sprintf("Domain %s not found in gcloud account", e.domain)
 }
 
-func (g *gcloud) getZone(domain string) (*dns.ManagedZone, error) {
-	if g.zones == nil {
-		g.zones = map[string]*dns.ManagedZone{}
-		pageToken := ""
-		for {
-			resp, err := g.client.ManagedZones.List(g.project).PageToken(pageToken).Do()
-			if err != nil {
-				return nil, err
-			}
-			for _, z := range resp.ManagedZones {
-				g.zones[z.DnsName] = z
-			}
-			if pageToken = resp.NextPageToken; pageToken == "" {
-				break
-			}
-		}
-	}
-	if g.zones[domain+"."] == nil {
-		return nil, errNoExist{domain}
-	}
-	return g.zones[domain+"."], nil
-}
-
 func (g *gcloud) GetNameservers(domain string) ([]*models.Nameserver, error) {
-	zone, err := g.getZone(domain)
+	s := g.defaults
+	if zones, err := g.getZones(s.Project, domain); err == nil && len(zones) > 1 && s.Visibility == "" {
+		// Only public zones are delegated.
+		s.Visibility = "public"
+	}
+	zone, err := g.findZone(domain, &s)
 	if err != nil {
 		return nil, err
 	}
@@ -110,7 +117,16 @@ func (g *gcloud) GetDomainCorrections(dc *models.DomainConfig) ([]*models.Correc
 	if err := dc.Punycode(); err != nil {
 		return nil, err
 	}
-	rrs, zoneName, err := g.getRecords(dc.Name)
+	settings, err := g.settings(dc)
+	if err != nil {
+		return nil, err
+	}
+	zone, err := g.findZone(dc.Name, settings)
+	if err != nil {
+		return nil, err
+	}
+	corrections := g.zoneCorrections(zone, settings)
+	rrs, err := g.getRecords(settings.Project, zone.Name)
 	if err != nil {
 		return nil, err
 	}
@@ -160,7 +176,7 @@ func (g *gcloud) GetDomainCorrections(dc *models.DomainConfig) ([]*models.Correc
 		changedKeys[keyForRec(m.Existing)] = true
 	}
 	if len(changedKeys) == 0 {
-		return nil, nil
+		return corrections, nil
 	}
 	chg := &dns.Change{Kind: "dns#change"}
 	for ck := range changedKeys {
@@ -186,30 +202,26 @@ func (g *gcloud) GetDomainCorrections(dc *models.DomainConfig) ([]*models.Correc
 	}
 
 	runChange := func() error {
-		_, err := g.client.Changes.Create(g.project, zoneName, chg).Do()
+		_, err := g.client.Changes.Create(settings.Project, zone.Name, chg).Do()
 		return err
 	}
-	return []*models.Correction{{
+	return append(corrections, &models.Correction{
 		Msg: desc,
 		F:   runChange,
-	}}, nil
+	}), nil
 }
 
-func (g *gcloud) getRecords(domain string) ([]*dns.ResourceRecordSet, string, error) {
-	zone, err := g.getZone(domain)
-	if err != nil {
-		return nil, "", err
-	}
+func (g *gcloud) getRecords(project, zoneName string) ([]*dns.ResourceRecordSet, error) {
 	pageToken := ""
 	sets := []*dns.ResourceRecordSet{}
 	for {
-		call := g.client.ResourceRecordSets.List(g.project, zone.Name)
+		call := g.client.ResourceRecordSets.List(project, zoneName)
 		if pageToken != "" {
 			call = call.PageToken(pageToken)
 		}
 		resp, err := call.Do()
 		if err != nil {
-			return nil, "", err
+			return nil, err
 		}
 		for _, rrs := range resp.Rrsets {
 			if rrs.Type == "SOA" {
@@ -221,26 +233,5 @@ func (g *gcloud) getRecords(domain string) ([]*dns.ResourceRecordSet, string, er
 			break
 		}
 	}
-	return sets, zone.Name, nil
-}
-
-func (g *gcloud) EnsureDomainExists(domain string) error {
-	z, err := g.getZone(domain)
-	if err != nil {
-		if _, ok := err.(errNoExist); !ok {
-			return err
-		}
-	}
-	if z != nil {
-		return nil
-	}
-	fmt.Printf("Adding zone for %s to gcloud account\n", domain)
-	mz := &dns.ManagedZone{
-		DnsName:     domain + ".",
-		Name:        strings.Replace(domain, ".", "-", -1),
-		Description: "zone added by dnscontrol",
-	}
-	g.zones = nil // reset cache
-	_, err = g.client.ManagedZones.Create(g.project, mz).Do()
-	return err
+	return sets, nil
 }
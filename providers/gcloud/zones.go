@@ -0,0 +1,342 @@
+package google
+
+// Managed zones: projects, visibility and DNSSEC.
+//
+// The vendored google.golang.org/api/dns/v1 predates private zones and
+// DNSSEC, so managed zones are read and written with the JSON API directly.
+// Record sets still use the generated client.
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/StackExchange/dnscontrol/models"
+)
+
+const (
+	// Domain metadata. The provider metadata has the same settings without the
+	// gcloud_ prefix, and they apply to the domains that don't set them.
+	metaProject    = "gcloud_project"
+	metaVisibility = "gcloud_visibility" // "public" or "private"
+	metaNetworks   = "gcloud_networks"   // Comma separated, for private zones.
+	metaZone       = "gcloud_zone"       // The name of the managed zone to use.
+	metaDNSSEC     = "gcloud_dnssec"     // "on", "off" or "transfer".
+
+	computeURL = "https://www.googleapis.com/compute/v1/"
+)
+
+type managedZone struct {
+	Name                    string                   `json:"name"`
+	DNSName                 string                   `json:"dnsName"`
+	Description             string                   `json:"description,omitempty"`
+	NameServers             []string                 `json:"nameServers,omitempty"`
+	Visibility              string                   `json:"visibility,omitempty"`
+	PrivateVisibilityConfig *privateVisibilityConfig `json:"privateVisibilityConfig,omitempty"`
+	DNSSECConfig            *dnssecConfig            `json:"dnssecConfig,omitempty"`
+}
+
+// zonePatch is the body of a PATCH of a managed zone: only the settings
+// that change, since the name and DNS name of a zone can't be changed.
+type zonePatch struct {
+	PrivateVisibilityConfig *privateVisibilityConfig `json:"privateVisibilityConfig,omitempty"`
+	DNSSECConfig            *dnssecConfig            `json:"dnssecConfig,omitempty"`
+}
+
+type privateVisibilityConfig struct {
+	Networks []network `json:"networks"`
+}
+
+type network struct {
+	NetworkURL string `json:"networkUrl"`
+}
+
+type dnssecConfig struct {
+	State string `json:"state,omitempty"`
+}
+
+func (z *managedZone) isPrivate() bool {
+	return z.Visibility == "private"
+}
+
+func (z *managedZone) dnssecState() string {
+	if z.DNSSECConfig == nil || z.DNSSECConfig.State == "" {
+		return "off"
+	}
+	return z.DNSSECConfig.State
+}
+
+func (z *managedZone) networks() []string {
+	urls := []string{}
+	if z.PrivateVisibilityConfig != nil {
+		for _, n := range z.PrivateVisibilityConfig.Networks {
+			urls = append(urls, n.NetworkURL)
+		}
+	}
+	sort.Strings(urls)
+	return urls
+}
+
+// zoneSettings are the settings of the zone of a domain.
+type zoneSettings struct {
+	Project    string   `json:"project"`
+	Visibility string   `json:"visibility"`
+	Networks   []string `json:"networks"`
+	Zone       string   `json:"zone"`
+	DNSSEC     string   `json:"dnssec"`
+}
+
+func (s *zoneSettings) check() error {
+	if s.Visibility != "" && s.Visibility != "public" && s.Visibility != "private" {
+		return fmt.Errorf("invalid visibility %q: use public or private", s.Visibility)
+	}
+	if s.DNSSEC != "" && s.DNSSEC != "on" && s.DNSSEC != "off" && s.DNSSEC != "transfer" {
+		return fmt.Errorf("invalid dnssec %q: use on, off or transfer", s.DNSSEC)
+	}
+	if s.DNSSEC != "" && s.DNSSEC != "off" && s.Visibility == "private" {
+		return fmt.Errorf("private zones can't use DNSSEC")
+	}
+	return nil
+}
+
+// settings returns the zone settings of dc: its metadata, or else the
+// provider metadata.
+func (g *gcloud) settings(dc *models.DomainConfig) (*zoneSettings, error) {
+	s := g.defaults
+	if v := dc.Metadata[metaProject]; v != "" {
+		s.Project = v
+	}
+	if v := dc.Metadata[metaVisibility]; v != "" {
+		s.Visibility = v
+	}
+	if v := dc.Metadata[metaNetworks]; v != "" {
+		s.Networks = strings.Split(v, ",")
+	}
+	if v := dc.Metadata[metaZone]; v != "" {
+		s.Zone = v
+	}
+	if v := dc.Metadata[metaDNSSEC]; v != "" {
+		s.DNSSEC = v
+	} else if s.Visibility == "private" {
+		// The provider's DNSSEC setting is for its public zones.
+		s.DNSSEC = ""
+	}
+	if err := s.check(); err != nil {
+		return nil, fmt.Errorf("%s: %s", dc.Name, err)
+	}
+	return &s, nil
+}
+
+// networkURL expands the name of a network of project, or a
+// "projects/.../global/networks/..." path, to the URL that the API expects.
+func networkURL(project, name string) string {
+	name = strings.TrimSpace(name)
+	switch {
+	case strings.HasPrefix(name, "https://"):
+		return name
+	case strings.HasPrefix(name, "projects/"):
+		return computeURL + name
+	}
+	return fmt.Sprintf("%sprojects/%s/global/networks/%s", computeURL, project, name)
+}
+
+func (s *zoneSettings) networkURLs() []string {
+	urls := make([]string, len(s.Networks))
+	for i, n := range s.Networks {
+		urls[i] = networkURL(s.Project, n)
+	}
+	sort.Strings(urls)
+	return urls
+}
+
+// call sends a request to the managed zones API of project.
+func (g *gcloud) call(method, project, path string, in, out interface{}) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, fmt.Sprintf("%s/projects/%s/managedZones%s", g.apiURL, project, path), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := g.hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		apiErr := struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}{}
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Error.Message != "" {
+			return fmt.Errorf("gcloud: %s %s: %s", method, project, apiErr.Error.Message)
+		}
+		return fmt.Errorf("gcloud: %s %s: %s", method, project, resp.Status)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+// getZones returns the managed zones of project named domain.
+func (g *gcloud) getZones(project, domain string) ([]*managedZone, error) {
+	if g.zones[project] == nil {
+		zones := map[string][]*managedZone{}
+		pageToken := ""
+		for {
+			resp := struct {
+				ManagedZones  []*managedZone `json:"managedZones"`
+				NextPageToken string         `json:"nextPageToken"`
+			}{}
+			path := ""
+			if pageToken != "" {
+				path = "?pageToken=" + pageToken
+			}
+			if err := g.call("GET", project, path, nil, &resp); err != nil {
+				return nil, err
+			}
+			for _, z := range resp.ManagedZones {
+				zones[z.DNSName] = append(zones[z.DNSName], z)
+			}
+			if pageToken = resp.NextPageToken; pageToken == "" {
+				break
+			}
+		}
+		g.zones[project] = zones
+	}
+	return g.zones[project][domain+"."], nil
+}
+
+// findZone returns the managed zone of domain with the settings s.
+func (g *gcloud) findZone(domain string, s *zoneSettings) (*managedZone, error) {
+	zones, err := g.getZones(s.Project, domain)
+	if err != nil {
+		return nil, err
+	}
+	found := []*managedZone{}
+	for _, z := range zones {
+		if s.Zone != "" && z.Name != s.Zone {
+			continue
+		}
+		if s.Visibility != "" && z.isPrivate() != (s.Visibility == "private") {
+			continue
+		}
+		found = append(found, z)
+	}
+	switch len(found) {
+	case 0:
+		return nil, errNoExist{domain}
+	case 1:
+		return found[0], nil
+	}
+	names := make([]string, len(found))
+	for i, z := range found {
+		names[i] = z.Name
+	}
+	return nil, fmt.Errorf("%d managed zones of project %s are named %s (%s): set %s or %s",
+		len(found), s.Project, domain, strings.Join(names, ", "), metaVisibility, metaZone)
+}
+
+// zoneCorrections returns the corrections to the DNSSEC state and the
+// networks of a zone.
+func (g *gcloud) zoneCorrections(zone *managedZone, s *zoneSettings) []*models.Correction {
+	corrections := []*models.Correction{}
+	patch := func(msg string, changes, undo *zonePatch) {
+		corrections = append(corrections, &models.Correction{
+			Msg:  msg,
+			F:    func() error { return g.call("PATCH", s.Project, "/"+zone.Name, changes, nil) },
+			Undo: func() error { return g.call("PATCH", s.Project, "/"+zone.Name, undo, nil) },
+		})
+	}
+	if s.DNSSEC != "" && s.DNSSEC != zone.dnssecState() {
+		patch(fmt.Sprintf("DNSSEC of %s: %s -> %s", zone.Name, zone.dnssecState(), s.DNSSEC),
+			&zonePatch{DNSSECConfig: &dnssecConfig{State: s.DNSSEC}},
+			&zonePatch{DNSSECConfig: &dnssecConfig{State: zone.dnssecState()}})
+	}
+	if zone.isPrivate() && len(s.Networks) > 0 {
+		if want, have := s.networkURLs(), zone.networks(); strings.Join(want, ",") != strings.Join(have, ",") {
+			patch(fmt.Sprintf("NETWORKS of %s: %s -> %s", zone.Name, strings.Join(have, ", "), strings.Join(want, ", ")),
+				&zonePatch{PrivateVisibilityConfig: visibilityConfig(want)},
+				&zonePatch{PrivateVisibilityConfig: visibilityConfig(have)})
+		}
+	}
+	return corrections
+}
+
+func visibilityConfig(urls []string) *privateVisibilityConfig {
+	c := &privateVisibilityConfig{Networks: []network{}}
+	for _, u := range urls {
+		c.Networks = append(c.Networks, network{NetworkURL: u})
+	}
+	return c
+}
+
+// zoneName returns the name of a new managed zone for dc. Split horizon
+// domains include their tag, since their zones may be in the same project.
+func zoneName(dc *models.DomainConfig) string {
+	name := strings.Replace(dc.Name, ".", "-", -1)
+	if dc.Tag != "" {
+		name += "-" + dc.Tag
+	}
+	return strings.ToLower(name)
+}
+
+func (g *gcloud) EnsureDomainExists(domain string) error {
+	return g.EnsureDomainConfigExists(&models.DomainConfig{Name: domain})
+}
+
+// EnsureDomainConfigExists creates the zone of dc with its settings.
+func (g *gcloud) EnsureDomainConfigExists(dc *models.DomainConfig) error {
+	s, err := g.settings(dc)
+	if err != nil {
+		return err
+	}
+	if _, err := g.findZone(dc.Name, s); err == nil {
+		return nil
+	} else if _, ok := err.(errNoExist); !ok {
+		return err
+	}
+	mz := &managedZone{
+		DNSName:     dc.Name + ".",
+		Name:        zoneName(dc),
+		Description: "zone added by dnscontrol",
+		Visibility:  s.Visibility,
+	}
+	if s.Zone != "" {
+		mz.Name = s.Zone
+	}
+	if s.Visibility == "private" {
+		if len(s.Networks) == 0 {
+			return fmt.Errorf("creating a private zone for %s needs at least one network", dc.Name)
+		}
+		mz.PrivateVisibilityConfig = visibilityConfig(s.networkURLs())
+	}
+	if s.DNSSEC != "" {
+		mz.DNSSECConfig = &dnssecConfig{State: s.DNSSEC}
+	}
+	fmt.Printf("Adding %s zone %s for %s to gcloud project %s\n", visibilityOrPublic(s.Visibility), mz.Name, dc.Name, s.Project)
+	delete(g.zones, s.Project) // reset cache
+	return g.call("POST", s.Project, "", mz, nil)
+}
+
+func visibilityOrPublic(v string) string {
+	if v == "" {
+		return "public"
+	}
+	return v
+}
@@ -0,0 +1,162 @@
+package google
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/StackExchange/dnscontrol/models"
+)
+
+// testServer serves the managed zones of project p1 and records the zones
+// that are created, and the bodies of the PATCH requests by path.
+func testServer(t *testing.T, created *[]*managedZone, patched map[string][]string) (*gcloud, func()) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/projects/p1/managedZones":
+			w.Write([]byte(`{"managedZones": [
+				{"name": "corp-public", "dnsName": "corp.example.com.", "nameServers": ["ns1.google."]},
+				{"name": "corp-private", "dnsName": "corp.example.com.", "visibility": "private",
+				 "privateVisibilityConfig": {"networks": [{"networkUrl": "https://www.googleapis.com/compute/v1/projects/p1/global/networks/default"}]}},
+				{"name": "example-net", "dnsName": "example.net.", "dnssecConfig": {"state": "on"}}
+			]}`))
+		case r.Method == "GET":
+			w.Write([]byte(`{}`))
+		case r.Method == "POST":
+			z := &managedZone{}
+			if err := json.NewDecoder(r.Body).Decode(z); err != nil {
+				t.Fatal(err)
+			}
+			*created = append(*created, z)
+			w.Write([]byte(`{}`))
+		case r.Method == "PATCH" && patched != nil:
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			patched[r.URL.Path] = append(patched[r.URL.Path], string(body))
+			w.Write([]byte(`{}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	g := &gcloud{
+		hc:       http.DefaultClient,
+		apiURL:   srv.URL,
+		defaults: zoneSettings{Project: "p1"},
+		zones:    map[string]map[string][]*managedZone{},
+	}
+	return g, srv.Close
+}
+
+func TestFindZone(t *testing.T) {
+	g, done := testServer(t, nil, nil)
+	defer done()
+	tests := []struct {
+		name     string
+		meta     map[string]string
+		expected string // The zone name, or "" for an error.
+	}{
+		{"example.net", nil, "example-net"},
+		{"corp.example.com", nil, ""},
+		{"corp.example.com", map[string]string{metaVisibility: "private"}, "corp-private"},
+		{"corp.example.com", map[string]string{metaZone: "corp-public"}, "corp-public"},
+		{"example.net", map[string]string{metaProject: "p2"}, ""},
+		{"example.net", map[string]string{metaVisibility: "internal"}, ""},
+	}
+	for _, tst := range tests {
+		dc := &models.DomainConfig{Name: tst.name, Metadata: tst.meta}
+		found := ""
+		s, err := g.settings(dc)
+		if err == nil {
+			var z *managedZone
+			if z, err = g.findZone(dc.Name, s); err == nil {
+				found = z.Name
+			}
+		}
+		if found != tst.expected {
+			t.Errorf("%s %v: expected %q, got %q (%v)", tst.name, tst.meta, tst.expected, found, err)
+		}
+	}
+	ns, err := g.GetNameservers("corp.example.com")
+	if err != nil || len(ns) != 1 {
+		t.Errorf("expected the nameservers of the public zone, got %v %v", ns, err)
+	}
+}
+
+func TestZoneCorrections(t *testing.T) {
+	patched := map[string][]string{}
+	g, done := testServer(t, nil, patched)
+	defer done()
+	s := &zoneSettings{Project: "p1", Visibility: "private", Networks: []string{"default", "projects/shared/global/networks/vpc"}}
+	zone, err := g.findZone("corp.example.com", s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := g.zoneCorrections(zone, s)
+	if len(c) != 1 || !strings.HasPrefix(c[0].Msg, "NETWORKS of corp-private") {
+		t.Fatalf("expected a networks correction, got %v", c)
+	}
+	if err := c[0].F(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c[0].Undo(); err != nil {
+		t.Fatal(err)
+	}
+	networks := `{"privateVisibilityConfig":{"networks":[{"networkUrl":"https://www.googleapis.com/compute/v1/projects/p1/global/networks/default"},{"networkUrl":"https://www.googleapis.com/compute/v1/projects/shared/global/networks/vpc"}]}}`
+	undo := `{"privateVisibilityConfig":{"networks":[{"networkUrl":"https://www.googleapis.com/compute/v1/projects/p1/global/networks/default"}]}}`
+	if found := patched["/projects/p1/managedZones/corp-private"]; len(found) != 2 || found[0] != networks || found[1] != undo {
+		t.Errorf("unexpected PATCH bodies %v", found)
+	}
+
+	s = &zoneSettings{Project: "p1", DNSSEC: "off"}
+	if zone, err = g.findZone("example.net", s); err != nil {
+		t.Fatal(err)
+	}
+	c = g.zoneCorrections(zone, s)
+	if len(c) != 1 || c[0].Msg != "DNSSEC of example-net: on -> off" {
+		t.Fatalf("expected a DNSSEC correction, got %v", c)
+	}
+	if err := c[0].F(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c[0].Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if found := patched["/projects/p1/managedZones/example-net"]; len(found) != 2 || found[0] != `{"dnssecConfig":{"state":"off"}}` || found[1] != `{"dnssecConfig":{"state":"on"}}` {
+		t.Errorf("unexpected PATCH bodies %v", found)
+	}
+}
+
+func TestEnsureDomainConfigExists(t *testing.T) {
+	var created []*managedZone
+	g, done := testServer(t, &created, nil)
+	defer done()
+	dc := &models.DomainConfig{Name: "example.com!internal", Metadata: map[string]string{
+		metaVisibility: "private",
+		metaNetworks:   "default",
+	}}
+	dc.UpdateSplitHorizonNames()
+	if err := g.EnsureDomainConfigExists(dc); err != nil {
+		t.Fatal(err)
+	}
+	// The private zone of corp.example.com exists already.
+	if err := g.EnsureDomainConfigExists(&models.DomainConfig{Name: "corp.example.com", Metadata: map[string]string{metaVisibility: "private"}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(created) != 1 {
+		t.Fatalf("expected 1 zone to be created, got %d", len(created))
+	}
+	z := created[0]
+	if z.Name != "example-com-internal" || !z.isPrivate() ||
+		strings.Join(z.networks(), ",") != "https://www.googleapis.com/compute/v1/projects/p1/global/networks/default" {
+		t.Errorf("unexpected zone %+v", z)
+	}
+	dc = &models.DomainConfig{Name: "example.org", Metadata: map[string]string{metaVisibility: "private"}}
+	if err := g.EnsureDomainConfigExists(dc); err == nil {
+		t.Error("expected an error for a private zone without networks")
+	}
+}
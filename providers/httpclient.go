@@ -0,0 +1,285 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/http/httputil"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/juju/ratelimit"
+)
+
+// HTTPDebug makes the HTTP clients returned by NewHTTPClient log every
+// request and response. It is set by the --http-debug flag.
+var HTTPDebug bool
+
+// HTTPTransport, when set, sends the requests of the HTTP clients returned by
+// NewHTTPClient instead of http.DefaultTransport. The integration tests set it
+// to record or replay the traffic of providers (see pkg/recorder).
+var HTTPTransport http.RoundTripper
+
+// HTTPOptions control the retries, the rate limit and the timeout of a provider's HTTP client.
+// They are read from the provider's entry in creds.json:
+//
+//	"http_max_retries": how many times a failed request is retried
+//	"http_rate_limit":  requests per second, 0 for no limit
+//	"http_burst":       how many requests may be sent at once before the rate limit applies
+//	"http_timeout":     seconds to wait for each attempt of a request, 0 for no limit
+type HTTPOptions struct {
+	MaxRetries int
+	RateLimit  float64
+	Burst      int
+	Timeout    time.Duration
+}
+
+// DefaultHTTPOptions are used for the settings that are not in creds.json.
+var DefaultHTTPOptions = HTTPOptions{MaxRetries: 5, Timeout: time.Minute}
+
+const (
+	// The delay before the first retry, doubled for every retry after that.
+	retryBaseDelay = time.Second
+	retryMaxDelay  = time.Minute
+	// A server asking to wait longer than this is not retried.
+	maxRetryAfter = 5 * time.Minute
+)
+
+// HTTPOptionsFromCreds reads the HTTP settings from creds, using defaults for
+// the missing ones.
+func HTTPOptionsFromCreds(creds map[string]string, defaults HTTPOptions) (HTTPOptions, error) {
+	opts := defaults
+	if s := creds["http_max_retries"]; s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return opts, fmt.Errorf("invalid http_max_retries %q", s)
+		}
+		opts.MaxRetries = n
+	}
+	if s := creds["http_rate_limit"]; s != "" {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil || f < 0 {
+			return opts, fmt.Errorf("invalid http_rate_limit %q", s)
+		}
+		opts.RateLimit = f
+	}
+	if s := creds["http_burst"]; s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			return opts, fmt.Errorf("invalid http_burst %q", s)
+		}
+		opts.Burst = n
+	}
+	if s := creds["http_timeout"]; s != "" {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil || f < 0 {
+			return opts, fmt.Errorf("invalid http_timeout %q", s)
+		}
+		opts.Timeout = time.Duration(f * float64(time.Second))
+	}
+	return opts, nil
+}
+
+// NewHTTPClient returns an HTTP client for the API of a provider, configured
+// from its creds.json entry. name is used in the debug logs.
+func NewHTTPClient(name string, creds map[string]string) (*http.Client, error) {
+	opts, err := HTTPOptionsFromCreds(creds, DefaultHTTPOptions)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", name, err)
+	}
+	return opts.NewClient(name), nil
+}
+
+// NewClient returns an HTTP client that retries and rate limits its requests.
+func (o HTTPOptions) NewClient(name string) *http.Client {
+	return &http.Client{Transport: o.NewTransport(name, nil)}
+}
+
+// NewTransport wraps base, or HTTPTransport if base is nil, with
+// retries, rate limiting, timeouts and debug logging. The timeout applies to
+// each attempt, up to the end of the response body, so that a stalled
+// connection is retried rather than waited on forever.
+func (o HTTPOptions) NewTransport(name string, base http.RoundTripper) http.RoundTripper {
+	t := &transport{name: name, base: base, opts: o, sleep: time.Sleep}
+	if o.RateLimit > 0 {
+		burst := o.Burst
+		if burst < 1 {
+			burst = 1
+		}
+		t.bucket = ratelimit.NewBucketWithRate(o.RateLimit, int64(burst))
+	}
+	return t
+}
+
+type transport struct {
+	name   string
+	base   http.RoundTripper
+	opts   HTTPOptions
+	bucket *ratelimit.Bucket
+	sleep  func(time.Duration)
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// HTTPTransport is looked up for every request so that it can be set
+	// after the client was created.
+	base := t.base
+	if base == nil {
+		base = HTTPTransport
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	// Keep the body so that the request can be sent again.
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	for attempt := 0; ; attempt++ {
+		r := new(http.Request)
+		*r = *req
+		if req.Body != nil {
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+		if t.bucket != nil {
+			t.sleep(t.bucket.Take(1))
+		}
+		var cancel context.CancelFunc
+		if t.opts.Timeout > 0 {
+			var ctx context.Context
+			ctx, cancel = context.WithTimeout(req.Context(), t.opts.Timeout)
+			r = r.WithContext(ctx)
+		}
+		t.dumpRequest(r)
+		resp, err := base.RoundTrip(r)
+		t.dumpResponse(resp, err)
+		if cancel != nil {
+			if err != nil {
+				cancel()
+			} else {
+				resp.Body = &cancelBody{resp.Body, cancel}
+			}
+		}
+		if attempt >= t.opts.MaxRetries || !shouldRetry(req.Method, resp, err) {
+			return resp, err
+		}
+		wait := backoff(attempt)
+		if resp != nil {
+			if d, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+				wait = d
+			}
+			if wait > maxRetryAfter {
+				return resp, err
+			}
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		if err != nil {
+			log.Printf("%s: %s %s: %s. Retrying in %s.", t.name, req.Method, req.URL.Path, err, wait)
+		} else {
+			log.Printf("%s: %s %s: %s. Retrying in %s.", t.name, req.Method, req.URL.Path, resp.Status, wait)
+		}
+		t.sleep(wait)
+	}
+}
+
+// cancelBody ends the timeout of a request when its response body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// shouldRetry reports whether a request can be sent again after it failed.
+// A request that may have changed something is only retried when the server
+// says it did not handle it.
+func shouldRetry(method string, resp *http.Response, err error) bool {
+	idempotent := method != http.MethodPost && method != http.MethodPatch
+	if err != nil {
+		return idempotent
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusGatewayTimeout:
+		return idempotent
+	}
+	return false
+}
+
+// backoff returns the delay before a retry: exponential, with some jitter so
+// that parallel clients do not all retry at the same time.
+func backoff(attempt int) time.Duration {
+	d := retryMaxDelay
+	if attempt < 16 {
+		d = retryBaseDelay << uint(attempt)
+	}
+	if d > retryMaxDelay {
+		d = retryMaxDelay
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// retryAfter parses a Retry-After header, which is a number of seconds or a date.
+func retryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if s, err := strconv.Atoi(v); err == nil && s >= 0 {
+		return time.Duration(s) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+// Headers that carry credentials are not logged.
+var secretHeader = regexp.MustCompile(`(?im)^((?:[\w-]*(?:auth|key|token|secret|password)[\w-]*|cookie|set-cookie):)[^\r\n]*`)
+
+func (t *transport) dumpRequest(req *http.Request) {
+	if !HTTPDebug {
+		return
+	}
+	dump, err := httputil.DumpRequestOut(req, true)
+	if err != nil {
+		log.Printf("%s: could not dump request: %s", t.name, err)
+		return
+	}
+	log.Printf("%s: HTTP request:\n%s", t.name, secretHeader.ReplaceAll(dump, []byte("$1 REDACTED")))
+}
+
+func (t *transport) dumpResponse(resp *http.Response, err error) {
+	if !HTTPDebug {
+		return
+	}
+	if err != nil {
+		log.Printf("%s: HTTP error: %s", t.name, err)
+		return
+	}
+	dump, err := httputil.DumpResponse(resp, true)
+	if err != nil {
+		log.Printf("%s: could not dump response: %s", t.name, err)
+		return
+	}
+	log.Printf("%s: HTTP response:\n%s", t.name, secretHeader.ReplaceAll(dump, []byte("$1 REDACTED")))
+}
@@ -0,0 +1,212 @@
+package providers
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testClient returns a client that records the delays instead of sleeping.
+func testClient(opts HTTPOptions) (*http.Client, *[]time.Duration) {
+	var delays []time.Duration
+	t := opts.NewTransport("TEST", nil).(*transport)
+	t.sleep = func(d time.Duration) {
+		if d > 0 {
+			delays = append(delays, d)
+		}
+	}
+	return &http.Client{Transport: t}, &delays
+}
+
+func TestRetry(t *testing.T) {
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		switch len(bodies) {
+		case 1:
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.Write([]byte("ok"))
+		}
+	}))
+	defer srv.Close()
+
+	client, delays := testClient(HTTPOptions{MaxRetries: 5})
+	resp, err := client.Post(srv.URL, "text/plain", strings.NewReader("payload"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %s", resp.Status)
+	}
+	if strings.Join(bodies, ",") != "payload,payload,payload" {
+		t.Errorf("the body was not sent again: %q", bodies)
+	}
+	if len(*delays) != 2 || (*delays)[0] != 7*time.Second {
+		t.Fatalf("expected to wait for Retry-After then back off, got %v", *delays)
+	}
+	if d := (*delays)[1]; d < retryBaseDelay/2 || d > 2*retryBaseDelay {
+		t.Errorf("unexpected backoff %s", d)
+	}
+}
+
+func TestNoRetry(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	// A POST may have been handled before the server failed.
+	client, _ := testClient(HTTPOptions{MaxRetries: 3})
+	resp, err := client.Post(srv.URL, "text/plain", strings.NewReader("payload"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if calls != 1 {
+		t.Errorf("expected 1 call, got %d", calls)
+	}
+
+	// A GET is retried until MaxRetries, then the last response is returned.
+	calls = 0
+	resp, err = client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if calls != 4 || resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("expected 4 calls and a 500, got %d and %s", calls, resp.Status)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	opts, err := HTTPOptionsFromCreds(map[string]string{"http_rate_limit": "2", "http_burst": "3"}, DefaultHTTPOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, delays := testClient(opts)
+	for i := 0; i < 5; i++ {
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	// The clock does not move: the first 3 requests use the burst, then they
+	// wait for the bucket to refill at 2 per second.
+	if len(*delays) != 2 || (*delays)[0] < 400*time.Millisecond || (*delays)[1] < 900*time.Millisecond {
+		t.Errorf("unexpected delays %v", *delays)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	stalled := make(chan struct{})
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			// The first attempt never gets an answer.
+			select {
+			case <-stalled:
+			case <-r.Context().Done():
+			}
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+	defer close(stalled)
+
+	client, _ := testClient(HTTPOptions{MaxRetries: 1, Timeout: 50 * time.Millisecond})
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil || string(body) != "ok" || requests != 2 {
+		t.Errorf("expected the stalled request to be retried, got %q, %v after %d requests", body, err, requests)
+	}
+
+	client, _ = testClient(HTTPOptions{Timeout: 50 * time.Millisecond})
+	requests = 0
+	if _, err := client.Get(srv.URL); err == nil {
+		t.Error("expected a timeout")
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestHTTPTransport(t *testing.T) {
+	client := HTTPOptions{}.NewClient("TEST")
+	// Set after the client was created, like the integration tests do.
+	HTTPTransport = roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusTeapot, Body: ioutil.NopCloser(strings.NewReader("")), Request: req}, nil
+	})
+	defer func() { HTTPTransport = nil }()
+	resp, err := client.Get("http://api.example.invalid/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTeapot {
+		t.Errorf("the request did not go through HTTPTransport: %s", resp.Status)
+	}
+}
+
+func TestHTTPOptionsFromCreds(t *testing.T) {
+	opts, err := HTTPOptionsFromCreds(map[string]string{"http_max_retries": "2"}, DefaultHTTPOptions)
+	if err != nil || opts.MaxRetries != 2 || opts.RateLimit != 0 || opts.Timeout != time.Minute {
+		t.Errorf("unexpected options %+v, %v", opts, err)
+	}
+	opts, err = HTTPOptionsFromCreds(map[string]string{"http_timeout": "2.5"}, DefaultHTTPOptions)
+	if err != nil || opts.Timeout != 2500*time.Millisecond {
+		t.Errorf("unexpected options %+v, %v", opts, err)
+	}
+	for _, creds := range []map[string]string{
+		{"http_max_retries": "-1"},
+		{"http_rate_limit": "fast"},
+		{"http_burst": "0"},
+		{"http_timeout": "-1"},
+	} {
+		if _, err := HTTPOptionsFromCreds(creds, DefaultHTTPOptions); err == nil {
+			t.Errorf("%v: expected an error", creds)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	if d, ok := retryAfter("120"); !ok || d != 2*time.Minute {
+		t.Errorf("unexpected %s %v", d, ok)
+	}
+	date := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	if d, ok := retryAfter(date); !ok || d < 59*time.Minute || d > time.Hour {
+		t.Errorf("unexpected %s %v", d, ok)
+	}
+	if _, ok := retryAfter("soon"); ok {
+		t.Error("expected an invalid header to be ignored")
+	}
+}
+
+func TestSecretHeaders(t *testing.T) {
+	dump := "GET / HTTP/1.1\r\nHost: api.example.com\r\nAuthorization: Bearer s3cr3t\r\nX-Auth-Key: s3cr3t\r\nAccept: */*\r\n\r\n"
+	redacted := string(secretHeader.ReplaceAll([]byte(dump), []byte("$1 REDACTED")))
+	if strings.Contains(redacted, "s3cr3t") || !strings.Contains(redacted, "Host: api.example.com\r\n") {
+		t.Errorf("unexpected dump:\n%s", redacted)
+	}
+}
@@ -66,7 +66,11 @@ func NewLinode(m map[string]string, metadata json.RawMessage) (providers.DNSServ
 		return nil, fmt.Errorf("Missing Linode token")
 	}
 
-	ctx := context.Background()
+	hc, err := providers.NewHTTPClient("LINODE", m)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, hc)
 	client := oauth2.NewClient(
 		ctx,
 		oauth2.StaticTokenSource(&oauth2.Token{AccessToken: m["token"]}),
@@ -0,0 +1,309 @@
+package memory
+
+/*
+
+memory -
+  A DNS provider that keeps its zones in memory.
+
+	It is meant for tests and dry runs: zones can be seeded from a
+	JSON or BIND zone file, corrections are applied to the in-memory
+	copy and every correction that ran is recorded.
+
+	Providers created with the same "instance" name share their zones,
+	so a test can inspect the state a command left behind with Instance().
+	Reset() starts over.
+
+*/
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/miekg/dns"
+	"github.com/miekg/dns/dnsutil"
+
+	"github.com/StackExchange/dnscontrol/models"
+	"github.com/StackExchange/dnscontrol/providers"
+	"github.com/StackExchange/dnscontrol/providers/diff"
+)
+
+var features = providers.DocumentationNotes{
+	providers.CanUseAlias:            providers.Can(),
+	providers.CanUseCAA:              providers.Can(),
+	providers.CanUsePTR:              providers.Can(),
+	providers.CanUseSOA:              providers.Can(),
+	providers.CanUseSRV:              providers.Can(),
+	providers.CanUseTLSA:             providers.Can(),
+	providers.CanUseTXTMulti:         providers.Can(),
+	providers.DocCreateDomains:       providers.Can(),
+	providers.DocDualHost:            providers.Can(),
+	providers.DocOfficiallySupported: providers.Cannot("For tests only"),
+}
+
+func init() {
+	providers.RegisterDomainServiceProviderType("MEMORY", newProvider, features)
+}
+
+var (
+	instancesMu sync.Mutex
+	instances   = map[string]*Provider{}
+)
+
+// Provider is the provider handle for the MEMORY driver.
+type Provider struct {
+	DefaultNS []string `json:"default_ns"`
+	// FailOn makes the corrections whose message contains it fail, to test
+	// how errors are handled.
+	FailOn string `json:"fail_on"`
+
+	mu      sync.Mutex
+	zones   map[string]models.Records
+	applied []string
+	seeded  bool
+}
+
+func newProvider(creds map[string]string, meta json.RawMessage) (providers.DNSServiceProvider, error) {
+	name := creds["instance"]
+	if name == "" {
+		name = "default"
+	}
+	p := Instance(name)
+	if len(meta) != 0 {
+		if err := json.Unmarshal(meta, p); err != nil {
+			return nil, err
+		}
+	}
+	// An instance is only seeded once, so that a second command in the same
+	// process sees the changes made by the first.
+	if seed := creds["seed"]; seed != "" && !p.seeded {
+		if err := p.Seed(seed); err != nil {
+			return nil, err
+		}
+		p.seeded = true
+	}
+	return p, nil
+}
+
+// Instance returns the provider with the given instance name, creating it if needed.
+func Instance(name string) *Provider {
+	instancesMu.Lock()
+	defer instancesMu.Unlock()
+	p, ok := instances[name]
+	if !ok {
+		p = &Provider{zones: map[string]models.Records{}}
+		instances[name] = p
+	}
+	return p
+}
+
+// Reset forgets all instances.
+func Reset() {
+	instancesMu.Lock()
+	defer instancesMu.Unlock()
+	instances = map[string]*Provider{}
+}
+
+// Seed replaces zones with the contents of a file or directory.
+//
+// A .json file holds a map of domain names to lists of records, in the same
+// format as the records of `dnscontrol print-ir`. A .zone file is a BIND zone
+// file named after its domain. A directory is scanned for .zone files.
+func (p *Provider) Seed(path string) error {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if fi.IsDir() {
+		files, err := filepath.Glob(filepath.Join(path, "*.zone"))
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			if err := p.seedZoneFile(f); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return p.seedJSON(path)
+	case ".zone":
+		return p.seedZoneFile(path)
+	}
+	return fmt.Errorf("MEMORY: can not seed from %s: expected a .json or .zone file", path)
+}
+
+func (p *Provider) seedJSON(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	zones := map[string]models.Records{}
+	if err := json.Unmarshal(data, &zones); err != nil {
+		return fmt.Errorf("MEMORY: parsing %s: %s", path, err)
+	}
+	for domain, recs := range zones {
+		for _, rc := range recs {
+			rc.NameFQDN = dnsutil.AddOrigin(rc.Name, domain)
+			if rc.TTL == 0 {
+				rc.TTL = models.DefaultTTL
+			}
+		}
+		p.SetZone(domain, recs)
+	}
+	return nil
+}
+
+func (p *Provider) seedZoneFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	domain := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	recs := models.Records{}
+	for x := range dns.ParseZone(f, domain, path) {
+		if x.Error != nil {
+			return x.Error
+		}
+		if x.RR.Header().Rrtype == dns.TypeSOA {
+			continue
+		}
+		rc, err := models.RRtoRC(x.RR, domain)
+		if err != nil {
+			return fmt.Errorf("MEMORY: %s: %s", path, err)
+		}
+		recs = append(recs, rc)
+	}
+	p.SetZone(domain, recs)
+	return nil
+}
+
+// SetZone replaces the records of a zone, creating it if needed.
+func (p *Provider) SetZone(domain string, recs models.Records) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	models.PostProcessRecords(recs)
+	p.zones[strings.ToLower(domain)] = recs
+}
+
+// Zone returns a copy of the records of a zone, or nil if it does not exist.
+func (p *Provider) Zone(domain string) models.Records {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	recs, ok := p.zones[strings.ToLower(domain)]
+	if !ok {
+		return nil
+	}
+	out := make(models.Records, 0, len(recs))
+	for _, rc := range recs {
+		c := *rc
+		out = append(out, &c)
+	}
+	return out
+}
+
+// Zones returns the sorted names of all zones.
+func (p *Provider) Zones() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	names := []string{}
+	for name := range p.zones {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Applied returns the messages of every correction that ran, in order.
+func (p *Provider) Applied() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string{}, p.applied...)
+}
+
+// GetNameservers returns the nameservers for a domain.
+func (p *Provider) GetNameservers(string) ([]*models.Nameserver, error) {
+	return models.StringsToNameservers(p.DefaultNS), nil
+}
+
+// EnsureDomainExists creates an empty zone if it does not exist.
+func (p *Provider) EnsureDomainExists(domain string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.zones[strings.ToLower(domain)]; !ok {
+		p.zones[strings.ToLower(domain)] = models.Records{}
+	}
+	return nil
+}
+
+// GetDomainCorrections returns a list of corrections to update a domain.
+func (p *Provider) GetDomainCorrections(dc *models.DomainConfig) ([]*models.Correction, error) {
+	dc.Punycode()
+	existing := p.Zone(dc.Name)
+	if existing == nil {
+		return nil, fmt.Errorf("%s does not exist in the MEMORY provider. Seed it or run create-domains", dc.Name)
+	}
+
+	_, create, del, mod := diff.New(dc).IncrementalDiff(existing)
+	corrections := []*models.Correction{}
+	for _, d := range del {
+		corrections = append(corrections, p.correction(dc.Name, d.String(), d.Existing, nil))
+	}
+	for _, c := range create {
+		corrections = append(corrections, p.correction(dc.Name, c.String(), nil, c.Desired))
+	}
+	for _, m := range mod {
+		corrections = append(corrections, p.correction(dc.Name, m.String(), m.Existing, m.Desired))
+	}
+	return corrections, nil
+}
+
+// correction replaces old by desired in the zone. Either may be nil.
+func (p *Provider) correction(domain, msg string, old, desired *models.RecordConfig) *models.Correction {
+	return &models.Correction{
+		Msg:  msg,
+		F:    p.replace(domain, msg, old, desired),
+		Undo: p.replace(domain, "UNDO "+msg, desired, old),
+	}
+}
+
+func (p *Provider) replace(domain, msg string, old, desired *models.RecordConfig) func() error {
+	return func() error {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.FailOn != "" && strings.Contains(msg, p.FailOn) {
+			return fmt.Errorf("MEMORY: %s fails as configured", msg)
+		}
+		key := strings.ToLower(domain)
+		recs := p.zones[key]
+		if old != nil {
+			found := false
+			for i, rc := range recs {
+				if rc.Key() == old.Key() && rc.Content() == old.Content() {
+					recs = append(recs[:i], recs[i+1:]...)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return fmt.Errorf("MEMORY: %s %s %s not found in %s", old.Type, old.Name, old.Content(), domain)
+			}
+		}
+		if desired != nil {
+			c := *desired
+			c.Original = nil
+			recs = append(recs, &c)
+		}
+		p.zones[key] = recs
+		p.applied = append(p.applied, msg)
+		return nil
+	}
+}
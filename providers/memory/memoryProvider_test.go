@@ -0,0 +1,127 @@
+package memory
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/StackExchange/dnscontrol/models"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func desired() *models.DomainConfig {
+	return &models.DomainConfig{
+		Name: "example.com",
+		Records: []*models.RecordConfig{
+			{Type: "A", Name: "www", NameFQDN: "www.example.com", Target: "1.2.3.4", TTL: 300},
+			{Type: "MX", Name: "@", NameFQDN: "example.com", Target: "mx.example.com.", MxPreference: 10, TTL: 300},
+			{Type: "ALIAS", Name: "@", NameFQDN: "example.com", Target: "lb.example.net.", TTL: 300},
+		},
+	}
+}
+
+func TestSeedAndCorrections(t *testing.T) {
+	defer Reset()
+	dir, err := ioutil.TempDir("", "memory")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	seed := writeFile(t, dir, "seed.json", `{"example.com": [
+		{"type": "A", "name": "www", "target": "1.2.3.5"},
+		{"type": "CNAME", "name": "old", "target": "www.example.com."}
+	]}`)
+
+	prv, err := newProvider(map[string]string{"seed": seed}, json.RawMessage(`{"default_ns": ["ns1.example.com."]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := prv.(*Provider)
+	if nss, _ := p.GetNameservers("example.com"); len(nss) != 1 {
+		t.Errorf("expected the default nameserver, got %v", nss)
+	}
+
+	corrections, err := p.GetDomainCorrections(desired())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Delete old, create MX and ALIAS, modify www.
+	if len(corrections) != 4 {
+		t.Fatalf("expected 4 corrections, got %d", len(corrections))
+	}
+	for _, c := range corrections {
+		if err := c.F(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(p.Applied()) != 4 {
+		t.Errorf("expected 4 applied corrections, got %v", p.Applied())
+	}
+	if recs := p.Zone("example.com"); len(recs) != 3 {
+		t.Errorf("expected 3 records, got %v", recs)
+	}
+
+	corrections, err = Instance("default").GetDomainCorrections(desired())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(corrections) != 0 {
+		t.Fatalf("expected no corrections on second run, got %v", corrections[0].Msg)
+	}
+}
+
+func TestSeedZoneFile(t *testing.T) {
+	defer Reset()
+	dir, err := ioutil.TempDir("", "memory")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeFile(t, dir, "example.com.zone", `$TTL 300
+@    IN SOA ns1.example.com. hostmaster.example.com. 1 3600 600 604800 300
+www  IN A   1.2.3.4
+@    IN MX  10 mx.example.com.
+@    IN TXT "v=spf1" "-all"
+`)
+	p := Instance("zones")
+	if err := p.Seed(dir); err != nil {
+		t.Fatal(err)
+	}
+	recs := p.Zone("example.com")
+	if len(recs) != 3 {
+		t.Fatalf("expected 3 records without the SOA, got %v", recs)
+	}
+	if recs[2].Type != "TXT" || len(recs[2].TxtStrings) != 2 {
+		t.Errorf("unexpected TXT record %v", recs[2])
+	}
+	if err := p.Seed(writeFile(t, dir, "bad.txt", "")); err == nil {
+		t.Error("expected an error for an unknown file type")
+	}
+}
+
+func TestMissingZone(t *testing.T) {
+	defer Reset()
+	p := Instance("missing")
+	if _, err := p.GetDomainCorrections(desired()); err == nil {
+		t.Fatal("expected an error")
+	}
+	if err := p.EnsureDomainExists("example.com"); err != nil {
+		t.Fatal(err)
+	}
+	corrections, err := p.GetDomainCorrections(desired())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(corrections) != 3 {
+		t.Fatalf("expected 3 corrections, got %d", len(corrections))
+	}
+}
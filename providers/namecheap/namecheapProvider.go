@@ -61,7 +61,12 @@ func newProvider(m map[string]string, metadata json.RawMessage) (*Namecheap, err
 	if api.ApiKey == "" || api.ApiUser == "" {
 		return nil, fmt.Errorf("missing Namecheap apikey and apiuser")
 	}
+	hc, err := providers.NewHTTPClient("NAMECHEAP", m)
+	if err != nil {
+		return nil, err
+	}
 	api.client = nc.NewClient(api.ApiUser, api.ApiKey, api.ApiUser)
+	api.client.HttpClient = hc
 	// if BaseURL is specified in creds, use that url
 	BaseURL, ok := m["BaseURL"]
 	if ok {
@@ -45,6 +45,11 @@ func newProvider(conf map[string]string) (*NameCom, error) {
 	if api.APIKey == "" || api.APIUser == "" {
 		return nil, fmt.Errorf("missing Name.com apikey or apiuser")
 	}
+	hc, err := providers.NewHTTPClient("NAMEDOTCOM", conf)
+	if err != nil {
+		return nil, err
+	}
+	api.client.Client = hc
 	if api.APIUrl == "" {
 		api.APIUrl = defaultAPIBase
 	}
@@ -0,0 +1,192 @@
+package ns1
+
+// Answer metadata and filters, for basic traffic steering.
+//
+// The metadata of a record is set with ns1_<field> keys, where field is one
+// of answerFields. The filters of a record set are set with ns1_filters on
+// each of its records.
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/StackExchange/dnscontrol/models"
+	"gopkg.in/ns1/ns1-go.v2/rest/model/data"
+	"gopkg.in/ns1/ns1-go.v2/rest/model/filter"
+)
+
+const (
+	metaPrefix  = "ns1_"
+	metaFilters = "ns1_filters" // Comma separated filter types, in order.
+)
+
+type fieldKind int
+
+const (
+	kindBool fieldKind = iota
+	kindNumber
+	kindString
+	kindList // Comma separated in the record metadata.
+)
+
+// answerFields are the fields of the answer metadata that can be set.
+var answerFields = []struct {
+	name  string
+	kind  fieldKind
+	field func(*data.Meta) *interface{}
+}{
+	{"up", kindBool, func(m *data.Meta) *interface{} { return &m.Up }},
+	{"weight", kindNumber, func(m *data.Meta) *interface{} { return &m.Weight }},
+	{"priority", kindNumber, func(m *data.Meta) *interface{} { return &m.Priority }},
+	{"country", kindList, func(m *data.Meta) *interface{} { return &m.Country }},
+	{"georegion", kindList, func(m *data.Meta) *interface{} { return &m.Georegion }},
+	{"us_state", kindList, func(m *data.Meta) *interface{} { return &m.USState }},
+	{"ca_province", kindList, func(m *data.Meta) *interface{} { return &m.CAProvince }},
+	{"latitude", kindNumber, func(m *data.Meta) *interface{} { return &m.Latitude }},
+	{"longitude", kindNumber, func(m *data.Meta) *interface{} { return &m.Longitude }},
+	{"asn", kindList, func(m *data.Meta) *interface{} { return &m.ASN }},
+	{"ip_prefixes", kindList, func(m *data.Meta) *interface{} { return &m.IPPrefixes }},
+	{"note", kindString, func(m *data.Meta) *interface{} { return &m.Note }},
+}
+
+// answerMeta returns the answer metadata of a record, or nil if it has none.
+func answerMeta(r *models.RecordConfig) (*data.Meta, error) {
+	var meta *data.Meta
+	for k := range r.Metadata {
+		if strings.HasPrefix(k, metaPrefix) && k != metaFilters && !knownField(strings.TrimPrefix(k, metaPrefix)) {
+			return nil, fmt.Errorf("%s %s: unknown NS1 metadata %s", r.Type, r.NameFQDN, k)
+		}
+	}
+	for _, f := range answerFields {
+		s, ok := r.Metadata[metaPrefix+f.name]
+		if !ok {
+			continue
+		}
+		var v interface{}
+		var err error
+		switch f.kind {
+		case kindBool:
+			v, err = strconv.ParseBool(s)
+		case kindNumber:
+			v, err = strconv.ParseFloat(s, 64)
+		case kindString:
+			v = s
+		case kindList:
+			list := []string{}
+			for _, item := range strings.Split(s, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					list = append(list, item)
+				}
+			}
+			v = list
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: invalid %s%s %q", r.Type, r.NameFQDN, metaPrefix, f.name, s)
+		}
+		if meta == nil {
+			meta = &data.Meta{}
+		}
+		*f.field(meta) = v
+	}
+	return meta, nil
+}
+
+func knownField(name string) bool {
+	for _, f := range answerFields {
+		if f.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// metaToRecord sets the ns1_ metadata of r from the metadata of an answer.
+// Data feeds are kept as JSON: they can't be set by dnscontrol.
+func metaToRecord(meta *data.Meta, r *models.RecordConfig) {
+	if meta == nil {
+		return
+	}
+	for _, f := range answerFields {
+		v := *f.field(meta)
+		if v == nil {
+			continue
+		}
+		var s string
+		switch t := v.(type) {
+		case bool:
+			s = strconv.FormatBool(t)
+		case float64:
+			s = strconv.FormatFloat(t, 'f', -1, 64)
+		case string:
+			s = t
+		case []string:
+			s = strings.Join(t, ",")
+		case []interface{}:
+			items := make([]string, len(t))
+			for i, item := range t {
+				items[i] = fmt.Sprint(item)
+			}
+			s = strings.Join(items, ",")
+		default:
+			b, _ := json.Marshal(t)
+			s = string(b)
+		}
+		if r.Metadata == nil {
+			r.Metadata = map[string]string{}
+		}
+		r.Metadata[metaPrefix+f.name] = s
+	}
+}
+
+// recordFilters returns the filters of the record set recs.
+func recordFilters(recs models.Records) ([]*filter.Filter, error) {
+	spec := recs[0].Metadata[metaFilters]
+	for _, r := range recs[1:] {
+		if r.Metadata[metaFilters] != spec {
+			return nil, fmt.Errorf("%s %s: all the records must have the same %s", r.Type, r.NameFQDN, metaFilters)
+		}
+	}
+	filters := []*filter.Filter{}
+	for _, t := range strings.Split(spec, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			filters = append(filters, &filter.Filter{Type: t, Config: filter.Config{}})
+		}
+	}
+	return filters, nil
+}
+
+func formatFilters(filters []*filter.Filter) string {
+	types := []string{}
+	for _, f := range filters {
+		if !f.Disabled {
+			types = append(types, f.Type)
+		}
+	}
+	return strings.Join(types, ",")
+}
+
+// getNS1Metadata returns the NS1 metadata of a record for the differ, in a
+// single value so that its order is stable.
+func getNS1Metadata(r *models.RecordConfig) map[string]string {
+	values := []string{}
+	meta, _ := answerMeta(r)
+	if meta != nil {
+		rec := &models.RecordConfig{}
+		metaToRecord(meta, rec)
+		for k, v := range rec.Metadata {
+			values = append(values, k+"="+v)
+		}
+	}
+	if f := r.Metadata[metaFilters]; f != "" {
+		filters, _ := recordFilters(models.Records{r})
+		values = append(values, metaFilters+"="+formatFilters(filters))
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	sort.Strings(values)
+	return map[string]string{"ns1": strings.Join(values, " ")}
+}
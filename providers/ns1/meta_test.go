@@ -0,0 +1,92 @@
+package ns1
+
+import (
+	"testing"
+
+	"github.com/StackExchange/dnscontrol/models"
+	"gopkg.in/ns1/ns1-go.v2/rest/model/dns"
+	"gopkg.in/ns1/ns1-go.v2/rest/model/filter"
+)
+
+func TestAnswerMeta(t *testing.T) {
+	r := &models.RecordConfig{Type: "A", NameFQDN: "www.example.com", Target: "1.2.3.4", Metadata: map[string]string{
+		"ns1_up":      "true",
+		"ns1_weight":  "10.5",
+		"ns1_country": "US, CA",
+		"ns1_note":    "east",
+		"ns1_filters": "up,weighted_shuffle",
+	}}
+	meta, err := answerMeta(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Up != true || meta.Weight != 10.5 || meta.Note != "east" {
+		t.Errorf("unexpected meta %+v", meta)
+	}
+	if c, ok := meta.Country.([]string); !ok || len(c) != 2 || c[1] != "CA" {
+		t.Errorf("unexpected country %#v", meta.Country)
+	}
+
+	// What the API returns.
+	meta.Country = []interface{}{"US", "CA"}
+	found := &models.RecordConfig{}
+	metaToRecord(meta, found)
+	found.Metadata[metaFilters] = formatFilters([]*filter.Filter{filter.NewUp(), filter.NewWeightedShuffle()})
+	if a, b := getNS1Metadata(r)["ns1"], getNS1Metadata(found)["ns1"]; a != b {
+		t.Errorf("the metadata differ:\n%s\n%s", a, b)
+	}
+	if getNS1Metadata(&models.RecordConfig{}) != nil {
+		t.Error("expected no metadata")
+	}
+
+	for _, m := range []map[string]string{{"ns1_up": "maybe"}, {"ns1_weight": "heavy"}, {"ns1_colour": "red"}} {
+		if _, err := answerMeta(&models.RecordConfig{Metadata: m}); err == nil {
+			t.Errorf("%v: expected an error", m)
+		}
+	}
+}
+
+func TestBuildRecord(t *testing.T) {
+	recs := models.Records{
+		{Type: "CAA", NameFQDN: "example.com", Target: "letsencrypt.org", CaaTag: "issue", TTL: 300},
+		{Type: "CAA", NameFQDN: "example.com", Target: "mailto:admin@example.com", CaaTag: "iodef", CaaFlag: 128, TTL: 300},
+	}
+	rec, err := buildRecord(recs, "example.com", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.Answers) != 2 || rec.Answers[1].String() != "128 iodef mailto:admin@example.com" || rec.Filters != nil {
+		t.Errorf("unexpected record %v", rec)
+	}
+
+	txt := &models.RecordConfig{Type: "TXT", NameFQDN: "example.com"}
+	txt.SetTxts([]string{"a b", "c"})
+	txt2 := &models.RecordConfig{Type: "TXT", NameFQDN: "example.com", Metadata: map[string]string{metaFilters: "up"}}
+	if _, err := buildRecord(models.Records{txt, txt2}, "example.com", ""); err == nil {
+		t.Error("expected an error for different filters")
+	}
+	if rec, err = buildRecord(models.Records{txt}, "example.com", ""); err != nil {
+		t.Fatal(err)
+	}
+	found, err := convertRecord(rec, "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found[0].Content() != txt.Content() {
+		t.Errorf("expected %s, got %s", txt.Content(), found[0].Content())
+	}
+}
+
+func TestConvertCAA(t *testing.T) {
+	found, err := convert(&dns.ZoneRecord{Domain: "example.com", Type: "CAA", TTL: 300, ShortAns: []string{"0 issue letsencrypt.org"}}, "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := found[0]
+	if r.CaaFlag != 0 || r.CaaTag != "issue" || r.Target != "letsencrypt.org" || r.Name != "@" {
+		t.Errorf("unexpected record %+v", r)
+	}
+	if _, err := convert(&dns.ZoneRecord{Domain: "example.com", Type: "CAA", ShortAns: []string{"issue"}}, "example.com"); err == nil {
+		t.Error("expected an error")
+	}
+}
@@ -2,30 +2,32 @@ package ns1
 
 import (
 	"encoding/json"
-
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/StackExchange/dnscontrol/models"
 	"github.com/StackExchange/dnscontrol/providers"
-
-	"net/http"
-
-	"strings"
-
 	"github.com/StackExchange/dnscontrol/providers/diff"
 	"github.com/miekg/dns/dnsutil"
 	"gopkg.in/ns1/ns1-go.v2/rest"
 	"gopkg.in/ns1/ns1-go.v2/rest/model/dns"
+	"gopkg.in/ns1/ns1-go.v2/rest/model/filter"
 )
 
-var docNotes = providers.DocumentationNotes{
-	providers.DocCreateDomains:       providers.Cannot(),
+var features = providers.DocumentationNotes{
+	providers.CanUseAlias:            providers.Can(),
+	providers.CanUseCAA:              providers.Can(),
+	providers.CanUsePTR:              providers.Can(),
+	providers.CanUseSRV:              providers.Can(),
+	providers.CanUseTXTMulti:         providers.Can(),
+	providers.DocCreateDomains:       providers.Can(),
 	providers.DocOfficiallySupported: providers.Cannot(),
 	providers.DocDualHost:            providers.Can(),
 }
 
 func init() {
-	providers.RegisterDomainServiceProviderType("NS1", newProvider, providers.CanUseSRV, docNotes)
+	providers.RegisterDomainServiceProviderType("NS1", newProvider, features)
 }
 
 type nsone struct {
@@ -36,7 +38,11 @@ func newProvider(creds map[string]string, meta json.RawMessage) (providers.DNSSe
 	if creds["api_token"] == "" {
 		return nil, fmt.Errorf("api_token required for ns1")
 	}
-	return &nsone{rest.NewClient(http.DefaultClient, rest.SetAPIKey(creds["api_token"]))}, nil
+	client, err := providers.NewHTTPClient("NS1", creds)
+	if err != nil {
+		return nil, err
+	}
+	return &nsone{rest.NewClient(client, rest.SetAPIKey(creds["api_token"]))}, nil
 }
 
 func (n *nsone) GetNameservers(domain string) ([]*models.Nameserver, error) {
@@ -47,6 +53,17 @@ func (n *nsone) GetNameservers(domain string) ([]*models.Nameserver, error) {
 	return models.StringsToNameservers(z.DNSServers), nil
 }
 
+func (n *nsone) EnsureDomainExists(domain string) error {
+	if _, _, err := n.Zones.Get(domain); err == nil {
+		return nil
+	} else if err != rest.ErrZoneMissing {
+		return err
+	}
+	fmt.Printf("Adding zone for %s to NS1 account\n", domain)
+	_, err := n.Zones.Create(dns.NewZone(domain))
+	return err
+}
+
 func (n *nsone) GetDomainCorrections(dc *models.DomainConfig) ([]*models.Correction, error) {
 	dc.Punycode()
 	dc.CombineMXs()
@@ -57,7 +74,7 @@ func (n *nsone) GetDomainCorrections(dc *models.DomainConfig) ([]*models.Correct
 
 	found := models.Records{}
 	for _, r := range z.Records {
-		zrs, err := convert(r, dc.Name)
+		zrs, err := n.getRecords(r, dc.Name)
 		if err != nil {
 			return nil, err
 		}
@@ -66,10 +83,18 @@ func (n *nsone) GetDomainCorrections(dc *models.DomainConfig) ([]*models.Correct
 	foundGrouped := found.Grouped()
 	desiredGrouped := dc.Records.Grouped()
 
+	// Check the NS1 metadata before diffing it.
+	desired := map[models.RecordKey]*dns.Record{}
+	for k, recs := range desiredGrouped {
+		if desired[k], err = buildRecord(recs, dc.Name, ""); err != nil {
+			return nil, err
+		}
+	}
+
 	//  Normalize
 	models.PostProcessRecords(found)
 
-	differ := diff.New(dc)
+	differ := diff.New(dc, getNS1Metadata)
 	changedGroups := differ.ChangedGroups(found)
 	corrections := []*models.Correction{}
 	// each name/type is given to the api as a unit.
@@ -77,12 +102,12 @@ func (n *nsone) GetDomainCorrections(dc *models.DomainConfig) ([]*models.Correct
 		key := k
 		desc := strings.Join(descs, "\n")
 		_, current := foundGrouped[k]
-		recs, wanted := desiredGrouped[k]
+		rec, wanted := desired[k]
 		if wanted && !current {
 			// pure addition
 			corrections = append(corrections, &models.Correction{
 				Msg: desc,
-				F:   func() error { return n.add(recs, dc.Name) },
+				F:   func() error { return n.add(rec) },
 			})
 		} else if current && !wanted {
 			// pure deletion
@@ -94,15 +119,22 @@ func (n *nsone) GetDomainCorrections(dc *models.DomainConfig) ([]*models.Correct
 			// modification
 			corrections = append(corrections, &models.Correction{
 				Msg: desc,
-				F:   func() error { return n.modify(recs, dc.Name) },
+				F:   func() error { return n.modify(rec) },
 			})
 		}
 	}
 	return corrections, nil
 }
 
-func (n *nsone) add(recs models.Records, domain string) error {
-	_, err := n.Records.Create(buildRecord(recs, domain, ""))
+// recordUpdate always sends the filters of a record, so that they are
+// removed when there are none.
+type recordUpdate struct {
+	*dns.Record
+	Filters []*filter.Filter `json:"filters"`
+}
+
+func (n *nsone) add(rec *dns.Record) error {
+	_, err := n.Records.Create(rec)
 	return err
 }
 
@@ -111,12 +143,16 @@ func (n *nsone) remove(key models.RecordKey, domain string) error {
 	return err
 }
 
-func (n *nsone) modify(recs models.Records, domain string) error {
-	_, err := n.Records.Update(buildRecord(recs, domain, ""))
+func (n *nsone) modify(rec *dns.Record) error {
+	req, err := n.NewRequest("POST", fmt.Sprintf("zones/%s/%s/%s", rec.Zone, rec.Domain, rec.Type), &recordUpdate{rec, rec.Filters})
+	if err != nil {
+		return err
+	}
+	_, err = n.Do(req, nil)
 	return err
 }
 
-func buildRecord(recs models.Records, domain string, id string) *dns.Record {
+func buildRecord(recs models.Records, domain string, id string) (*dns.Record, error) {
 	r := recs[0]
 	rec := &dns.Record{
 		Domain: r.NameFQDN,
@@ -125,31 +161,105 @@ func buildRecord(recs models.Records, domain string, id string) *dns.Record {
 		TTL:    int(r.TTL),
 		Zone:   domain,
 	}
+	filters, err := recordFilters(recs)
+	if err != nil {
+		return nil, err
+	}
+	if len(filters) > 0 {
+		rec.Filters = filters
+	}
 	for _, r := range recs {
-		if r.Type == "TXT" {
-			rec.AddAnswer(&dns.Answer{Rdata: []string{r.Target}})
-		} else if r.Type == "SRV" {
-			rec.AddAnswer(&dns.Answer{Rdata: strings.Split(fmt.Sprintf("%d %d %d %v", r.SrvPriority, r.SrvWeight, r.SrvPort, r.Target), " ")})
-		} else {
-			rec.AddAnswer(&dns.Answer{Rdata: strings.Split(r.Target, " ")})
+		ans := &dns.Answer{}
+		switch r.Type {
+		case "TXT":
+			ans.Rdata = r.TxtStrings
+			if len(ans.Rdata) == 0 {
+				ans.Rdata = []string{r.Target}
+			}
+		case "SRV":
+			ans.Rdata = strings.Split(fmt.Sprintf("%d %d %d %v", r.SrvPriority, r.SrvWeight, r.SrvPort, r.Target), " ")
+		case "CAA":
+			ans.Rdata = []string{fmt.Sprint(r.CaaFlag), r.CaaTag, r.Target}
+		default:
+			ans.Rdata = strings.Split(r.Target, " ")
 		}
+		if ans.Meta, err = answerMeta(r); err != nil {
+			return nil, err
+		}
+		rec.AddAnswer(ans)
+	}
+	return rec, nil
+}
+
+// getRecords returns the records of a record set of the zone. The short
+// answers of the zone lack the metadata of the answers, and don't tell the
+// strings of a TXT record apart, so those are read from the full record.
+func (n *nsone) getRecords(zr *dns.ZoneRecord, domain string) (models.Records, error) {
+	if zr.Tier <= 1 && zr.Type != "TXT" {
+		return convert(zr, domain)
+	}
+	rec, _, err := n.Records.Get(domain, zr.Domain, zr.Type)
+	if err != nil {
+		return nil, err
+	}
+	return convertRecord(rec, domain)
+}
+
+func newRecordConfig(name, rtype string, ttl int, domain string, rdata []string, original interface{}) (*models.RecordConfig, error) {
+	rc := &models.RecordConfig{
+		NameFQDN: name,
+		Name:     dnsutil.TrimDomainName(name, domain),
+		TTL:      uint32(ttl),
+		Target:   strings.Join(rdata, " "),
+		Original: original,
+		Type:     rtype,
+	}
+	switch rtype {
+	case "MX", "SRV":
+		rc.CombinedTarget = true
+	case "TXT":
+		rc.SetTxts(rdata)
+	case "CAA":
+		if len(rdata) == 1 {
+			rdata = strings.SplitN(rdata[0], " ", 3)
+		}
+		if len(rdata) != 3 {
+			return nil, fmt.Errorf("unexpected CAA answer %q for %s", rc.Target, name)
+		}
+		flag, err := strconv.ParseUint(rdata[0], 10, 8)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected CAA answer %q for %s", rc.Target, name)
+		}
+		rc.CaaFlag, rc.CaaTag, rc.Target = uint8(flag), rdata[1], rdata[2]
 	}
-	return rec
+	return rc, nil
 }
 
 func convert(zr *dns.ZoneRecord, domain string) ([]*models.RecordConfig, error) {
 	found := []*models.RecordConfig{}
 	for _, ans := range zr.ShortAns {
-		rec := &models.RecordConfig{
-			NameFQDN: zr.Domain,
-			Name:     dnsutil.TrimDomainName(zr.Domain, domain),
-			TTL:      uint32(zr.TTL),
-			Target:   ans,
-			Original: zr,
-			Type:     zr.Type,
+		rec, err := newRecordConfig(zr.Domain, zr.Type, zr.TTL, domain, []string{ans}, zr)
+		if err != nil {
+			return nil, err
+		}
+		found = append(found, rec)
+	}
+	return found, nil
+}
+
+func convertRecord(r *dns.Record, domain string) ([]*models.RecordConfig, error) {
+	found := []*models.RecordConfig{}
+	for _, ans := range r.Answers {
+		rec, err := newRecordConfig(r.Domain, r.Type, r.TTL, domain, ans.Rdata, r)
+		if err != nil {
+			return nil, err
 		}
-		if zr.Type == "MX" || zr.Type == "SRV" {
-			rec.CombinedTarget = true
+		metaToRecord(ans.Meta, rec)
+		if len(r.Filters) > 0 {
+			if rec.Metadata == nil {
+				rec.Metadata = map[string]string{}
+			}
+			rec.Metadata[metaFilters] = formatFilters(r.Filters)
 		}
 		found = append(found, rec)
 	}
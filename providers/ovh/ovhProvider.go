@@ -3,6 +3,7 @@ package ovh
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"sort"
 	"strings"
 
@@ -14,7 +15,8 @@ import (
 )
 
 type ovhProvider struct {
-	client *ovh.Client
+	client *ovh.Client // Holds the credentials and the endpoint.
+	http   *http.Client
 	zones  map[string]bool
 }
 
@@ -32,14 +34,21 @@ var features = providers.DocumentationNotes{
 func newOVH(m map[string]string, metadata json.RawMessage) (*ovhProvider, error) {
 	appKey, appSecretKey, consumerKey := m["app-key"], m["app-secret-key"], m["consumer-key"]
 
-	c := ovh.NewClient(ovh.ENDPOINT_EU_OVHCOM, appKey, appSecretKey, consumerKey, false)
+	hc, err := providers.NewHTTPClient("OVH", m)
+	if err != nil {
+		return nil, err
+	}
+	c := &ovhProvider{
+		client: ovh.NewClient(ovh.ENDPOINT_EU_OVHCOM, appKey, appSecretKey, consumerKey, false),
+		http:   hc,
+	}
 
 	// Check for time lag
-	if err := c.PollTimeshift(); err != nil {
+	if err := c.pollTimeshift(); err != nil {
 		return nil, err
 	}
 
-	return &ovhProvider{client: c}, nil
+	return c, nil
 }
 
 func newDsp(conf map[string]string, metadata json.RawMessage) (providers.DNSServiceProvider, error) {
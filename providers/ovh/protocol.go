@@ -1,7 +1,14 @@
 package ovh
 
 import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/StackExchange/dnscontrol/models"
 	"github.com/miekg/dns/dnsutil"
@@ -11,6 +18,71 @@ import (
 type Void struct {
 }
 
+// The requests are sent here rather than by ovh.Client, which creates its
+// own HTTP client for every request.
+
+// pollTimeshift sets the difference between the clock of the API and ours,
+// which signatures must take into account.
+func (c *ovhProvider) pollTimeshift() error {
+	now := time.Now()
+	resp, err := c.http.Get(c.client.Endpoint + "/auth/time")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	apiTime, err := strconv.ParseInt(string(body), 10, 64)
+	if err != nil {
+		return fmt.Errorf("unexpected time from the OVH API: %q", body)
+	}
+	c.client.TimeShift = time.Unix(apiTime, 0).Sub(now)
+	return nil
+}
+
+// call sends a signed request to the API, with in as its JSON body if it
+// isn't nil, and decodes the JSON response into out.
+func (c *ovhProvider) call(method, path string, in, out interface{}) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return err
+		}
+	}
+	url := c.client.Endpoint + path
+	timestamp := time.Now().Add(c.client.TimeShift).Unix()
+	signature := sha1.Sum([]byte(fmt.Sprintf("%s+%s+%s+%s+%s+%d", c.client.AppSecret, c.client.ConsumerKey, method, url, body, timestamp)))
+
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Ovh-Application", c.client.AppKey)
+	req.Header.Set("X-Ovh-Consumer", c.client.ConsumerKey)
+	req.Header.Set("X-Ovh-Signature", fmt.Sprintf("$1$%x", signature))
+	req.Header.Set("X-Ovh-Timestamp", strconv.FormatInt(timestamp, 10))
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected HTTP status from the OVH API (%s : %s)", resp.Status, respBody)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(respBody, out)
+}
+
 // fetchDomainList gets list of zones for account
 func (c *ovhProvider) fetchZones() error {
 	if c.zones != nil {
@@ -20,7 +92,7 @@ func (c *ovhProvider) fetchZones() error {
 
 	var response []string
 
-	err := c.client.Call("GET", "/domain/zone", nil, &response)
+	err := c.call("GET", "/domain/zone", nil, &response)
 
 	if err != nil {
 		return err
@@ -44,7 +116,7 @@ type Zone struct {
 func (c *ovhProvider) fetchZone(fqdn string) (*Zone, error) {
 	var response Zone
 
-	err := c.client.Call("GET", "/domain/zone/"+fqdn, nil, &response)
+	err := c.call("GET", "/domain/zone/"+fqdn, nil, &response)
 	if err != nil {
 		return nil, err
 	}
@@ -69,7 +141,7 @@ type records struct {
 func (c *ovhProvider) fetchRecords(fqdn string) ([]*Record, error) {
 	var recordIds []int
 
-	err := c.client.Call("GET", "/domain/zone/"+fqdn+"/record", nil, &recordIds)
+	err := c.call("GET", "/domain/zone/"+fqdn+"/record", nil, &recordIds)
 	if err != nil {
 		return nil, err
 	}
@@ -89,7 +161,7 @@ func (c *ovhProvider) fetchRecords(fqdn string) ([]*Record, error) {
 func (c *ovhProvider) fecthRecord(fqdn string, id int) (*Record, error) {
 	var response Record
 
-	err := c.client.Call("GET", fmt.Sprintf("/domain/zone/%s/record/%d", fqdn, id), nil, &response)
+	err := c.call("GET", fmt.Sprintf("/domain/zone/%s/record/%d", fqdn, id), nil, &response)
 	if err != nil {
 		return nil, err
 	}
@@ -99,7 +171,7 @@ func (c *ovhProvider) fecthRecord(fqdn string, id int) (*Record, error) {
 // Returns a function that can be invoked to delete a record in a zone.
 func (c *ovhProvider) deleteRecordFunc(id int64, fqdn string) func() error {
 	return func() error {
-		err := c.client.Call("DELETE", fmt.Sprintf("/domain/zone/%s/record/%d", fqdn, id), nil, nil)
+		err := c.call("DELETE", fmt.Sprintf("/domain/zone/%s/record/%d", fqdn, id), nil, nil)
 		if err != nil {
 			return err
 		}
@@ -120,7 +192,7 @@ func (c *ovhProvider) createRecordFunc(rc *models.RecordConfig, fqdn string) fun
 			record.SubDomain = ""
 		}
 		var response Record
-		err := c.client.Call("POST", fmt.Sprintf("/domain/zone/%s/record", fqdn), &record, &response)
+		err := c.call("POST", fmt.Sprintf("/domain/zone/%s/record", fqdn), &record, &response)
 		return err
 	}
 }
@@ -140,12 +212,12 @@ func (c *ovhProvider) updateRecordFunc(old *Record, rc *models.RecordConfig, fqd
 			record.SubDomain = ""
 		}
 
-		return c.client.Call("PUT", fmt.Sprintf("/domain/zone/%s/record/%d", fqdn, old.ID), &record, &Void{})
+		return c.call("PUT", fmt.Sprintf("/domain/zone/%s/record/%d", fqdn, old.ID), &record, &Void{})
 	}
 }
 
 func (c *ovhProvider) refreshZone(fqdn string) error {
-	return c.client.Call("POST", fmt.Sprintf("/domain/zone/%s/refresh", fqdn), nil, &Void{})
+	return c.call("POST", fmt.Sprintf("/domain/zone/%s/refresh", fqdn), nil, &Void{})
 }
 
 // fetch the NS OVH attributed to this zone (which is distinct from fetchRealNS which
@@ -171,7 +243,7 @@ type CurrentNameServer struct {
 // Retrieve the NS currently being deployed to the registrar
 func (c *ovhProvider) fetchRegistrarNS(fqdn string) ([]string, error) {
 	var nameServersID []int
-	err := c.client.Call("GET", "/domain/"+fqdn+"/nameServer", nil, &nameServersID)
+	err := c.call("GET", "/domain/"+fqdn+"/nameServer", nil, &nameServersID)
 	if err != nil {
 		return nil, err
 	}
@@ -179,7 +251,7 @@ func (c *ovhProvider) fetchRegistrarNS(fqdn string) ([]string, error) {
 	var nameServers []string
 	for _, id := range nameServersID {
 		var ns CurrentNameServer
-		err = c.client.Call("GET", fmt.Sprintf("/domain/%s/nameServer/%d", fqdn, id), nil, &ns)
+		err = c.call("GET", fmt.Sprintf("/domain/%s/nameServer/%d", fqdn, id), nil, &ns)
 		if err != nil {
 			return nil, err
 		}
@@ -231,7 +303,7 @@ func (c *ovhProvider) updateNS(fqdn string, ns []string) error {
 	// by default zones are in "hosted" mode meaning they default
 	// to OVH default NS. In this mode, the NS can't be updated.
 	domain := Domain{NameServerType: "external"}
-	err := c.client.Call("PUT", fmt.Sprintf("/domain/%s", fqdn), &domain, &Void{})
+	err := c.call("PUT", fmt.Sprintf("/domain/%s", fqdn), &domain, &Void{})
 	if err != nil {
 		return err
 	}
@@ -247,7 +319,7 @@ func (c *ovhProvider) updateNS(fqdn string, ns []string) error {
 		NameServers: newNs,
 	}
 	var task Task
-	err = c.client.Call("POST", fmt.Sprintf("/domain/%s/nameServers/update", fqdn), &update, &task)
+	err = c.call("POST", fmt.Sprintf("/domain/%s/nameServers/update", fqdn), &update, &task)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,128 @@
+package powerdns
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// api is a minimal client for the PowerDNS Authoritative HTTP API.
+// See https://doc.powerdns.com/authoritative/http-api/
+type api struct {
+	client     *http.Client
+	baseURL    string
+	apiKey     string
+	serverName string
+}
+
+type zone struct {
+	ID          string   `json:"id,omitempty"`
+	Name        string   `json:"name"`
+	Kind        string   `json:"kind,omitempty"`
+	DNSSec      *bool    `json:"dnssec,omitempty"`
+	SoaEditAPI  string   `json:"soa_edit_api,omitempty"`
+	Nameservers []string `json:"nameservers,omitempty"`
+	RRsets      []rrset  `json:"rrsets,omitempty"`
+}
+
+type rrset struct {
+	Name       string   `json:"name"`
+	Type       string   `json:"type"`
+	TTL        uint32   `json:"ttl,omitempty"`
+	ChangeType string   `json:"changetype,omitempty"`
+	Records    []record `json:"records"`
+}
+
+type record struct {
+	Content  string `json:"content"`
+	Disabled bool   `json:"disabled"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func (c *api) zonesURL() string {
+	return fmt.Sprintf("%s/api/v1/servers/%s/zones", strings.TrimSuffix(c.baseURL, "/"), url.PathEscape(c.serverName))
+}
+
+func (c *api) zoneURL(name string) string {
+	return c.zonesURL() + "/" + url.PathEscape(canonical(name))
+}
+
+// canonical returns the zone or record name in the form the API expects: with a trailing dot.
+func canonical(name string) string {
+	return strings.TrimSuffix(name, ".") + "."
+}
+
+func (c *api) do(method, u string, body, target interface{}) (int, error) {
+	buf := &bytes.Buffer{}
+	if body != nil {
+		if err := json.NewEncoder(buf).Encode(body); err != nil {
+			return 0, err
+		}
+	}
+	req, err := http.NewRequest(method, u, buf)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("X-API-Key", c.apiKey)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		e := &errorResponse{}
+		if err := json.NewDecoder(resp.Body).Decode(e); err != nil || e.Error == "" {
+			return resp.StatusCode, fmt.Errorf("PowerDNS API %s %s: %s", method, u, resp.Status)
+		}
+		return resp.StatusCode, fmt.Errorf("PowerDNS API %s %s: %s: %s", method, u, resp.Status, e.Error)
+	}
+	if target != nil {
+		return resp.StatusCode, json.NewDecoder(resp.Body).Decode(target)
+	}
+	return resp.StatusCode, nil
+}
+
+func (c *api) listZones() ([]zone, error) {
+	zones := []zone{}
+	_, err := c.do(http.MethodGet, c.zonesURL(), nil, &zones)
+	return zones, err
+}
+
+// getZone returns the zone with all its RRsets, or nil if it does not exist.
+func (c *api) getZone(name string) (*zone, error) {
+	z := &zone{}
+	status, err := c.do(http.MethodGet, c.zoneURL(name), nil, z)
+	if status == http.StatusNotFound || status == http.StatusUnprocessableEntity {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return z, nil
+}
+
+func (c *api) createZone(z *zone) error {
+	_, err := c.do(http.MethodPost, c.zonesURL(), z, nil)
+	return err
+}
+
+// updateZone changes the zone settings (kind, dnssec...). RRsets are ignored.
+func (c *api) updateZone(name string, z *zone) error {
+	_, err := c.do(http.MethodPut, c.zoneURL(name), z, nil)
+	return err
+}
+
+func (c *api) patchRRsets(name string, sets []rrset) error {
+	_, err := c.do(http.MethodPatch, c.zoneURL(name), &zone{Name: canonical(name), RRsets: sets}, nil)
+	return err
+}
@@ -0,0 +1,274 @@
+package powerdns
+
+/*
+
+PowerDNS Authoritative API provider:
+
+Info required in `creds.json`:
+   - apiurl       (for example http://localhost:8081)
+   - apikey
+   - servername   (optional, defaults to "localhost")
+
+*/
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/miekg/dns/dnsutil"
+
+	"github.com/StackExchange/dnscontrol/models"
+	"github.com/StackExchange/dnscontrol/providers"
+	"github.com/StackExchange/dnscontrol/providers/diff"
+)
+
+var features = providers.DocumentationNotes{
+	providers.CanUseCAA:              providers.Can(),
+	providers.CanUsePTR:              providers.Can(),
+	providers.CanUseSRV:              providers.Can(),
+	providers.CanUseTLSA:             providers.Can(),
+	providers.CanUseTXTMulti:         providers.Can(),
+	providers.DocCreateDomains:       providers.Can(),
+	providers.DocDualHost:            providers.Can(),
+	providers.DocOfficiallySupported: providers.Cannot(),
+}
+
+func init() {
+	providers.RegisterDomainServiceProviderType("POWERDNS", newProvider, features)
+}
+
+// Provider is the provider handle for the PowerDNS driver.
+type Provider struct {
+	*api
+
+	// DefaultNS is the list of nameservers of every zone.
+	DefaultNS []string `json:"default_ns"`
+	// ZoneKind is Native, Master or Slave. It is used for new zones and,
+	// when set, enforced on existing ones.
+	ZoneKind string `json:"zone_kind"`
+	// DNSSec, when set, signs (or unsigns) zones.
+	DNSSec *bool `json:"dnssec"`
+	// SoaEditAPI is the SOA-EDIT-API setting of new zones. It controls how
+	// the serial is bumped when records are changed through the API.
+	SoaEditAPI string `json:"soa_edit_api"`
+
+	nameservers []*models.Nameserver
+}
+
+func newProvider(creds map[string]string, meta json.RawMessage) (providers.DNSServiceProvider, error) {
+	client, err := providers.NewHTTPClient("POWERDNS", creds)
+	if err != nil {
+		return nil, err
+	}
+	p := &Provider{
+		api: &api{
+			client:     client,
+			baseURL:    creds["apiurl"],
+			apiKey:     creds["apikey"],
+			serverName: creds["servername"],
+		},
+		SoaEditAPI: "DEFAULT",
+	}
+	if p.baseURL == "" || p.apiKey == "" {
+		return nil, fmt.Errorf("apiurl and apikey required for POWERDNS")
+	}
+	if p.serverName == "" {
+		p.serverName = "localhost"
+	}
+	if len(meta) != 0 {
+		if err := json.Unmarshal(meta, p); err != nil {
+			return nil, err
+		}
+	}
+	switch p.ZoneKind {
+	case "", "Native", "Master", "Slave":
+	default:
+		return nil, fmt.Errorf("POWERDNS zone_kind must be Native, Master or Slave, not %q", p.ZoneKind)
+	}
+	p.nameservers = models.StringsToNameservers(p.DefaultNS)
+	return p, nil
+}
+
+// GetNameservers returns the nameservers for a domain.
+func (p *Provider) GetNameservers(string) ([]*models.Nameserver, error) {
+	return p.nameservers, nil
+}
+
+// EnsureDomainExists creates the zone if it does not exist.
+func (p *Provider) EnsureDomainExists(domain string) error {
+	zones, err := p.listZones()
+	if err != nil {
+		return err
+	}
+	for _, z := range zones {
+		if strings.EqualFold(z.Name, canonical(domain)) {
+			return nil
+		}
+	}
+	kind := p.ZoneKind
+	if kind == "" {
+		kind = "Native"
+	}
+	nameservers := []string{}
+	for _, ns := range p.DefaultNS {
+		nameservers = append(nameservers, canonical(ns))
+	}
+	fmt.Printf("Adding zone for %s to PowerDNS\n", domain)
+	return p.createZone(&zone{
+		Name:        canonical(domain),
+		Kind:        kind,
+		DNSSec:      p.DNSSec,
+		SoaEditAPI:  p.SoaEditAPI,
+		Nameservers: nameservers,
+	})
+}
+
+// GetDomainCorrections returns a list of corrections to update a domain.
+func (p *Provider) GetDomainCorrections(dc *models.DomainConfig) ([]*models.Correction, error) {
+	dc.Punycode()
+	z, err := p.getZone(dc.Name)
+	if err != nil {
+		return nil, err
+	}
+	if z == nil {
+		return nil, fmt.Errorf("%s not listed in zones for PowerDNS server %s", dc.Name, p.serverName)
+	}
+
+	corrections := []*models.Correction{}
+	if c := p.settingsCorrection(dc.Name, z); c != nil {
+		corrections = append(corrections, c)
+	}
+
+	desiredGrouped := dc.Records.Grouped()
+	// Without default_ns or NAMESERVER(), the apex NS records of the zone
+	// are left alone rather than deleted.
+	_, manageApexNS := desiredGrouped[models.RecordKey{Name: "@", Type: "NS"}]
+
+	existing := models.Records{}
+	for _, set := range z.RRsets {
+		if set.Type == "SOA" {
+			// Maintained by PowerDNS according to SOA-EDIT-API.
+			continue
+		}
+		if set.Type == "NS" && strings.EqualFold(set.Name, canonical(dc.Name)) && !manageApexNS {
+			continue
+		}
+		for _, r := range set.Records {
+			if r.Disabled {
+				continue
+			}
+			rc, err := toRecordConfig(dc.Name, set, r)
+			if err != nil {
+				log.Printf("POWERDNS: ignoring record in %s: %s", dc.Name, err)
+				continue
+			}
+			existing = append(existing, rc)
+		}
+	}
+	models.PostProcessRecords(existing)
+
+	changedGroups := diff.New(dc).ChangedGroups(existing)
+	keys := make([]models.RecordKey, 0, len(changedGroups))
+	for k := range changedGroups {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Name == keys[j].Name {
+			return keys[i].Type < keys[j].Type
+		}
+		return keys[i].Name < keys[j].Name
+	})
+
+	// Each name/type is given to the API as a unit.
+	for _, k := range keys {
+		set := rrset{
+			Name:       canonical(dnsutil.AddOrigin(k.Name, dc.Name)),
+			Type:       k.Type,
+			ChangeType: "DELETE",
+			Records:    []record{},
+		}
+		if recs, ok := desiredGrouped[k]; ok {
+			set.ChangeType = "REPLACE"
+			set.TTL = recs[0].TTL
+			for _, rc := range recs {
+				set.Records = append(set.Records, record{Content: toContent(rc)})
+			}
+		}
+		corrections = append(corrections, &models.Correction{
+			Msg:    strings.Join(changedGroups[k], "\n"),
+			F:      func() error { return p.patchRRsets(dc.Name, []rrset{set}) },
+			Change: set,
+		})
+	}
+	return corrections, nil
+}
+
+// ApplyCorrections sends all the changed RRsets in one PATCH, which PowerDNS
+// applies in a single transaction. The zone settings are updated first.
+func (p *Provider) ApplyCorrections(dc *models.DomainConfig, corrections []*models.Correction) error {
+	sets := []rrset{}
+	for _, c := range corrections {
+		set, ok := c.Change.(rrset)
+		if !ok {
+			if err := c.F(); err != nil {
+				return err
+			}
+			continue
+		}
+		sets = append(sets, set)
+	}
+	if len(sets) == 0 {
+		return nil
+	}
+	return p.patchRRsets(dc.Name, sets)
+}
+
+// settingsCorrection returns a correction aligning the zone kind and DNSSEC
+// state with the metadata, or nil if there is nothing to do.
+func (p *Provider) settingsCorrection(domain string, z *zone) *models.Correction {
+	update := &zone{}
+	msgs := []string{}
+	if p.ZoneKind != "" && !strings.EqualFold(p.ZoneKind, z.Kind) {
+		update.Kind = p.ZoneKind
+		msgs = append(msgs, fmt.Sprintf("Change zone kind from %s to %s", z.Kind, p.ZoneKind))
+	}
+	if p.DNSSec != nil && (z.DNSSec == nil || *z.DNSSec != *p.DNSSec) {
+		update.DNSSec = p.DNSSec
+		if *p.DNSSec {
+			msgs = append(msgs, "Enable DNSSEC")
+		} else {
+			msgs = append(msgs, "Disable DNSSEC")
+		}
+	}
+	if len(msgs) == 0 {
+		return nil
+	}
+	return &models.Correction{
+		Msg: strings.Join(msgs, "\n"),
+		F:   func() error { return p.updateZone(domain, update) },
+	}
+}
+
+// toRecordConfig parses the presentation format content of a record.
+func toRecordConfig(domain string, set rrset, r record) (*models.RecordConfig, error) {
+	rr, err := dns.NewRR(fmt.Sprintf("%s %d IN %s %s", set.Name, set.TTL, set.Type, r.Content))
+	if err != nil {
+		return nil, err
+	}
+	if rr == nil {
+		return nil, fmt.Errorf("empty %s record %s", set.Type, set.Name)
+	}
+	// The origin must be a FQDN for Name to be relative to it, as the diff
+	// groups records by Name.
+	return models.RRtoRC(rr, canonical(domain))
+}
+
+// toContent returns the record data in presentation format.
+func toContent(rc *models.RecordConfig) string {
+	rr := rc.ToRR()
+	return strings.TrimPrefix(rr.String(), rr.Header().String())
+}
@@ -0,0 +1,286 @@
+package powerdns
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/StackExchange/dnscontrol/models"
+)
+
+// fakeAPI emulates the parts of the PowerDNS API used by the provider.
+type fakeAPI struct {
+	sync.Mutex
+	zones   map[string]*zone
+	patches int
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+	if r.Header.Get("X-API-Key") != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(&errorResponse{Error: "Unauthorized"})
+		return
+	}
+	const prefix = "/api/v1/servers/localhost/zones"
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	name := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, prefix), "/")
+	body := &zone{}
+	if r.Method != http.MethodGet {
+		if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+	z := f.zones[name]
+	switch {
+	case name == "" && r.Method == http.MethodGet:
+		list := []zone{}
+		for _, z := range f.zones {
+			list = append(list, zone{ID: z.ID, Name: z.Name, Kind: z.Kind})
+		}
+		json.NewEncoder(w).Encode(list)
+	case name == "" && r.Method == http.MethodPost:
+		body.ID = body.Name
+		body.RRsets = []rrset{{Name: body.Name, Type: "SOA", TTL: 3600, Records: []record{{Content: "a.misconfigured.powerdns.server. hostmaster." + body.Name + " 1 10800 3600 604800 3600"}}}}
+		f.zones[body.Name] = body
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(body)
+	case z == nil:
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(&errorResponse{Error: "Could not find domain '" + name + "'"})
+	case r.Method == http.MethodGet:
+		json.NewEncoder(w).Encode(z)
+	case r.Method == http.MethodPut:
+		if body.Kind != "" {
+			z.Kind = body.Kind
+		}
+		if body.DNSSec != nil {
+			z.DNSSec = body.DNSSec
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPatch:
+		f.patches++
+		for _, set := range body.RRsets {
+			kept := []rrset{}
+			for _, old := range z.RRsets {
+				if old.Name != set.Name || old.Type != set.Type {
+					kept = append(kept, old)
+				}
+			}
+			if set.ChangeType == "REPLACE" {
+				set.ChangeType = ""
+				kept = append(kept, set)
+			}
+			z.RRsets = kept
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func newTestProvider(t *testing.T, meta string) (*Provider, *fakeAPI, func()) {
+	f := &fakeAPI{zones: map[string]*zone{}}
+	srv := httptest.NewServer(f)
+	var raw json.RawMessage
+	if meta != "" {
+		raw = json.RawMessage(meta)
+	}
+	p, err := newProvider(map[string]string{"apiurl": srv.URL, "apikey": "secret"}, raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p.(*Provider), f, srv.Close
+}
+
+func desired() *models.DomainConfig {
+	return &models.DomainConfig{
+		Name: "example.com",
+		Records: []*models.RecordConfig{
+			{Type: "NS", Name: "@", NameFQDN: "example.com", Target: "ns1.example.com.", TTL: 3600},
+			{Type: "A", Name: "www", NameFQDN: "www.example.com", Target: "1.2.3.4", TTL: 300},
+			{Type: "A", Name: "www", NameFQDN: "www.example.com", Target: "1.2.3.5", TTL: 300},
+			{Type: "MX", Name: "@", NameFQDN: "example.com", Target: "mx.example.com.", MxPreference: 10, TTL: 300},
+			{Type: "TXT", Name: "@", NameFQDN: "example.com", Target: "v=spf1 -all", TxtStrings: []string{"v=spf1 -all"}, TTL: 300},
+			{Type: "SRV", Name: "_sip._tcp", NameFQDN: "_sip._tcp.example.com", Target: "sip.example.com.", SrvPriority: 1, SrvWeight: 2, SrvPort: 5060, TTL: 300},
+		},
+	}
+}
+
+func TestCorrections(t *testing.T) {
+	p, f, stop := newTestProvider(t, `{"default_ns": ["ns1.example.com."]}`)
+	defer stop()
+
+	if err := p.EnsureDomainExists("example.com"); err != nil {
+		t.Fatal(err)
+	}
+	z := f.zones["example.com."]
+	if z == nil || z.Kind != "Native" || len(z.Nameservers) != 1 {
+		t.Fatalf("zone not created as expected: %+v", z)
+	}
+	z.RRsets = append(z.RRsets,
+		rrset{Name: "old.example.com.", Type: "CNAME", TTL: 300, Records: []record{{Content: "www.example.com."}}},
+		rrset{Name: "www.example.com.", Type: "A", TTL: 300, Records: []record{{Content: "1.2.3.4"}}},
+	)
+
+	corrections, err := p.GetDomainCorrections(desired())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// NS, www A, MX, TXT, SRV and the deleted CNAME.
+	if len(corrections) != 6 {
+		t.Fatalf("expected 6 corrections, got %d", len(corrections))
+	}
+	for _, c := range corrections {
+		if err := c.F(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if f.patches != 6 {
+		t.Errorf("expected one PATCH per RRset, got %d", f.patches)
+	}
+
+	corrections, err = p.GetDomainCorrections(desired())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(corrections) != 0 {
+		t.Fatalf("expected no corrections on second run, got %v", corrections[0].Msg)
+	}
+}
+
+func TestApexNSWithoutNameservers(t *testing.T) {
+	p, f, stop := newTestProvider(t, "")
+	defer stop()
+	f.zones["example.com."] = &zone{ID: "example.com.", Name: "example.com.", Kind: "Native", RRsets: []rrset{
+		{Name: "example.com.", Type: "NS", TTL: 3600, Records: []record{{Content: "ns1.example.net."}, {Content: "ns2.example.net."}}},
+		{Name: "sub.example.com.", Type: "NS", TTL: 3600, Records: []record{{Content: "ns1.example.org."}}},
+	}}
+
+	dc := &models.DomainConfig{
+		Name: "example.com",
+		Records: []*models.RecordConfig{
+			{Type: "A", Name: "www", NameFQDN: "www.example.com", Target: "1.2.3.4", TTL: 300},
+		},
+	}
+	corrections, err := p.GetDomainCorrections(dc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The www A record and the deleted delegation of sub, but not the apex NS.
+	if len(corrections) != 2 {
+		t.Fatalf("expected 2 corrections, got %d", len(corrections))
+	}
+	for _, c := range corrections {
+		if set := c.Change.(rrset); set.Name == "example.com." {
+			t.Errorf("unexpected correction of the apex NS: %s", c.Msg)
+		}
+	}
+
+	// With nameservers, the apex NS records are managed.
+	dc.Records = append(dc.Records, &models.RecordConfig{Type: "NS", Name: "@", NameFQDN: "example.com", Target: "ns1.example.net.", TTL: 3600})
+	if corrections, err = p.GetDomainCorrections(dc); err != nil || len(corrections) != 3 {
+		t.Fatalf("expected 3 corrections, got %d, %v", len(corrections), err)
+	}
+}
+
+func TestDeleteOneOfSet(t *testing.T) {
+	p, f, stop := newTestProvider(t, "")
+	defer stop()
+	f.zones["example.com."] = &zone{ID: "example.com.", Name: "example.com.", Kind: "Native", RRsets: []rrset{
+		{Name: "www.example.com.", Type: "A", TTL: 300, Records: []record{{Content: "1.2.3.4"}, {Content: "1.2.3.5"}}},
+	}}
+
+	dc := &models.DomainConfig{
+		Name: "example.com",
+		Records: []*models.RecordConfig{
+			{Type: "A", Name: "www", NameFQDN: "www.example.com", Target: "1.2.3.4", TTL: 300},
+		},
+	}
+	corrections, err := p.GetDomainCorrections(dc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(corrections) != 1 {
+		t.Fatalf("expected 1 correction, got %d", len(corrections))
+	}
+	// The RRset keeps the other record.
+	if set := corrections[0].Change.(rrset); set.ChangeType != "REPLACE" || len(set.Records) != 1 || set.Records[0].Content != "1.2.3.4" {
+		t.Fatalf("unexpected change %+v", set)
+	}
+}
+
+func TestApplyCorrections(t *testing.T) {
+	p, f, stop := newTestProvider(t, `{"default_ns": ["ns1.example.com."], "dnssec": true}`)
+	defer stop()
+	if err := p.EnsureDomainExists("example.com"); err != nil {
+		t.Fatal(err)
+	}
+	f.zones["example.com."].DNSSec = nil
+
+	dc := desired()
+	corrections, err := p.GetDomainCorrections(dc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The DNSSEC setting, then NS, www A, MX, TXT and SRV.
+	if len(corrections) != 6 {
+		t.Fatalf("expected 6 corrections, got %d", len(corrections))
+	}
+	if err := p.ApplyCorrections(dc, corrections); err != nil {
+		t.Fatal(err)
+	}
+	if f.patches != 1 {
+		t.Errorf("expected all the RRsets in one PATCH, got %d", f.patches)
+	}
+	if corrections, err = p.GetDomainCorrections(desired()); err != nil || len(corrections) != 0 {
+		t.Fatalf("expected no corrections on second run, got %d, %v", len(corrections), err)
+	}
+}
+
+func TestZoneSettings(t *testing.T) {
+	p, f, stop := newTestProvider(t, `{"zone_kind": "Master", "dnssec": true}`)
+	defer stop()
+	f.zones["example.com."] = &zone{ID: "example.com.", Name: "example.com.", Kind: "Native"}
+
+	corrections, err := p.GetDomainCorrections(&models.DomainConfig{Name: "example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(corrections) != 1 {
+		t.Fatalf("expected a settings correction, got %d", len(corrections))
+	}
+	if err := corrections[0].F(); err != nil {
+		t.Fatal(err)
+	}
+	z := f.zones["example.com."]
+	if z.Kind != "Master" || z.DNSSec == nil || !*z.DNSSec {
+		t.Fatalf("settings not applied: %+v", z)
+	}
+}
+
+func TestMissingZone(t *testing.T) {
+	p, _, stop := newTestProvider(t, "")
+	defer stop()
+	if _, err := p.GetDomainCorrections(&models.DomainConfig{Name: "example.com"}); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestBadKey(t *testing.T) {
+	p, _, stop := newTestProvider(t, "")
+	defer stop()
+	p.apiKey = "wrong"
+	err := p.EnsureDomainExists("example.com")
+	if err == nil || !strings.Contains(err.Error(), "Unauthorized") {
+		t.Fatalf("expected an authorization error, got %v", err)
+	}
+}
@@ -25,6 +25,33 @@ type DomainCreator interface {
 	EnsureDomainExists(domain string) error
 }
 
+// DomainConfigCreator should be implemented by DomainCreators whose new zones depend on the metadata of the domain
+// (a project or a private network for example). create-domains then calls it instead of EnsureDomainExists.
+type DomainConfigCreator interface {
+	EnsureDomainConfigExists(dc *models.DomainConfig) error
+}
+
+// DomainConfigNameserverGetter should be implemented by DNS providers whose zone for a domain depends on the
+// metadata of the domain. DetermineNameservers then calls it instead of GetNameservers.
+type DomainConfigNameserverGetter interface {
+	GetDomainConfigNameservers(dc *models.DomainConfig) ([]*models.Nameserver, error)
+}
+
+// CorrectionBatcher should be implemented by DNS providers that can apply several corrections to a domain in one
+// atomic request. push then hands it all the corrections of a domain at once, instead of running them one by one,
+// so that a failure leaves the zone as it was.
+type CorrectionBatcher interface {
+	// ApplyCorrections applies corrections, which GetDomainCorrections returned for dc. Some of them may have been
+	// skipped in interactive mode.
+	ApplyCorrections(dc *models.DomainConfig, corrections []*models.Correction) error
+}
+
+// RegistrarStatusReporter should be implemented by registrars that can report the registration state of a domain
+// (expiry date, lock state and current delegation). the registrar-status command uses it.
+type RegistrarStatusReporter interface {
+	GetRegistrarStatus(domain string) (*models.RegistrarStatus, error)
+}
+
 // RegistrarInitializer is a function to create a registrar. Function will be passed the unprocessed json payload from the configuration file for the given provider.
 type RegistrarInitializer func(map[string]string) (Registrar, error)
 
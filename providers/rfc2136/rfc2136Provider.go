@@ -0,0 +1,252 @@
+package rfc2136
+
+/*
+
+rfc2136 -
+  Manage zones on any server that accepts RFC 2136 dynamic updates
+  (BIND, Knot, PowerDNS, Windows DNS...).
+
+	The existing zone is read with an AXFR and changes are sent as
+	UPDATE messages. Both are signed with TSIG when a key is configured.
+
+*/
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"github.com/StackExchange/dnscontrol/models"
+	"github.com/StackExchange/dnscontrol/providers"
+	"github.com/StackExchange/dnscontrol/providers/diff"
+)
+
+var features = providers.DocumentationNotes{
+	providers.CanUseCAA:              providers.Can(),
+	providers.CanUsePTR:              providers.Can(),
+	providers.CanUseSRV:              providers.Can(),
+	providers.CanUseTLSA:             providers.Can(),
+	providers.CanUseTXTMulti:         providers.Can(),
+	providers.DocCreateDomains:       providers.Cannot("Zones must be configured on the server before they can be updated"),
+	providers.DocDualHost:            providers.Can(),
+	providers.DocOfficiallySupported: providers.Cannot(),
+}
+
+func init() {
+	providers.RegisterDomainServiceProviderType("RFC2136", newProvider, features)
+}
+
+// maxChangesPerUpdate limits the size of a single UPDATE message.
+// Larger change sets are split over several messages.
+const maxChangesPerUpdate = 500
+
+// typeSigningState is the private type BIND uses to track inline signing.
+const typeSigningState = 65534
+
+// Provider is the provider handle for the RFC2136 driver.
+type Provider struct {
+	DefaultNS   []string `json:"default_ns"`
+	nameservers []*models.Nameserver
+
+	server    string
+	keyName   string
+	secret    string
+	algorithm string
+	timeout   time.Duration
+}
+
+func newProvider(creds map[string]string, meta json.RawMessage) (providers.DNSServiceProvider, error) {
+	api := &Provider{
+		server:    creds["server"],
+		secret:    creds["tsig_secret"],
+		algorithm: dns.HmacSHA256,
+		timeout:   10 * time.Second,
+	}
+	if api.server == "" {
+		return nil, fmt.Errorf("server required for RFC2136")
+	}
+	if _, _, err := net.SplitHostPort(api.server); err != nil {
+		api.server = net.JoinHostPort(api.server, "53")
+	}
+	if creds["tsig_keyname"] != "" {
+		if api.secret == "" {
+			return nil, fmt.Errorf("tsig_secret required when tsig_keyname is set")
+		}
+		api.keyName = dns.Fqdn(strings.ToLower(creds["tsig_keyname"]))
+	}
+	if creds["tsig_algorithm"] != "" {
+		api.algorithm = dns.Fqdn(strings.ToLower(creds["tsig_algorithm"]))
+		switch api.algorithm {
+		case dns.HmacMD5, dns.HmacSHA1, dns.HmacSHA256, dns.HmacSHA512:
+		case "hmac-md5.":
+			api.algorithm = dns.HmacMD5
+		default:
+			return nil, fmt.Errorf("unsupported tsig_algorithm %q", creds["tsig_algorithm"])
+		}
+	}
+	if len(meta) != 0 {
+		if err := json.Unmarshal(meta, api); err != nil {
+			return nil, err
+		}
+	}
+	api.nameservers = models.StringsToNameservers(api.DefaultNS)
+	return api, nil
+}
+
+// GetNameservers returns the nameservers for a domain.
+func (c *Provider) GetNameservers(string) ([]*models.Nameserver, error) {
+	return c.nameservers, nil
+}
+
+func (c *Provider) tsigSecret() map[string]string {
+	if c.keyName == "" {
+		return nil
+	}
+	return map[string]string{c.keyName: c.secret}
+}
+
+func (c *Provider) sign(m *dns.Msg) {
+	if c.keyName != "" {
+		m.SetTsig(c.keyName, c.algorithm, 300, time.Now().Unix())
+	}
+}
+
+// getZone transfers the zone and returns its records along with its SOA.
+func (c *Provider) getZone(zone string) ([]*models.RecordConfig, *dns.SOA, error) {
+	m := new(dns.Msg)
+	m.SetAxfr(dns.Fqdn(zone))
+	c.sign(m)
+	t := &dns.Transfer{
+		DialTimeout:  c.timeout,
+		ReadTimeout:  c.timeout,
+		WriteTimeout: c.timeout,
+		TsigSecret:   c.tsigSecret(),
+	}
+	env, err := t.In(m, c.server)
+	if err != nil {
+		return nil, nil, fmt.Errorf("AXFR of %s from %s failed: %s", zone, c.server, err)
+	}
+	var soa *dns.SOA
+	records := []*models.RecordConfig{}
+	for e := range env {
+		if e.Error != nil {
+			return nil, nil, fmt.Errorf("AXFR of %s from %s failed: %s", zone, c.server, e.Error)
+		}
+		for _, rr := range e.RR {
+			switch v := rr.(type) {
+			case *dns.SOA:
+				// The SOA is sent at both ends of the transfer.
+				// The server maintains it, so it never takes part in the diff.
+				soa = v
+				continue
+			case *dns.RRSIG, *dns.NSEC, *dns.NSEC3, *dns.NSEC3PARAM, *dns.DNSKEY, *dns.CDS, *dns.CDNSKEY:
+				// Maintained by the server when the zone is signed inline.
+				continue
+			}
+			if rr.Header().Rrtype == typeSigningState {
+				continue
+			}
+			rc, err := models.RRtoRC(rr, zone)
+			if err != nil {
+				log.Printf("RFC2136: ignoring record in %s: %s: %v", zone, err, rr)
+				continue
+			}
+			records = append(records, rc)
+		}
+	}
+	if soa == nil {
+		return nil, nil, fmt.Errorf("AXFR of %s from %s returned no SOA", zone, c.server)
+	}
+	return records, soa, nil
+}
+
+// GetDomainCorrections returns a list of corrections to update a domain.
+func (c *Provider) GetDomainCorrections(dc *models.DomainConfig) ([]*models.Correction, error) {
+	dc.Punycode()
+	zone, soa, err := c.getZone(dc.Name)
+	if err != nil {
+		return nil, err
+	}
+	// Without default_ns or NAMESERVER(), the apex NS records of the zone
+	// are left alone: the server ignores updates deleting all of them
+	// (RFC 2136 section 3.4.2.4), so they would be deleted on every run.
+	_, manageApexNS := dc.Records.Grouped()[models.RecordKey{Name: "@", Type: "NS"}]
+	existing := []*models.RecordConfig{}
+	for _, rc := range zone {
+		if rc.Type == "NS" && rc.NameFQDN == strings.ToLower(dc.Name) && !manageApexNS {
+			continue
+		}
+		existing = append(existing, rc)
+	}
+	models.PostProcessRecords(existing)
+
+	differ := diff.New(dc)
+	_, create, del, mod := differ.IncrementalDiff(existing)
+
+	// Each change becomes a list of RRs to remove and to insert.
+	type change struct {
+		msg            string
+		remove, insert []dns.RR
+	}
+	changes := []change{}
+	for _, d := range del {
+		changes = append(changes, change{msg: d.String(), remove: []dns.RR{d.Existing.Original.(dns.RR)}})
+	}
+	for _, cr := range create {
+		changes = append(changes, change{msg: cr.String(), insert: []dns.RR{cr.Desired.ToRR()}})
+	}
+	for _, m := range mod {
+		changes = append(changes, change{msg: m.String(), remove: []dns.RR{m.Existing.Original.(dns.RR)}, insert: []dns.RR{m.Desired.ToRR()}})
+	}
+
+	corrections := []*models.Correction{}
+	for start := 0; start < len(changes); start += maxChangesPerUpdate {
+		end := start + maxChangesPerUpdate
+		if end > len(changes) {
+			end = len(changes)
+		}
+		batch := changes[start:end]
+
+		m := new(dns.Msg)
+		m.SetUpdate(dns.Fqdn(dc.Name))
+		if start == 0 {
+			// Refuse to apply the first batch if the zone changed since we read it.
+			prereq := dns.Copy(soa)
+			prereq.Header().Ttl = 0
+			m.Used([]dns.RR{prereq})
+		}
+		msgs := []string{}
+		for _, ch := range batch {
+			msgs = append(msgs, ch.msg)
+			m.Remove(ch.remove)
+			m.Insert(ch.insert)
+		}
+		corrections = append(corrections, &models.Correction{
+			Msg: strings.Join(msgs, "\n"),
+			F:   func() error { return c.update(dc.Name, m) },
+		})
+	}
+	return corrections, nil
+}
+
+func (c *Provider) update(zone string, m *dns.Msg) error {
+	c.sign(m)
+	client := &dns.Client{
+		Net:        "tcp",
+		Timeout:    c.timeout,
+		TsigSecret: c.tsigSecret(),
+	}
+	r, _, err := client.Exchange(m, c.server)
+	if err != nil {
+		return fmt.Errorf("UPDATE of %s on %s failed: %s", zone, c.server, err)
+	}
+	if r.Rcode != dns.RcodeSuccess {
+		return fmt.Errorf("UPDATE of %s on %s failed: %s", zone, c.server, dns.RcodeToString[r.Rcode])
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+package rfc2136
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"github.com/StackExchange/dnscontrol/models"
+)
+
+const (
+	testKey    = "dnscontrol."
+	testSecret = "so6ZGir4GPAqINNh9U5c3A=="
+)
+
+// fakeServer is a minimal primary server answering AXFR and UPDATE for one zone.
+type fakeServer struct {
+	sync.Mutex
+	soa     *dns.SOA
+	records []dns.RR
+	updates int
+}
+
+func (s *fakeServer) ServeDNS(w dns.ResponseWriter, req *dns.Msg) {
+	s.Lock()
+	defer s.Unlock()
+	resp := new(dns.Msg)
+	resp.SetReply(req)
+	switch {
+	case req.IsTsig() == nil || w.TsigStatus() != nil:
+		resp.Rcode = dns.RcodeNotAuth
+	case req.Opcode == dns.OpcodeUpdate:
+		resp.Rcode = s.update(req)
+	case req.Question[0].Qtype == dns.TypeAXFR:
+		resp.Answer = append([]dns.RR{s.soa}, s.records...)
+		resp.Answer = append(resp.Answer, s.soa)
+	default:
+		resp.Rcode = dns.RcodeRefused
+	}
+	resp.SetTsig(testKey, dns.HmacSHA256, 300, time.Now().Unix())
+	w.WriteMsg(resp)
+}
+
+func (s *fakeServer) update(req *dns.Msg) int {
+	for _, rr := range req.Answer {
+		if soa, ok := rr.(*dns.SOA); !ok || soa.Serial != s.soa.Serial {
+			return dns.RcodeNXRrset
+		}
+	}
+	for _, rr := range req.Ns {
+		switch rr.Header().Class {
+		case dns.ClassNONE:
+			for i, old := range s.records {
+				if sameRR(old, rr) {
+					s.records = append(s.records[:i], s.records[i+1:]...)
+					break
+				}
+			}
+		case dns.ClassINET:
+			s.records = append(s.records, rr)
+		}
+	}
+	s.soa.Serial++
+	s.updates++
+	return dns.RcodeSuccess
+}
+
+// sameRR compares two records ignoring their class and TTL.
+func sameRR(a, b dns.RR) bool {
+	a, b = dns.Copy(a), dns.Copy(b)
+	a.Header().Class, b.Header().Class = dns.ClassINET, dns.ClassINET
+	a.Header().Ttl, b.Header().Ttl = 0, 0
+	return a.String() == b.String()
+}
+
+func mustRR(t *testing.T, s string) dns.RR {
+	rr, err := dns.NewRR(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rr
+}
+
+func startServer(t *testing.T, s *fakeServer) (string, func()) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	started := make(chan struct{})
+	srv := &dns.Server{
+		Listener:          l,
+		Handler:           s,
+		TsigSecret:        map[string]string{testKey: testSecret},
+		NotifyStartedFunc: func() { close(started) },
+	}
+	go srv.ActivateAndServe()
+	<-started
+	return l.Addr().String(), func() { srv.Shutdown() }
+}
+
+func TestCorrections(t *testing.T) {
+	s := &fakeServer{
+		soa: mustRR(t, "example.com. 3600 IN SOA ns1.example.com. hostmaster.example.com. 1 3600 600 604800 300").(*dns.SOA),
+		records: []dns.RR{
+			mustRR(t, "example.com. 300 IN NS ns1.example.com."),
+			mustRR(t, "www.example.com. 300 IN A 1.2.3.4"),
+			mustRR(t, "old.example.com. 300 IN A 1.2.3.5"),
+			mustRR(t, "mail.example.com. 300 IN TXT \"hello\""),
+		},
+	}
+	addr, stop := startServer(t, s)
+	defer stop()
+
+	p, err := newProvider(map[string]string{
+		"server":       addr,
+		"tsig_keyname": "dnscontrol",
+		"tsig_secret":  testSecret,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	desired := func() *models.DomainConfig {
+		return &models.DomainConfig{
+			Name: "example.com",
+			Records: []*models.RecordConfig{
+				{Type: "NS", Name: "@", NameFQDN: "example.com", Target: "ns1.example.com.", TTL: 300},
+				{Type: "A", Name: "www", NameFQDN: "www.example.com", Target: "1.2.3.4", TTL: 600},
+				{Type: "A", Name: "new", NameFQDN: "new.example.com", Target: "1.2.3.6", TTL: 300},
+				{Type: "TXT", Name: "mail", NameFQDN: "mail.example.com", Target: "hello", TxtStrings: []string{"hello"}, TTL: 300},
+			},
+		}
+	}
+
+	corrections, err := p.GetDomainCorrections(desired())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(corrections) != 1 {
+		t.Fatalf("expected a single batched correction, got %d", len(corrections))
+	}
+	if err := corrections[0].F(); err != nil {
+		t.Fatal(err)
+	}
+	if s.updates != 1 || len(s.records) != 4 {
+		t.Fatalf("after update: %d updates, records %v", s.updates, s.records)
+	}
+
+	corrections, err = p.GetDomainCorrections(desired())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(corrections) != 0 {
+		t.Fatalf("expected no corrections on second run, got %v", corrections[0].Msg)
+	}
+}
+
+func TestApexNSWithoutNameservers(t *testing.T) {
+	s := &fakeServer{
+		soa: mustRR(t, "example.com. 3600 IN SOA ns1.example.com. hostmaster.example.com. 1 3600 600 604800 300").(*dns.SOA),
+		records: []dns.RR{
+			mustRR(t, "example.com. 300 IN NS ns1.example.com."),
+			mustRR(t, "example.com. 300 IN NS ns2.example.com."),
+			mustRR(t, "www.example.com. 300 IN A 1.2.3.4"),
+		},
+	}
+	addr, stop := startServer(t, s)
+	defer stop()
+
+	p, err := newProvider(map[string]string{"server": addr, "tsig_keyname": testKey, "tsig_secret": testSecret}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dc := &models.DomainConfig{
+		Name: "example.com",
+		Records: []*models.RecordConfig{
+			{Type: "A", Name: "www", NameFQDN: "www.example.com", Target: "1.2.3.4", TTL: 300},
+		},
+	}
+	corrections, err := p.GetDomainCorrections(dc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(corrections) != 0 {
+		t.Fatalf("expected no corrections, got %v", corrections[0].Msg)
+	}
+}
+
+func TestStaleZone(t *testing.T) {
+	s := &fakeServer{
+		soa: mustRR(t, "example.com. 3600 IN SOA ns1.example.com. hostmaster.example.com. 7 3600 600 604800 300").(*dns.SOA),
+	}
+	addr, stop := startServer(t, s)
+	defer stop()
+
+	p, err := newProvider(map[string]string{"server": addr, "tsig_keyname": testKey, "tsig_secret": testSecret}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dc := &models.DomainConfig{
+		Name: "example.com",
+		Records: []*models.RecordConfig{
+			{Type: "A", Name: "www", NameFQDN: "www.example.com", Target: "1.2.3.4", TTL: 300},
+		},
+	}
+	corrections, err := p.GetDomainCorrections(dc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Somebody else changes the zone before the correction runs.
+	s.soa.Serial++
+	if err := corrections[0].F(); err == nil {
+		t.Fatal("expected the update to be refused")
+	}
+}
+
+func TestBadKey(t *testing.T) {
+	addr, stop := startServer(t, &fakeServer{})
+	defer stop()
+	p, err := newProvider(map[string]string{"server": addr, "tsig_keyname": "other", "tsig_secret": testSecret}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.GetDomainCorrections(&models.DomainConfig{Name: "example.com"}); err == nil {
+		t.Fatal("expected an error")
+	}
+}
@@ -0,0 +1,384 @@
+package route53
+
+// Health checks: R53_HEALTH_CHECK.
+//
+// The health checks that dnscontrol creates are tagged with their name and
+// with the id of the hosted zone that declares them. Only those are updated
+// and deleted, so checks made by hand or for other zones are left alone.
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/StackExchange/dnscontrol/models"
+	"github.com/aws/aws-sdk-go/aws"
+	r53 "github.com/aws/aws-sdk-go/service/route53"
+)
+
+const (
+	// metaHealthCheck is the name of the health check of a record, or the
+	// settings of a R53_HEALTH_CHECK.
+	metaHealthCheck = "r53_health_check"
+	tagName         = "Name"
+	tagZone         = "dnscontrol-zone"
+)
+
+// healthCheck is the part of a health check that can be declared.
+type healthCheck struct {
+	Type         string `json:"type"` // HTTP, HTTPS or TCP.
+	FQDN         string `json:"fqdn"`
+	IPAddress    string `json:"ip_address"`
+	Port         int64  `json:"port"`
+	Path         string `json:"path"`
+	SearchString string `json:"search_string"`
+	Interval     int64  `json:"interval"`
+	Threshold    int64  `json:"threshold"`
+}
+
+func (h healthCheck) String() string {
+	target := h.FQDN
+	if h.IPAddress != "" {
+		target = h.IPAddress
+		if h.FQDN != "" {
+			target += " (" + h.FQDN + ")"
+		}
+	}
+	s := fmt.Sprintf("%s %s:%d%s interval=%d threshold=%d", h.Type, target, h.Port, h.Path, h.Interval, h.Threshold)
+	if h.SearchString != "" {
+		s += fmt.Sprintf(" search=%q", h.SearchString)
+	}
+	return s
+}
+
+// parseHealthCheck reads the settings of a R53_HEALTH_CHECK and sets the defaults.
+func parseHealthCheck(name, raw string) (healthCheck, error) {
+	h := healthCheck{}
+	dec := json.NewDecoder(strings.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&h); err != nil {
+		return h, fmt.Errorf("R53_HEALTH_CHECK %s: %s", name, err)
+	}
+	h.Type = strings.ToUpper(h.Type)
+	switch h.Type {
+	case "HTTP", "HTTPS":
+		if h.Path == "" {
+			h.Path = "/"
+		}
+	case "TCP":
+		if h.Path != "" || h.SearchString != "" {
+			return h, fmt.Errorf("R53_HEALTH_CHECK %s: a TCP check has no path or search_string", name)
+		}
+	default:
+		return h, fmt.Errorf("R53_HEALTH_CHECK %s: type must be HTTP, HTTPS or TCP", name)
+	}
+	if h.FQDN == "" && h.IPAddress == "" {
+		return h, fmt.Errorf("R53_HEALTH_CHECK %s: fqdn or ip_address is required", name)
+	}
+	if h.Port == 0 {
+		switch h.Type {
+		case "HTTP":
+			h.Port = 80
+		case "HTTPS":
+			h.Port = 443
+		default:
+			return h, fmt.Errorf("R53_HEALTH_CHECK %s: port is required", name)
+		}
+	}
+	if h.Interval == 0 {
+		h.Interval = 30
+	}
+	if h.Interval != 10 && h.Interval != 30 {
+		return h, fmt.Errorf("R53_HEALTH_CHECK %s: interval must be 10 or 30", name)
+	}
+	if h.Threshold == 0 {
+		h.Threshold = 3
+	}
+	if h.Threshold < 1 || h.Threshold > 10 {
+		return h, fmt.Errorf("R53_HEALTH_CHECK %s: threshold must be between 1 and 10", name)
+	}
+	return h, nil
+}
+
+// apiType is the type of the check for the API, which has separate types
+// for checks with a search string.
+func (h healthCheck) apiType() string {
+	if h.SearchString != "" {
+		return h.Type + "_STR_MATCH"
+	}
+	return h.Type
+}
+
+func (h healthCheck) config() *r53.HealthCheckConfig {
+	c := &r53.HealthCheckConfig{
+		Type:             aws.String(h.apiType()),
+		Port:             aws.Int64(h.Port),
+		RequestInterval:  aws.Int64(h.Interval),
+		FailureThreshold: aws.Int64(h.Threshold),
+	}
+	if h.FQDN != "" {
+		c.FullyQualifiedDomainName = aws.String(h.FQDN)
+	}
+	if h.IPAddress != "" {
+		c.IPAddress = aws.String(h.IPAddress)
+	}
+	if h.Path != "" {
+		c.ResourcePath = aws.String(h.Path)
+	}
+	if h.SearchString != "" {
+		c.SearchString = aws.String(h.SearchString)
+	}
+	return c
+}
+
+func fromConfig(c *r53.HealthCheckConfig) healthCheck {
+	return healthCheck{
+		Type:         strings.TrimSuffix(aws.StringValue(c.Type), "_STR_MATCH"),
+		FQDN:         aws.StringValue(c.FullyQualifiedDomainName),
+		IPAddress:    aws.StringValue(c.IPAddress),
+		Port:         aws.Int64Value(c.Port),
+		Path:         aws.StringValue(c.ResourcePath),
+		SearchString: aws.StringValue(c.SearchString),
+		Interval:     aws.Int64Value(c.RequestInterval),
+		Threshold:    aws.Int64Value(c.FailureThreshold),
+	}
+}
+
+// canUpdate reports whether a check can be changed from h to d in place.
+// The type and the interval of a check are fixed, and its IP address can't
+// be removed.
+func (h healthCheck) canUpdate(d healthCheck) bool {
+	return h.apiType() == d.apiType() && h.Interval == d.Interval && (h.IPAddress == "" || d.IPAddress != "")
+}
+
+// managedCheck is a health check created by dnscontrol.
+type managedCheck struct {
+	id, name, zoneID string
+	check            healthCheck
+}
+
+// getHealthChecks returns the health checks created by dnscontrol.
+func (r *route53Provider) getHealthChecks() ([]*managedCheck, error) {
+	if r.healthChecks != nil {
+		return r.healthChecks, nil
+	}
+	all := map[string]*r53.HealthCheck{}
+	ids := []*string{}
+	var marker *string
+	for {
+		out, err := r.client.ListHealthChecks(&r53.ListHealthChecksInput{Marker: marker})
+		if err != nil {
+			return nil, err
+		}
+		for _, hc := range out.HealthChecks {
+			all[*hc.Id] = hc
+			ids = append(ids, hc.Id)
+		}
+		if !aws.BoolValue(out.IsTruncated) {
+			break
+		}
+		marker = out.NextMarker
+	}
+	checks := []*managedCheck{}
+	// Tags are listed for at most 10 resources at a time.
+	for len(ids) > 0 {
+		n := len(ids)
+		if n > 10 {
+			n = 10
+		}
+		out, err := r.client.ListTagsForResources(&r53.ListTagsForResourcesInput{
+			ResourceIds:  ids[:n],
+			ResourceType: aws.String(r53.TagResourceTypeHealthcheck),
+		})
+		if err != nil {
+			return nil, err
+		}
+		ids = ids[n:]
+		for _, set := range out.ResourceTagSets {
+			m := &managedCheck{id: aws.StringValue(set.ResourceId)}
+			for _, t := range set.Tags {
+				switch aws.StringValue(t.Key) {
+				case tagName:
+					m.name = aws.StringValue(t.Value)
+				case tagZone:
+					m.zoneID = aws.StringValue(t.Value)
+				}
+			}
+			if hc := all[m.id]; hc != nil && m.zoneID != "" && m.name != "" {
+				m.check = fromConfig(hc.HealthCheckConfig)
+				checks = append(checks, m)
+			}
+		}
+	}
+	r.healthChecks = checks
+	return checks, nil
+}
+
+// desiredHealthChecks removes the R53_HEALTH_CHECK records from dc and
+// returns them by name.
+func desiredHealthChecks(dc *models.DomainConfig) (map[string]healthCheck, error) {
+	checks := map[string]healthCheck{}
+	records := []*models.RecordConfig{}
+	for _, rec := range dc.Records {
+		if rec.Type != "R53_HEALTH_CHECK" {
+			records = append(records, rec)
+			continue
+		}
+		if _, ok := checks[rec.Target]; ok {
+			return nil, fmt.Errorf("R53_HEALTH_CHECK %s is declared twice", rec.Target)
+		}
+		h, err := parseHealthCheck(rec.Target, rec.Metadata[metaHealthCheck])
+		if err != nil {
+			return nil, err
+		}
+		checks[rec.Target] = h
+	}
+	dc.Records = records
+	for _, rec := range records {
+		if name := rec.Metadata[metaHealthCheck]; name != "" {
+			if _, ok := checks[name]; !ok {
+				return nil, fmt.Errorf("%s record %s uses the undeclared health check %s", rec.Type, rec.NameFQDN, name)
+			}
+		}
+	}
+	return checks, nil
+}
+
+// healthCheckChanges holds the corrections for the health checks of a
+// zone. The checks are created and updated before the records change, and
+// deleted after.
+type healthCheckChanges struct {
+	before, after []*models.Correction
+	// ids holds the id of each declared check, by name. The ids of the checks
+	// that are created are only set when their correction runs, so the
+	// records point to these strings.
+	ids map[string]*string
+	// names holds the name of the existing checks that are kept, by id.
+	names map[string]string
+}
+
+func (r *route53Provider) healthCheckCorrections(zoneID string, desired map[string]healthCheck) (*healthCheckChanges, error) {
+	existing, err := r.getHealthChecks()
+	if err != nil {
+		return nil, err
+	}
+	changes := &healthCheckChanges{ids: map[string]*string{}, names: map[string]string{}}
+	byName := map[string]*managedCheck{}
+	for _, m := range existing {
+		if m.zoneID != zoneID {
+			continue
+		}
+		if byName[m.name] != nil {
+			// A duplicate, from an interrupted run for example.
+			changes.after = append(changes.after, r.deleteHealthCheck(m))
+			continue
+		}
+		byName[m.name] = m
+	}
+	names := make([]string, 0, len(desired))
+	for name := range desired {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		d, m := desired[name], byName[name]
+		delete(byName, name)
+		switch {
+		case m == nil:
+			id := new(string)
+			changes.ids[name] = id
+			changes.before = append(changes.before, r.createHealthCheck(zoneID, name, d, id))
+		case m.check == d:
+			changes.ids[name] = aws.String(m.id)
+			changes.names[m.id] = name
+		case m.check.canUpdate(d):
+			changes.ids[name] = aws.String(m.id)
+			changes.names[m.id] = name
+			changes.before = append(changes.before, r.updateHealthCheck(m, d))
+		default:
+			id := new(string)
+			changes.ids[name] = id
+			changes.before = append(changes.before, r.createHealthCheck(zoneID, name, d, id))
+			changes.after = append(changes.after, r.deleteHealthCheck(m))
+		}
+	}
+	gone := make([]string, 0, len(byName))
+	for name := range byName {
+		gone = append(gone, name)
+	}
+	sort.Strings(gone)
+	for _, name := range gone {
+		changes.after = append(changes.after, r.deleteHealthCheck(byName[name]))
+	}
+	return changes, nil
+}
+
+func (r *route53Provider) createHealthCheck(zoneID, name string, h healthCheck, id *string) *models.Correction {
+	return &models.Correction{
+		Msg: fmt.Sprintf("CREATE health check %s: %s", name, h),
+		F: func() error {
+			out, err := r.client.CreateHealthCheck(&r53.CreateHealthCheckInput{
+				CallerReference:   aws.String(fmt.Sprintf("dnscontrol-%s-%d", name, time.Now().UnixNano())),
+				HealthCheckConfig: h.config(),
+			})
+			if err != nil {
+				return err
+			}
+			*id = *out.HealthCheck.Id
+			_, err = r.client.ChangeTagsForResource(&r53.ChangeTagsForResourceInput{
+				ResourceId:   id,
+				ResourceType: aws.String(r53.TagResourceTypeHealthcheck),
+				AddTags: []*r53.Tag{
+					{Key: aws.String(tagName), Value: aws.String(name)},
+					{Key: aws.String(tagZone), Value: aws.String(zoneID)},
+				},
+			})
+			return err
+		},
+		Undo: func() error {
+			_, err := r.client.DeleteHealthCheck(&r53.DeleteHealthCheckInput{HealthCheckId: id})
+			return err
+		},
+	}
+}
+
+func (r *route53Provider) updateHealthCheck(m *managedCheck, d healthCheck) *models.Correction {
+	update := func(h healthCheck) error {
+		c := h.config()
+		in := &r53.UpdateHealthCheckInput{
+			HealthCheckId:            aws.String(m.id),
+			FullyQualifiedDomainName: c.FullyQualifiedDomainName,
+			IPAddress:                c.IPAddress,
+			Port:                     c.Port,
+			ResourcePath:             c.ResourcePath,
+			SearchString:             c.SearchString,
+			FailureThreshold:         c.FailureThreshold,
+		}
+		if h.FQDN == "" {
+			in.ResetElements = append(in.ResetElements, aws.String(r53.ResettableElementNameFullyQualifiedDomainName))
+		}
+		if h.Path == "" {
+			in.ResetElements = append(in.ResetElements, aws.String(r53.ResettableElementNameResourcePath))
+		}
+		_, err := r.client.UpdateHealthCheck(in)
+		return err
+	}
+	return &models.Correction{
+		Msg:  fmt.Sprintf("MODIFY health check %s: %s -> %s", m.name, m.check, d),
+		F:    func() error { return update(d) },
+		Undo: func() error { return update(m.check) },
+	}
+}
+
+// deleteHealthCheck can't be undone: a new check would have another id.
+func (r *route53Provider) deleteHealthCheck(m *managedCheck) *models.Correction {
+	return &models.Correction{
+		Msg: fmt.Sprintf("DELETE health check %s: %s (id=%s)", m.name, m.check, m.id),
+		F: func() error {
+			_, err := r.client.DeleteHealthCheck(&r53.DeleteHealthCheckInput{HealthCheckId: aws.String(m.id)})
+			return err
+		},
+	}
+}
@@ -0,0 +1,100 @@
+package route53
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/StackExchange/dnscontrol/models"
+)
+
+func TestParseHealthCheck(t *testing.T) {
+	h, err := parseHealthCheck("web", `{"type":"https","fqdn":"www.example.com"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := healthCheck{Type: "HTTPS", FQDN: "www.example.com", Port: 443, Path: "/", Interval: 30, Threshold: 3}
+	if h != expected {
+		t.Errorf("expected %v, got %v", expected, h)
+	}
+	if found := fromConfig(h.config()); found != h {
+		t.Errorf("expected %v, got %v", h, found)
+	}
+	for _, raw := range []string{
+		`{"type":"UDP","fqdn":"www.example.com"}`,
+		`{"type":"HTTP"}`,
+		`{"type":"TCP","ip_address":"192.0.2.1"}`,
+		`{"type":"TCP","ip_address":"192.0.2.1","port":22,"path":"/"}`,
+		`{"type":"HTTP","fqdn":"www.example.com","interval":20}`,
+		`{"type":"HTTP","fqdn":"www.example.com","threshold":11}`,
+		`{"type":"HTTP","fqdn":"www.example.com","timeout":5}`,
+	} {
+		if _, err := parseHealthCheck("web", raw); err == nil {
+			t.Errorf("%s: expected an error", raw)
+		}
+	}
+}
+
+func TestHealthCheckCorrections(t *testing.T) {
+	check := func(raw string) healthCheck {
+		h, err := parseHealthCheck("test", raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+	r := &route53Provider{healthChecks: []*managedCheck{
+		{id: "1", name: "same", zoneID: "Z1", check: check(`{"type":"HTTP","fqdn":"a.example.com"}`)},
+		{id: "2", name: "path", zoneID: "Z1", check: check(`{"type":"HTTP","fqdn":"b.example.com"}`)},
+		{id: "3", name: "interval", zoneID: "Z1", check: check(`{"type":"HTTP","fqdn":"c.example.com"}`)},
+		{id: "4", name: "gone", zoneID: "Z1", check: check(`{"type":"TCP","fqdn":"d.example.com","port":22}`)},
+		{id: "5", name: "gone", zoneID: "Z2", check: check(`{"type":"TCP","fqdn":"d.example.com","port":22}`)},
+	}}
+	changes, err := r.healthCheckCorrections("Z1", map[string]healthCheck{
+		"same":     check(`{"type":"HTTP","fqdn":"a.example.com"}`),
+		"path":     check(`{"type":"HTTP","fqdn":"b.example.com","path":"/health"}`),
+		"interval": check(`{"type":"HTTP","fqdn":"c.example.com","interval":10}`),
+		"new":      check(`{"type":"HTTPS","ip_address":"192.0.2.1"}`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgs := func(corrections []*models.Correction) string {
+		var m []string
+		for _, c := range corrections {
+			m = append(m, strings.SplitN(c.Msg, ":", 2)[0])
+		}
+		return strings.Join(m, ", ")
+	}
+	// A new interval needs a new check, which replaces the old one after
+	// the records use it.
+	if found := msgs(changes.before); found != "CREATE health check interval, CREATE health check new, MODIFY health check path" {
+		t.Errorf("unexpected corrections before the records: %s", found)
+	}
+	if found := msgs(changes.after); found != "DELETE health check interval, DELETE health check gone" {
+		t.Errorf("unexpected corrections after the records: %s", found)
+	}
+	if *changes.ids["same"] != "1" || *changes.ids["path"] != "2" || *changes.ids["interval"] != "" {
+		t.Errorf("unexpected ids %v", changes.ids)
+	}
+	if changes.names["1"] != "same" || changes.names["3"] != "" {
+		t.Errorf("unexpected names %v", changes.names)
+	}
+}
+
+func TestDesiredHealthChecks(t *testing.T) {
+	dc := &models.DomainConfig{Name: "example.com", Records: []*models.RecordConfig{
+		{Type: "R53_HEALTH_CHECK", Target: "web", Metadata: map[string]string{metaHealthCheck: `{"type":"HTTP","fqdn":"www.example.com"}`}},
+		{Type: "A", NameFQDN: "www.example.com", Target: "192.0.2.1", Metadata: map[string]string{metaHealthCheck: "web"}},
+	}}
+	checks, err := desiredHealthChecks(dc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(checks) != 1 || len(dc.Records) != 1 {
+		t.Errorf("expected the health check to be removed from the records: %v %v", checks, dc.Records)
+	}
+	dc.Records[0].Metadata[metaHealthCheck] = "api"
+	if _, err := desiredHealthChecks(dc); err == nil {
+		t.Error("expected an error for an undeclared health check")
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"sort"
 	"strings"
-	"time"
 
 	"github.com/StackExchange/dnscontrol/models"
 	"github.com/StackExchange/dnscontrol/providers"
@@ -18,10 +17,30 @@ import (
 	"github.com/pkg/errors"
 )
 
+// route53Client is the part of the route 53 API used by the provider.
+type route53Client interface {
+	AssociateVPCWithHostedZone(*r53.AssociateVPCWithHostedZoneInput) (*r53.AssociateVPCWithHostedZoneOutput, error)
+	ChangeResourceRecordSets(*r53.ChangeResourceRecordSetsInput) (*r53.ChangeResourceRecordSetsOutput, error)
+	ChangeTagsForResource(*r53.ChangeTagsForResourceInput) (*r53.ChangeTagsForResourceOutput, error)
+	CreateHealthCheck(*r53.CreateHealthCheckInput) (*r53.CreateHealthCheckOutput, error)
+	CreateHostedZone(*r53.CreateHostedZoneInput) (*r53.CreateHostedZoneOutput, error)
+	DeleteHealthCheck(*r53.DeleteHealthCheckInput) (*r53.DeleteHealthCheckOutput, error)
+	GetHostedZone(*r53.GetHostedZoneInput) (*r53.GetHostedZoneOutput, error)
+	ListHealthChecks(*r53.ListHealthChecksInput) (*r53.ListHealthChecksOutput, error)
+	ListHostedZones(*r53.ListHostedZonesInput) (*r53.ListHostedZonesOutput, error)
+	ListResourceRecordSets(*r53.ListResourceRecordSetsInput) (*r53.ListResourceRecordSetsOutput, error)
+	ListTagsForResources(*r53.ListTagsForResourcesInput) (*r53.ListTagsForResourcesOutput, error)
+	UpdateHealthCheck(*r53.UpdateHealthCheckInput) (*r53.UpdateHealthCheckOutput, error)
+}
+
 type route53Provider struct {
-	client    *r53.Route53
+	client    route53Client
 	registrar *r53d.Route53Domains
-	zones     map[string]*r53.HostedZone
+	zones     map[string][]*r53.HostedZone
+	zoneType  string // "public", "private" or "" for any.
+	vpcs      []vpc
+	// The health checks created by dnscontrol, listed on first use.
+	healthChecks []*managedCheck
 }
 
 func newRoute53Reg(conf map[string]string) (providers.Registrar, error) {
@@ -38,8 +57,14 @@ func newRoute53(m map[string]string, metadata json.RawMessage) (*route53Provider
 	// Route53 uses a global endpoint and route53domains
 	// currently only has a single regional endpoint in us-east-1
 	// http://docs.aws.amazon.com/general/latest/gr/rande.html#r53_region
+	// The AWS SDK retries throttled requests itself.
+	opts, err := providers.HTTPOptionsFromCreds(m, providers.HTTPOptions{Timeout: providers.DefaultHTTPOptions.Timeout})
+	if err != nil {
+		return nil, err
+	}
 	config := &aws.Config{
-		Region: aws.String("us-east-1"),
+		Region:     aws.String("us-east-1"),
+		HTTPClient: opts.NewClient("ROUTE53"),
 	}
 
 	if keyID != "" || secretKey != "" {
@@ -48,7 +73,20 @@ func newRoute53(m map[string]string, metadata json.RawMessage) (*route53Provider
 	sess := session.New(config)
 
 	api := &route53Provider{client: r53.New(sess), registrar: r53d.New(sess)}
-	err := api.getZones()
+	if len(metadata) > 0 {
+		meta := &struct {
+			ZoneType string `json:"zone_type"`
+			VPCs     []vpc  `json:"vpcs"`
+		}{}
+		if err := json.Unmarshal(metadata, meta); err != nil {
+			return nil, err
+		}
+		if err := checkZoneType(meta.ZoneType); err != nil {
+			return nil, err
+		}
+		api.zoneType, api.vpcs = meta.ZoneType, meta.VPCs
+	}
+	err = api.getZones()
 	if err != nil {
 		return nil, err
 	}
@@ -69,6 +107,7 @@ var features = providers.DocumentationNotes{
 func init() {
 	providers.RegisterDomainServiceProviderType("ROUTE53", newRoute53Dsp, features)
 	providers.RegisterRegistrarType("ROUTE53", newRoute53Reg)
+	providers.RegisterCustomRecordType("R53_HEALTH_CHECK", "ROUTE53", "")
 }
 
 func sPtr(s string) *string {
@@ -77,7 +116,7 @@ func sPtr(s string) *string {
 
 func (r *route53Provider) getZones() error {
 	var nextMarker *string
-	r.zones = make(map[string]*r53.HostedZone)
+	r.zones = make(map[string][]*r53.HostedZone)
 	for {
 		if nextMarker != nil {
 			fmt.Println(*nextMarker)
@@ -91,7 +130,7 @@ func (r *route53Provider) getZones() error {
 		}
 		for _, z := range out.HostedZones {
 			domain := strings.TrimSuffix(*z.Name, ".")
-			r.zones[domain] = z
+			r.zones[domain] = append(r.zones[domain], z)
 		}
 		if out.NextMarker != nil {
 			nextMarker = out.NextMarker
@@ -104,11 +143,11 @@ func (r *route53Provider) getZones() error {
 
 // map key for grouping records
 type key struct {
-	Name, Type string
+	Name, Type, SetID string
 }
 
 func getKey(r *models.RecordConfig) key {
-	return key{r.NameFQDN, r.Type}
+	return key{r.NameFQDN, r.Type, r.Metadata[metaSetIdentifier]}
 }
 
 type errNoExist struct {
@@ -120,10 +159,20 @@ func (e errNoExist) Error() string {
 }
 
 func (r *route53Provider) GetNameservers(domain string) ([]*models.Nameserver, error) {
+	return r.GetDomainConfigNameservers(&models.DomainConfig{Name: domain})
+}
 
-	zone, ok := r.zones[domain]
-	if !ok {
-		return nil, errNoExist{domain}
+// GetDomainConfigNameservers returns the nameservers of the zone of dc, chosen
+// as in zoneFor.
+func (r *route53Provider) GetDomainConfigNameservers(dc *models.DomainConfig) ([]*models.Nameserver, error) {
+	zoneType, id := r.zoneTypeFor(dc), dc.Metadata[metaZoneID]
+	if zoneType == "" && id == "" && len(r.zones[dc.Name]) > 1 {
+		// Only public zones are delegated.
+		zoneType = "public"
+	}
+	zone, err := r.findZone(dc.Name, zoneType, id)
+	if err != nil {
+		return nil, err
 	}
 	z, err := r.client.GetHostedZone(&r53.GetHostedZoneInput{Id: zone.Id})
 	if err != nil {
@@ -139,13 +188,29 @@ func (r *route53Provider) GetNameservers(domain string) ([]*models.Nameserver, e
 }
 
 func (r *route53Provider) GetDomainCorrections(dc *models.DomainConfig) ([]*models.Correction, error) {
+	// The R53_HEALTH_CHECK records aren't DNS records: remove them first.
+	checks, err := desiredHealthChecks(dc)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkRouting(dc.Records); err != nil {
+		return nil, err
+	}
 	dc.Punycode()
 
 	var corrections = []*models.Correction{}
-	zone, ok := r.zones[dc.Name]
-	// add zone if it doesn't exist
-	if !ok {
-		return nil, errNoExist{dc.Name}
+	zone, err := r.zoneFor(dc)
+	if err != nil {
+		return nil, err
+	}
+	hc, err := r.healthCheckCorrections(trimZoneID(*zone.Id), checks)
+	if err != nil {
+		if len(checks) > 0 {
+			return nil, err
+		}
+		// Accounts that can't list health checks work as long as they
+		// don't declare any.
+		hc = &healthCheckChanges{}
 	}
 
 	records, err := r.fetchRecordSets(zone.Id)
@@ -166,6 +231,14 @@ func (r *route53Provider) GetDomainCorrections(dc *models.DomainConfig) ([]*mode
 				TTL:            uint32(*set.TTL),
 				CombinedTarget: true,
 			}
+			if set.HealthCheckId != nil {
+				name := hc.names[*set.HealthCheckId]
+				if name == "" {
+					name = *set.HealthCheckId
+				}
+				r.Metadata = map[string]string{metaHealthCheck: name}
+			}
+			setRoutingMetadata(r, set)
 			existingRecords = append(existingRecords, r)
 		}
 	}
@@ -177,7 +250,7 @@ func (r *route53Provider) GetDomainCorrections(dc *models.DomainConfig) ([]*mode
 	models.PostProcessRecords(existingRecords)
 
 	// diff
-	differ := diff.New(dc)
+	differ := diff.New(dc, getRecordMetadata)
 	_, create, delete, modify := differ.IncrementalDiff(existingRecords)
 
 	namesToUpdate := map[key][]string{}
@@ -189,10 +262,14 @@ func (r *route53Provider) GetDomainCorrections(dc *models.DomainConfig) ([]*mode
 	}
 	for _, m := range modify {
 		namesToUpdate[getKey(m.Desired)] = append(namesToUpdate[getKey(m.Desired)], m.String())
+		if k := getKey(m.Existing); k != getKey(m.Desired) {
+			// The record moves to another record set.
+			namesToUpdate[k] = append(namesToUpdate[k], m.String())
+		}
 	}
 
 	if len(namesToUpdate) == 0 {
-		return nil, nil
+		return append(hc.before, hc.after...), nil
 	}
 
 	updates := map[key][]*models.RecordConfig{}
@@ -219,7 +296,7 @@ func (r *route53Provider) GetDomainCorrections(dc *models.DomainConfig) ([]*mode
 			delDesc += strings.Join(namesToUpdate[k], "\n") + "\n"
 			// on delete just submit the original resource set we got from r53.
 			for _, r := range records {
-				if *r.Name == k.Name+"." && *r.Type == k.Type {
+				if *r.Name == k.Name+"." && *r.Type == k.Type && aws.StringValue(r.SetIdentifier) == k.SetID {
 					rrset = r
 					break
 				}
@@ -234,7 +311,11 @@ func (r *route53Provider) GetDomainCorrections(dc *models.DomainConfig) ([]*mode
 				Type:            sPtr(k.Type),
 				ResourceRecords: []*r53.ResourceRecord{},
 			}
+			check := recs[0].Metadata[metaHealthCheck]
 			for _, r := range recs {
+				if r.Metadata[metaHealthCheck] != check {
+					return nil, fmt.Errorf("the %s records of %s must all use the same health check", k.Type, k.Name)
+				}
 				val := r.Target
 				rr := &r53.ResourceRecord{
 					Value: &val,
@@ -243,6 +324,12 @@ func (r *route53Provider) GetDomainCorrections(dc *models.DomainConfig) ([]*mode
 				i := int64(r.TTL)
 				rrset.TTL = &i // TODO: make sure that ttls are consistent within a set
 			}
+			if check != "" {
+				rrset.HealthCheckId = hc.ids[check]
+			}
+			// checkRouting has already validated the routing of the records.
+			p, _ := recordRouting(recs[0])
+			p.apply(rrset)
 		}
 		chg.ResourceRecordSet = rrset
 	}
@@ -264,6 +351,7 @@ func (r *route53Provider) GetDomainCorrections(dc *models.DomainConfig) ([]*mode
 					_, err := r.client.ChangeResourceRecordSets(req)
 					return err
 				},
+				Change: req,
 			})
 	}
 
@@ -275,10 +363,112 @@ func (r *route53Provider) GetDomainCorrections(dc *models.DomainConfig) ([]*mode
 		addCorrection(changeDesc, changeReq)
 	}
 
-	return corrections, nil
+	corrections = append(hc.before, corrections...)
+	return append(corrections, hc.after...), nil
 
 }
 
+// Limits of a ChangeResourceRecordSets request. The records and the values
+// of UPSERT changes count twice.
+const (
+	maxBatchRecords    = 1000
+	maxBatchValueChars = 32000
+)
+
+// splitChanges splits changes, in order, into batches within the limits of a
+// request.
+func splitChanges(changes []*r53.Change) [][]*r53.Change {
+	batches := [][]*r53.Change{}
+	var batch []*r53.Change
+	records, chars := 0, 0
+	for _, c := range changes {
+		n, l := 0, 0
+		if c.ResourceRecordSet != nil {
+			for _, rr := range c.ResourceRecordSet.ResourceRecords {
+				n++
+				l += len(aws.StringValue(rr.Value))
+			}
+		}
+		if aws.StringValue(c.Action) == r53.ChangeActionUpsert {
+			n, l = 2*n, 2*l
+		}
+		if len(batch) > 0 && (records+n > maxBatchRecords || chars+l > maxBatchValueChars) {
+			batches = append(batches, batch)
+			batch, records, chars = nil, 0, 0
+		}
+		batch = append(batch, c)
+		records += n
+		chars += l
+	}
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+	return batches
+}
+
+// undoCorrections undoes the corrections that ran, newest first, after err.
+func undoCorrections(ran []*models.Correction, err error) error {
+	for i := len(ran) - 1; i >= 0; i-- {
+		if ran[i].Undo == nil {
+			continue
+		}
+		if uerr := ran[i].Undo(); uerr != nil {
+			return fmt.Errorf("%s (undoing %q failed: %s)", err, ran[i].Msg, uerr)
+		}
+	}
+	return err
+}
+
+// ApplyCorrections sends the changes of all the corrections in one batch,
+// which Route 53 applies atomically, or in several batches when they exceed
+// the limits of a request. The health check corrections run before or after
+// the batches, as they come before or after the record changes. Those that
+// ran before are undone if the first batch fails.
+func (r *route53Provider) ApplyCorrections(dc *models.DomainConfig, corrections []*models.Correction) error {
+	zone, err := r.zoneFor(dc)
+	if err != nil {
+		return err
+	}
+	changes := []*r53.Change{}
+	before := []*models.Correction{}
+	after := []*models.Correction{}
+	for _, c := range corrections {
+		req, ok := c.Change.(*r53.ChangeResourceRecordSetsInput)
+		if !ok {
+			if len(changes) > 0 {
+				after = append(after, c)
+				continue
+			}
+			if err := c.F(); err != nil {
+				return undoCorrections(before, err)
+			}
+			before = append(before, c)
+			continue
+		}
+		changes = append(changes, req.ChangeBatch.Changes...)
+	}
+	batches := splitChanges(changes)
+	for i, batch := range batches {
+		_, err = r.client.ChangeResourceRecordSets(&r53.ChangeResourceRecordSetsInput{
+			HostedZoneId: zone.Id,
+			ChangeBatch:  &r53.ChangeBatch{Changes: batch},
+		})
+		if err != nil && i == 0 {
+			return undoCorrections(before, err)
+		}
+		if err != nil {
+			// The records of the applied batches may use the new health checks.
+			return fmt.Errorf("%d of %d batches of changes were applied: %s", i, len(batches), err)
+		}
+	}
+	for _, c := range after {
+		if err := c.F(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (r *route53Provider) GetRegistrarCorrections(dc *models.DomainConfig) ([]*models.Correction, error) {
 	corrections := []*models.Correction{}
 	actualSet, err := r.getRegistrarNameservers(&dc.Name)
@@ -324,6 +514,29 @@ func (r *route53Provider) getRegistrarNameservers(domainName *string) ([]string,
 	return nameservers, nil
 }
 
+// GetRegistrarStatus returns the expiry date, transfer lock and delegation of a domain registered with route53.
+func (r *route53Provider) GetRegistrarStatus(domain string) (*models.RegistrarStatus, error) {
+	domainDetail, err := r.registrar.GetDomainDetail(&r53d.GetDomainDetailInput{DomainName: &domain})
+	if err != nil {
+		return nil, err
+	}
+	status := &models.RegistrarStatus{Domain: domain, Nameservers: []string{}}
+	if domainDetail.ExpirationDate != nil {
+		status.SetExpiry(*domainDetail.ExpirationDate)
+	}
+	locked := false
+	for _, st := range domainDetail.StatusList {
+		if st != nil && strings.HasPrefix(*st, "clientTransferProhibited") {
+			locked = true
+		}
+	}
+	status.SetLocked(locked)
+	for _, ns := range domainDetail.Nameservers {
+		status.Nameservers = append(status.Nameservers, *ns.Name)
+	}
+	return status, nil
+}
+
 func (r *route53Provider) updateRegistrarNameservers(domainName string, nameservers []string) (*string, error) {
 	servers := []*r53d.Nameserver{}
 	for i := range nameservers {
@@ -376,17 +589,3 @@ func unescape(s *string) string {
 	name = strings.Replace(name, `\052`, "*", -1) // TODO: escape all octal sequences
 	return name
 }
-
-func (r *route53Provider) EnsureDomainExists(domain string) error {
-	if _, ok := r.zones[domain]; ok {
-		return nil
-	}
-	fmt.Printf("Adding zone for %s to route 53 account\n", domain)
-	in := &r53.CreateHostedZoneInput{
-		Name:            &domain,
-		CallerReference: sPtr(fmt.Sprint(time.Now().UnixNano())),
-	}
-	_, err := r.client.CreateHostedZone(in)
-	return err
-
-}
@@ -1,6 +1,14 @@
 package route53
 
-import "testing"
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/StackExchange/dnscontrol/models"
+	"github.com/aws/aws-sdk-go/aws"
+	r53 "github.com/aws/aws-sdk-go/service/route53"
+)
 
 func TestUnescape(t *testing.T) {
 	var tests = []struct {
@@ -22,3 +30,146 @@ func TestUnescape(t *testing.T) {
 		}
 	}
 }
+
+// applyTestCorrections gets and applies the corrections of a zone that
+// changes its health checks and records, with the nth batch failing.
+func applyTestCorrections(t *testing.T, failBatch int) (*fakeClient, error) {
+	check := func(raw string) healthCheck {
+		h, err := parseHealthCheck("test", raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+	f := &fakeClient{sets: []*r53.ResourceRecordSet{
+		{
+			Name: aws.String("www.corp.example.com."), Type: aws.String("A"), TTL: aws.Int64(300),
+			ResourceRecords: []*r53.ResourceRecord{{Value: aws.String("192.0.2.1")}},
+		},
+		{
+			Name: aws.String("api.corp.example.com."), Type: aws.String("A"), TTL: aws.Int64(300),
+			ResourceRecords: []*r53.ResourceRecord{{Value: aws.String("192.0.2.3")}},
+			SetIdentifier:   aws.String("api"), Failover: aws.String("PRIMARY"), HealthCheckId: aws.String("API"),
+		},
+		{
+			Name: aws.String("api.corp.example.com."), Type: aws.String("A"), TTL: aws.Int64(300),
+			ResourceRecords: []*r53.ResourceRecord{{Value: aws.String("192.0.2.4")}},
+			SetIdentifier:   aws.String("old"), Failover: aws.String("SECONDARY"), HealthCheckId: aws.String("OLD"),
+		},
+	}, failBatch: failBatch}
+	r := &route53Provider{
+		client: f,
+		zones:  map[string][]*r53.HostedZone{"corp.example.com": {testZone("PUBLIC", false)}},
+		healthChecks: []*managedCheck{
+			{id: "API", name: "api", zoneID: "PUBLIC", check: check(`{"type":"HTTP","fqdn":"api.corp.example.com"}`)},
+			{id: "OLD", name: "old", zoneID: "PUBLIC", check: check(`{"type":"TCP","fqdn":"api.corp.example.com","port":22}`)},
+		},
+	}
+	rec := func(name, target string, meta map[string]string) *models.RecordConfig {
+		return &models.RecordConfig{Name: name, NameFQDN: name + ".corp.example.com", Type: "A", Target: target, TTL: 300, Metadata: meta}
+	}
+	dc := &models.DomainConfig{Name: "corp.example.com", Records: []*models.RecordConfig{
+		{Type: "R53_HEALTH_CHECK", Target: "api", Metadata: map[string]string{metaHealthCheck: `{"type":"HTTP","fqdn":"api.corp.example.com","path":"/health"}`}},
+		{Type: "R53_HEALTH_CHECK", Target: "www", Metadata: map[string]string{metaHealthCheck: `{"type":"HTTPS","fqdn":"www.corp.example.com"}`}},
+		rec("api", "192.0.2.3", map[string]string{metaHealthCheck: "api", metaSetIdentifier: "api", metaFailover: "PRIMARY"}),
+		rec("www", "192.0.2.1", map[string]string{metaHealthCheck: "www", metaSetIdentifier: "primary", metaFailover: "PRIMARY"}),
+		rec("www", "192.0.2.2", map[string]string{metaSetIdentifier: "secondary", metaFailover: "SECONDARY"}),
+	}}
+
+	corrections, err := r.GetDomainCorrections(dc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f, r.ApplyCorrections(dc, corrections)
+}
+
+func TestApplyCorrections(t *testing.T) {
+	f, err := applyTestCorrections(t, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The checks are created and updated before the records use them, and
+	// deleted after the records stop using them.
+	expected := []string{
+		"UpdateHealthCheck API",
+		"CreateHealthCheck",
+		"ChangeTagsForResource NEW",
+		"ChangeResourceRecordSets",
+		"DeleteHealthCheck OLD",
+	}
+	if !reflect.DeepEqual(f.calls, expected) {
+		t.Fatalf("expected calls %v, got %v", expected, f.calls)
+	}
+
+	found := map[string]string{}
+	for _, c := range f.batches[0].Changes {
+		set := c.ResourceRecordSet
+		found[*c.Action+" "+*set.Name+" "+aws.StringValue(set.SetIdentifier)] = aws.StringValue(set.Failover) + " " + aws.StringValue(set.HealthCheckId)
+	}
+	expectedChanges := map[string]string{
+		"DELETE api.corp.example.com. old":      "SECONDARY OLD",
+		"DELETE www.corp.example.com. ":         " ",
+		"UPSERT www.corp.example.com primary":   "PRIMARY NEW",
+		"UPSERT www.corp.example.com secondary": "SECONDARY ",
+	}
+	if !reflect.DeepEqual(found, expectedChanges) {
+		t.Errorf("expected changes %v, got %v", expectedChanges, found)
+	}
+}
+
+func TestApplyCorrectionsUndo(t *testing.T) {
+	f, err := applyTestCorrections(t, 1)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	// The checks created and updated for the batch are put back as they were.
+	expected := []string{
+		"UpdateHealthCheck API",
+		"CreateHealthCheck",
+		"ChangeTagsForResource NEW",
+		"ChangeResourceRecordSets",
+		"DeleteHealthCheck NEW",
+		"UpdateHealthCheck API",
+	}
+	if !reflect.DeepEqual(f.calls, expected) {
+		t.Fatalf("expected calls %v, got %v", expected, f.calls)
+	}
+}
+
+func TestSplitChanges(t *testing.T) {
+	change := func(action string, values ...string) *r53.Change {
+		set := &r53.ResourceRecordSet{}
+		for _, v := range values {
+			set.ResourceRecords = append(set.ResourceRecords, &r53.ResourceRecord{Value: aws.String(v)})
+		}
+		return &r53.Change{Action: aws.String(action), ResourceRecordSet: set}
+	}
+	sizes := func(changes []*r53.Change) []int {
+		found := []int{}
+		for _, b := range splitChanges(changes) {
+			found = append(found, len(b))
+		}
+		return found
+	}
+
+	changes := []*r53.Change{}
+	for i := 0; i < 600; i++ {
+		changes = append(changes, change("UPSERT", "192.0.2.1"))
+	}
+	// An UPSERT counts twice, so 500 of them make 1000 records.
+	if found := sizes(changes); !reflect.DeepEqual(found, []int{500, 100}) {
+		t.Errorf("expected batches of 500 and 100 changes, got %v", found)
+	}
+
+	// The values of an UPSERT count twice too: 30000 characters, then 10000 + 20000.
+	long := strings.Repeat("x", 10000)
+	changes = []*r53.Change{change("DELETE", long, long, long), change("DELETE", long), change("UPSERT", long)}
+	if found := sizes(changes); !reflect.DeepEqual(found, []int{1, 2}) {
+		t.Errorf("expected batches of 1 and 2 changes, got %v", found)
+	}
+
+	if found := sizes([]*r53.Change{change("DELETE", "a"), change("UPSERT", "b")}); !reflect.DeepEqual(found, []int{2}) {
+		t.Errorf("expected a single batch, got %v", found)
+	}
+}
@@ -0,0 +1,142 @@
+package route53
+
+// Routing policies: R53_FAILOVER and R53_WEIGHT.
+//
+// Records with a routing policy are in record sets of their own, told apart
+// by their set identifier. Route 53 only uses the health check of a record
+// set to choose between the sets of a routing policy.
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/StackExchange/dnscontrol/models"
+	"github.com/aws/aws-sdk-go/aws"
+	r53 "github.com/aws/aws-sdk-go/service/route53"
+)
+
+const (
+	metaSetIdentifier = "r53_set_identifier"
+	metaFailover      = "r53_failover" // PRIMARY or SECONDARY.
+	metaWeight        = "r53_weight"   // 0 to 255.
+)
+
+// routing is the routing policy of a record set.
+type routing struct {
+	setID    string
+	failover string
+	weight   *int64
+}
+
+func (p routing) policy() string {
+	switch {
+	case p.failover != "":
+		return "failover"
+	case p.weight != nil:
+		return "weighted"
+	}
+	return "simple"
+}
+
+// recordRouting returns the routing policy of the metadata of a record.
+func recordRouting(rec *models.RecordConfig) (routing, error) {
+	p := routing{
+		setID:    rec.Metadata[metaSetIdentifier],
+		failover: strings.ToUpper(rec.Metadata[metaFailover]),
+	}
+	if w, ok := rec.Metadata[metaWeight]; ok {
+		n, err := strconv.ParseInt(w, 10, 64)
+		if err != nil || n < 0 || n > 255 {
+			return p, fmt.Errorf("%s record %s: weight must be between 0 and 255, not %q", rec.Type, rec.NameFQDN, w)
+		}
+		p.weight = &n
+	}
+	switch {
+	case p.failover != "" && p.failover != r53.ResourceRecordSetFailoverPrimary && p.failover != r53.ResourceRecordSetFailoverSecondary:
+		return p, fmt.Errorf("%s record %s: failover must be PRIMARY or SECONDARY, not %q", rec.Type, rec.NameFQDN, p.failover)
+	case p.failover != "" && p.weight != nil:
+		return p, fmt.Errorf("%s record %s can't use both failover and weighted routing", rec.Type, rec.NameFQDN)
+	case p.setID == "" && p.policy() != "simple":
+		return p, fmt.Errorf("%s record %s: %s routing needs a set identifier", rec.Type, rec.NameFQDN, p.policy())
+	case p.setID != "" && p.policy() == "simple":
+		return p, fmt.Errorf("%s record %s: set identifier %s has no routing policy", rec.Type, rec.NameFQDN, p.setID)
+	}
+	return p, nil
+}
+
+// apply sets the routing policy of a record set.
+func (p routing) apply(set *r53.ResourceRecordSet) {
+	if p.setID != "" {
+		set.SetIdentifier = aws.String(p.setID)
+	}
+	if p.failover != "" {
+		set.Failover = aws.String(p.failover)
+	}
+	set.Weight = p.weight
+}
+
+// setRoutingMetadata sets the routing policy of a record set on a record read from Route 53.
+func setRoutingMetadata(rec *models.RecordConfig, set *r53.ResourceRecordSet) {
+	if set.SetIdentifier == nil {
+		return
+	}
+	if rec.Metadata == nil {
+		rec.Metadata = map[string]string{}
+	}
+	rec.Metadata[metaSetIdentifier] = *set.SetIdentifier
+	if set.Failover != nil {
+		rec.Metadata[metaFailover] = *set.Failover
+	}
+	if set.Weight != nil {
+		rec.Metadata[metaWeight] = strconv.FormatInt(*set.Weight, 10)
+	}
+}
+
+// checkRouting checks that the records with a health check have a routing
+// policy, that the records of a set agree, and that the sets of a name and
+// type use the same policy.
+func checkRouting(records []*models.RecordConfig) error {
+	sets := map[key]routing{}
+	policies := map[key]string{}
+	for _, rec := range records {
+		p, err := recordRouting(rec)
+		if err != nil {
+			return err
+		}
+		if check := rec.Metadata[metaHealthCheck]; check != "" && p.policy() == "simple" {
+			return fmt.Errorf("%s record %s uses health check %s without a routing policy, so Route 53 would ignore it: add R53_FAILOVER or R53_WEIGHT", rec.Type, rec.NameFQDN, check)
+		}
+		k := getKey(rec)
+		if prev, ok := sets[k]; ok && (prev.failover != p.failover || aws.Int64Value(prev.weight) != aws.Int64Value(p.weight)) {
+			return fmt.Errorf("the %s records of %s with set identifier %s must all use the same routing policy", rec.Type, rec.NameFQDN, p.setID)
+		}
+		sets[k] = p
+		nt := key{Name: rec.NameFQDN, Type: rec.Type}
+		if prev, ok := policies[nt]; ok && prev != p.policy() {
+			return fmt.Errorf("the %s records of %s mix %s and %s routing", rec.Type, rec.NameFQDN, prev, p.policy())
+		}
+		policies[nt] = p.policy()
+	}
+	return nil
+}
+
+// getRecordMetadata includes the routing policy and the health check of
+// records in the diff.
+func getRecordMetadata(r *models.RecordConfig) map[string]string {
+	vals := []string{}
+	for _, k := range []string{metaHealthCheck, metaSetIdentifier, metaFailover, metaWeight} {
+		if v := r.Metadata[k]; v != "" {
+			if k == metaFailover {
+				v = strings.ToUpper(v)
+			}
+			vals = append(vals, k+"="+v)
+		}
+	}
+	if len(vals) == 0 {
+		return nil
+	}
+	sort.Strings(vals)
+	return map[string]string{"r53": strings.Join(vals, " ")}
+}
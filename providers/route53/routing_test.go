@@ -0,0 +1,74 @@
+package route53
+
+import (
+	"testing"
+
+	"github.com/StackExchange/dnscontrol/models"
+	"github.com/aws/aws-sdk-go/aws"
+	r53 "github.com/aws/aws-sdk-go/service/route53"
+)
+
+func TestCheckRouting(t *testing.T) {
+	rec := func(name string, meta ...string) *models.RecordConfig {
+		r := &models.RecordConfig{NameFQDN: name + ".example.com", Type: "A", Metadata: map[string]string{}}
+		for i := 0; i < len(meta); i += 2 {
+			r.Metadata[meta[i]] = meta[i+1]
+		}
+		return r
+	}
+	tests := []struct {
+		desc    string
+		records []*models.RecordConfig
+		valid   bool
+	}{
+		{"simple", []*models.RecordConfig{rec("www"), rec("www")}, true},
+		{"failover", []*models.RecordConfig{
+			rec("www", metaSetIdentifier, "a", metaFailover, "PRIMARY", metaHealthCheck, "web"),
+			rec("www", metaSetIdentifier, "b", metaFailover, "secondary"),
+		}, true},
+		{"weighted", []*models.RecordConfig{
+			rec("www", metaSetIdentifier, "a", metaWeight, "10", metaHealthCheck, "web"),
+			rec("www", metaSetIdentifier, "a", metaWeight, "10", metaHealthCheck, "web"),
+			rec("www", metaSetIdentifier, "b", metaWeight, "0"),
+		}, true},
+		{"health check without routing", []*models.RecordConfig{rec("www", metaHealthCheck, "web")}, false},
+		{"no set identifier", []*models.RecordConfig{rec("www", metaFailover, "PRIMARY")}, false},
+		{"no policy", []*models.RecordConfig{rec("www", metaSetIdentifier, "a")}, false},
+		{"failover role", []*models.RecordConfig{rec("www", metaSetIdentifier, "a", metaFailover, "TERTIARY")}, false},
+		{"weight range", []*models.RecordConfig{rec("www", metaSetIdentifier, "a", metaWeight, "256")}, false},
+		{"weight number", []*models.RecordConfig{rec("www", metaSetIdentifier, "a", metaWeight, "high")}, false},
+		{"both policies", []*models.RecordConfig{rec("www", metaSetIdentifier, "a", metaWeight, "1", metaFailover, "PRIMARY")}, false},
+		{"mixed policies", []*models.RecordConfig{
+			rec("www", metaSetIdentifier, "a", metaWeight, "1"),
+			rec("www", metaSetIdentifier, "b", metaFailover, "PRIMARY"),
+		}, false},
+		{"mixed with simple", []*models.RecordConfig{rec("www", metaSetIdentifier, "a", metaWeight, "1"), rec("www")}, false},
+		{"set disagrees", []*models.RecordConfig{
+			rec("www", metaSetIdentifier, "a", metaWeight, "1"),
+			rec("www", metaSetIdentifier, "a", metaWeight, "2"),
+		}, false},
+	}
+	for _, tst := range tests {
+		t.Run(tst.desc, func(t *testing.T) {
+			err := checkRouting(tst.records)
+			if tst.valid && err != nil {
+				t.Error(err)
+			}
+			if !tst.valid && err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestRoutingMetadata(t *testing.T) {
+	desired := &models.RecordConfig{Metadata: map[string]string{metaSetIdentifier: "a", metaFailover: "primary", metaHealthCheck: "web"}}
+	existing := &models.RecordConfig{Metadata: map[string]string{metaHealthCheck: "web"}}
+	setRoutingMetadata(existing, &r53.ResourceRecordSet{SetIdentifier: aws.String("a"), Failover: aws.String("PRIMARY")})
+	if d, e := getRecordMetadata(desired), getRecordMetadata(existing); d["r53"] != e["r53"] {
+		t.Errorf("expected the same metadata, got %v and %v", d, e)
+	}
+	if m := getRecordMetadata(&models.RecordConfig{}); m != nil {
+		t.Errorf("expected no metadata, got %v", m)
+	}
+}
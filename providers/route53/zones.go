@@ -0,0 +1,151 @@
+package route53
+
+// Choosing between hosted zones that have the same name, and creating
+// private zones.
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/StackExchange/dnscontrol/models"
+	"github.com/aws/aws-sdk-go/aws"
+	r53 "github.com/aws/aws-sdk-go/service/route53"
+)
+
+const (
+	// Domain metadata.
+	metaZoneID   = "route53_zone_id"
+	metaZoneType = "route53_zone_type"
+)
+
+// vpc is a VPC that private zones created by EnsureDomainExists are associated with.
+type vpc struct {
+	ID     string `json:"id"`
+	Region string `json:"region"`
+}
+
+func isPrivate(z *r53.HostedZone) bool {
+	return z.Config != nil && aws.BoolValue(z.Config.PrivateZone)
+}
+
+func checkZoneType(t string) error {
+	if t != "" && t != "public" && t != "private" {
+		return fmt.Errorf("invalid zone type %q: use public or private", t)
+	}
+	return nil
+}
+
+// trimZoneID removes the "/hostedzone/" prefix of the ids returned by the API.
+func trimZoneID(id string) string {
+	return strings.TrimPrefix(id, "/hostedzone/")
+}
+
+// findZone returns the hosted zone named domain. If id is set, it is the
+// id of the zone. Otherwise there must be only one zone of the given type
+// ("public", "private" or "" for any) with that name.
+func (r *route53Provider) findZone(domain, zoneType, id string) (*r53.HostedZone, error) {
+	if err := checkZoneType(zoneType); err != nil {
+		return nil, err
+	}
+	if id != "" {
+		for _, z := range r.zones[domain] {
+			if trimZoneID(*z.Id) == trimZoneID(id) {
+				return z, nil
+			}
+		}
+		return nil, fmt.Errorf("hosted zone %s for %s not found in your route 53 account", id, domain)
+	}
+	found := r.matchingZones(domain, zoneType)
+	switch len(found) {
+	case 0:
+		return nil, errNoExist{domain}
+	case 1:
+		return found[0], nil
+	}
+	ids := make([]string, len(found))
+	for i, z := range found {
+		ids[i] = trimZoneID(*z.Id)
+	}
+	return nil, fmt.Errorf("%d hosted zones are named %s (%s): set %s or %s",
+		len(found), domain, strings.Join(ids, ", "), metaZoneType, metaZoneID)
+}
+
+// matchingZones returns the hosted zones named domain of the given type.
+func (r *route53Provider) matchingZones(domain, zoneType string) []*r53.HostedZone {
+	found := []*r53.HostedZone{}
+	for _, z := range r.zones[domain] {
+		if zoneType == "" || isPrivate(z) == (zoneType == "private") {
+			found = append(found, z)
+		}
+	}
+	return found
+}
+
+// zoneTypeFor returns the zone type of dc: its metadata or else the
+// zone_type of the provider.
+func (r *route53Provider) zoneTypeFor(dc *models.DomainConfig) string {
+	if t := dc.Metadata[metaZoneType]; t != "" {
+		return t
+	}
+	return r.zoneType
+}
+
+// zoneFor returns the hosted zone of dc, chosen by its metadata or else the
+// zone_type of the provider.
+func (r *route53Provider) zoneFor(dc *models.DomainConfig) (*r53.HostedZone, error) {
+	return r.findZone(dc.Name, r.zoneTypeFor(dc), dc.Metadata[metaZoneID])
+}
+
+func (r *route53Provider) EnsureDomainExists(domain string) error {
+	return r.EnsureDomainConfigExists(&models.DomainConfig{Name: domain})
+}
+
+// EnsureDomainConfigExists creates the hosted zone of dc, of the type chosen
+// as in zoneFor, unless there is one. Choosing between several zones is left
+// to GetDomainCorrections.
+func (r *route53Provider) EnsureDomainConfigExists(dc *models.DomainConfig) error {
+	domain := dc.Name
+	zoneType := r.zoneTypeFor(dc)
+	if err := checkZoneType(zoneType); err != nil {
+		return err
+	}
+	if id := dc.Metadata[metaZoneID]; id != "" {
+		// A zone can't be created with a given id.
+		_, err := r.findZone(domain, zoneType, id)
+		return err
+	}
+	if len(r.matchingZones(domain, zoneType)) > 0 {
+		return nil
+	}
+	in := &r53.CreateHostedZoneInput{
+		Name:            &domain,
+		CallerReference: sPtr(fmt.Sprint(time.Now().UnixNano())),
+	}
+	if zoneType == "private" {
+		if len(r.vpcs) == 0 {
+			return fmt.Errorf("creating a private zone for %s needs at least one vpc in the provider metadata", domain)
+		}
+		fmt.Printf("Adding private zone for %s to route 53 account\n", domain)
+		in.HostedZoneConfig = &r53.HostedZoneConfig{PrivateZone: aws.Bool(true)}
+		in.VPC = &r53.VPC{VPCId: &r.vpcs[0].ID, VPCRegion: &r.vpcs[0].Region}
+	} else {
+		fmt.Printf("Adding zone for %s to route 53 account\n", domain)
+	}
+	out, err := r.client.CreateHostedZone(in)
+	if err != nil {
+		return err
+	}
+	// A private zone is created with one VPC, the others are added after.
+	for i := 1; i < len(r.vpcs) && in.VPC != nil; i++ {
+		_, err := r.client.AssociateVPCWithHostedZone(&r53.AssociateVPCWithHostedZoneInput{
+			HostedZoneId: out.HostedZone.Id,
+			VPC:          &r53.VPC{VPCId: &r.vpcs[i].ID, VPCRegion: &r.vpcs[i].Region},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	r.zones[domain] = append(r.zones[domain], out.HostedZone)
+	return nil
+}
@@ -0,0 +1,205 @@
+package route53
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/StackExchange/dnscontrol/models"
+	"github.com/aws/aws-sdk-go/aws"
+	r53 "github.com/aws/aws-sdk-go/service/route53"
+)
+
+func testZone(id string, private bool) *r53.HostedZone {
+	return &r53.HostedZone{
+		Id:     aws.String("/hostedzone/" + id),
+		Name:   aws.String("corp.example.com."),
+		Config: &r53.HostedZoneConfig{PrivateZone: aws.Bool(private)},
+	}
+}
+
+func TestZoneFor(t *testing.T) {
+	r := &route53Provider{zones: map[string][]*r53.HostedZone{
+		"corp.example.com": {testZone("PUBLIC", false), testZone("PRIVATE1", true), testZone("PRIVATE2", true)},
+		"example.net":      {testZone("NET", false)},
+	}}
+	tests := []struct {
+		name     string
+		meta     map[string]string
+		expected string // The id, or "" for an error.
+	}{
+		{"example.net", nil, "NET"},
+		{"corp.example.com", nil, ""},
+		{"corp.example.com", map[string]string{metaZoneType: "public"}, "PUBLIC"},
+		{"corp.example.com", map[string]string{metaZoneType: "private"}, ""},
+		{"corp.example.com", map[string]string{metaZoneID: "PRIVATE2"}, "PRIVATE2"},
+		{"corp.example.com", map[string]string{metaZoneID: "/hostedzone/PRIVATE1"}, "PRIVATE1"},
+		{"corp.example.com", map[string]string{metaZoneID: "NET"}, ""},
+		{"example.net", map[string]string{metaZoneType: "private"}, ""},
+		{"example.net", map[string]string{metaZoneType: "internal"}, ""},
+	}
+	for _, tst := range tests {
+		z, err := r.zoneFor(&models.DomainConfig{Name: tst.name, Metadata: tst.meta})
+		found := ""
+		if err == nil {
+			found = trimZoneID(*z.Id)
+		}
+		if found != tst.expected {
+			t.Errorf("%s %v: expected %q, got %q (%v)", tst.name, tst.meta, tst.expected, found, err)
+		}
+	}
+
+	// The provider metadata applies to the domains that don't set a type.
+	r.zoneType = "private"
+	if _, err := r.zoneFor(&models.DomainConfig{Name: "example.net"}); err == nil {
+		t.Error("expected no private zone for example.net")
+	}
+}
+
+// fakeClient records the calls of the provider. The methods it doesn't
+// implement panic.
+type fakeClient struct {
+	route53Client
+	created []*r53.CreateHostedZoneInput
+	sets    []*r53.ResourceRecordSet
+	batches []*r53.ChangeBatch
+	calls   []string
+	// failBatch makes the nth ChangeResourceRecordSets call fail, from 1.
+	failBatch int
+}
+
+func (f *fakeClient) CreateHostedZone(in *r53.CreateHostedZoneInput) (*r53.CreateHostedZoneOutput, error) {
+	f.created = append(f.created, in)
+	f.calls = append(f.calls, "CreateHostedZone")
+	return &r53.CreateHostedZoneOutput{HostedZone: &r53.HostedZone{
+		Id:     aws.String("/hostedzone/NEW"),
+		Name:   in.Name,
+		Config: in.HostedZoneConfig,
+	}}, nil
+}
+
+func (f *fakeClient) AssociateVPCWithHostedZone(in *r53.AssociateVPCWithHostedZoneInput) (*r53.AssociateVPCWithHostedZoneOutput, error) {
+	f.calls = append(f.calls, "AssociateVPCWithHostedZone "+*in.VPC.VPCId)
+	return &r53.AssociateVPCWithHostedZoneOutput{}, nil
+}
+
+func (f *fakeClient) ListResourceRecordSets(in *r53.ListResourceRecordSetsInput) (*r53.ListResourceRecordSetsOutput, error) {
+	return &r53.ListResourceRecordSetsOutput{ResourceRecordSets: f.sets}, nil
+}
+
+func (f *fakeClient) ChangeResourceRecordSets(in *r53.ChangeResourceRecordSetsInput) (*r53.ChangeResourceRecordSetsOutput, error) {
+	f.batches = append(f.batches, in.ChangeBatch)
+	f.calls = append(f.calls, "ChangeResourceRecordSets")
+	if len(f.batches) == f.failBatch {
+		return nil, fmt.Errorf("InvalidChangeBatch")
+	}
+	return &r53.ChangeResourceRecordSetsOutput{}, nil
+}
+
+func (f *fakeClient) CreateHealthCheck(in *r53.CreateHealthCheckInput) (*r53.CreateHealthCheckOutput, error) {
+	f.calls = append(f.calls, "CreateHealthCheck")
+	return &r53.CreateHealthCheckOutput{HealthCheck: &r53.HealthCheck{Id: aws.String("NEW")}}, nil
+}
+
+func (f *fakeClient) ChangeTagsForResource(in *r53.ChangeTagsForResourceInput) (*r53.ChangeTagsForResourceOutput, error) {
+	f.calls = append(f.calls, "ChangeTagsForResource "+*in.ResourceId)
+	return &r53.ChangeTagsForResourceOutput{}, nil
+}
+
+func (f *fakeClient) UpdateHealthCheck(in *r53.UpdateHealthCheckInput) (*r53.UpdateHealthCheckOutput, error) {
+	f.calls = append(f.calls, "UpdateHealthCheck "+*in.HealthCheckId)
+	return &r53.UpdateHealthCheckOutput{}, nil
+}
+
+func (f *fakeClient) DeleteHealthCheck(in *r53.DeleteHealthCheckInput) (*r53.DeleteHealthCheckOutput, error) {
+	f.calls = append(f.calls, "DeleteHealthCheck "+*in.HealthCheckId)
+	return &r53.DeleteHealthCheckOutput{}, nil
+}
+
+func (f *fakeClient) GetHostedZone(in *r53.GetHostedZoneInput) (*r53.GetHostedZoneOutput, error) {
+	f.calls = append(f.calls, "GetHostedZone "+trimZoneID(*in.Id))
+	return &r53.GetHostedZoneOutput{DelegationSet: &r53.DelegationSet{
+		NameServers: []*string{aws.String("ns-" + trimZoneID(*in.Id) + ".awsdns.com")},
+	}}, nil
+}
+
+func TestGetDomainConfigNameservers(t *testing.T) {
+	f := &fakeClient{}
+	r := &route53Provider{client: f, zones: map[string][]*r53.HostedZone{
+		"corp.example.com": {testZone("OLD", false), testZone("NEW", false), testZone("PRIVATE", true)},
+	}}
+	if _, err := r.GetNameservers("corp.example.com"); err == nil {
+		t.Error("expected an error for two public zones")
+	}
+	if _, err := r.GetDomainConfigNameservers(&models.DomainConfig{Name: "corp.example.com"}); err == nil {
+		t.Error("expected an error for two public zones without a zone id")
+	}
+	ns, err := r.GetDomainConfigNameservers(&models.DomainConfig{Name: "corp.example.com", Metadata: map[string]string{metaZoneID: "NEW"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ns) != 1 || ns[0].Name != "ns-NEW.awsdns.com" {
+		t.Errorf("expected the nameservers of zone NEW, got %v", ns)
+	}
+}
+
+func TestEnsureDomainConfigExists(t *testing.T) {
+	tests := []struct {
+		desc     string
+		zoneType string // of the provider
+		zones    []*r53.HostedZone
+		meta     map[string]string
+		created  string // "public", "private", "" for none or "error".
+	}{
+		{"exists", "", []*r53.HostedZone{testZone("PUBLIC", false)}, nil, ""},
+		{"missing", "", nil, nil, "public"},
+		{"private missing", "", []*r53.HostedZone{testZone("PUBLIC", false)}, map[string]string{metaZoneType: "private"}, "private"},
+		{"private exists", "public", []*r53.HostedZone{testZone("PUBLIC", false), testZone("PRIVATE", true)}, map[string]string{metaZoneType: "private"}, ""},
+		{"provider private missing", "private", []*r53.HostedZone{testZone("PUBLIC", false)}, nil, "private"},
+		{"public and private", "", []*r53.HostedZone{testZone("PUBLIC", false), testZone("PRIVATE", true)}, nil, ""},
+		{"public metadata", "private", []*r53.HostedZone{testZone("PRIVATE", true)}, map[string]string{metaZoneType: "public"}, "public"},
+		{"zone id", "", []*r53.HostedZone{testZone("PUBLIC", false)}, map[string]string{metaZoneID: "PUBLIC"}, ""},
+		{"missing zone id", "", []*r53.HostedZone{testZone("PUBLIC", false)}, map[string]string{metaZoneID: "OTHER"}, "error"},
+		{"invalid type", "", nil, map[string]string{metaZoneType: "internal"}, "error"},
+	}
+	for _, tst := range tests {
+		t.Run(tst.desc, func(t *testing.T) {
+			f := &fakeClient{}
+			r := &route53Provider{
+				client:   f,
+				zoneType: tst.zoneType,
+				zones:    map[string][]*r53.HostedZone{"corp.example.com": tst.zones},
+				vpcs:     []vpc{{ID: "vpc-1", Region: "us-east-1"}, {ID: "vpc-2", Region: "eu-west-1"}},
+			}
+			dc := &models.DomainConfig{Name: "corp.example.com", Metadata: tst.meta}
+			err := r.EnsureDomainConfigExists(dc)
+			if tst.created == "error" {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			switch {
+			case tst.created == "" && len(f.created) != 0:
+				t.Fatalf("expected no zone to be created, got %v", f.created)
+			case tst.created == "":
+				return
+			case len(f.created) != 1:
+				t.Fatalf("expected a %s zone to be created, got %v", tst.created, f.created)
+			}
+			private := f.created[0].HostedZoneConfig != nil && aws.BoolValue(f.created[0].HostedZoneConfig.PrivateZone)
+			if private != (tst.created == "private") {
+				t.Errorf("expected a %s zone, got %v", tst.created, f.created[0])
+			}
+			if private && (len(f.calls) != 2 || f.calls[1] != "AssociateVPCWithHostedZone vpc-2") {
+				t.Errorf("expected the second vpc to be associated, got %v", f.calls)
+			}
+			// The new zone is the one GetDomainCorrections uses.
+			if z, err := r.zoneFor(dc); err != nil || trimZoneID(*z.Id) != "NEW" {
+				t.Errorf("expected the new zone, got %v %v", z, err)
+			}
+		})
+	}
+}
@@ -37,6 +37,18 @@ func newReg(conf map[string]string, _ json.RawMessage) (providers.DNSServiceProv
 		return nil, fmt.Errorf("SoftLayer UserName and APIKey must be provided")
 	}
 
+	opts, err := providers.HTTPOptionsFromCreds(conf, providers.DefaultHTTPOptions)
+	if err != nil {
+		return nil, fmt.Errorf("SOFTLAYER: %s", err)
+	}
+	if s.Timeout != 0 && conf["http_timeout"] == "" {
+		opts.Timeout = s.Timeout
+	}
+	if !strings.Contains(s.Endpoint, "/xmlrpc/") {
+		// XML-RPC endpoints keep the transport of softlayer-go.
+		s.TransportHandler = &restTransport{client: opts.NewClient("SOFTLAYER")}
+	}
+
 	// s.Debug = true
 
 	api := &SoftLayer{
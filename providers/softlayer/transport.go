@@ -0,0 +1,121 @@
+package softlayer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/softlayer/softlayer-go/datatypes"
+	"github.com/softlayer/softlayer-go/session"
+	"github.com/softlayer/softlayer-go/sl"
+)
+
+// restTransport sends the requests of the softlayer-go services to the REST
+// endpoint, like session.RestTransport, but with the client of
+// providers.NewHTTPClient: session.RestTransport uses http.DefaultClient.
+type restTransport struct {
+	client *http.Client
+}
+
+// restMethod returns the HTTP method of an API method.
+func restMethod(method string, args []interface{}) string {
+	switch {
+	case method == "deleteObject":
+		return "DELETE"
+	case method == "editObject" || method == "editObjects":
+		return "PUT"
+	case method == "createObject" || method == "createObjects" || len(args) > 0:
+		return "POST"
+	}
+	return "GET"
+}
+
+// restURL returns the URL of an API method.
+func restURL(endpoint, service, method string, options *sl.Options) string {
+	path := service
+	if options.Id != nil {
+		path += "/" + strconv.Itoa(*options.Id)
+	}
+	switch method {
+	case "getObject", "deleteObject", "createObject", "createObjects", "editObject", "editObjects":
+		// The HTTP method is enough.
+	default:
+		path += "/" + method
+	}
+	query := url.Values{}
+	if options.Mask != "" {
+		query.Set("objectMask", options.Mask)
+	}
+	if options.Filter != "" {
+		query.Set("objectFilter", options.Filter)
+	}
+	if options.Limit != nil {
+		offset := 0
+		if options.Offset != nil {
+			offset = *options.Offset
+		}
+		query.Set("resultLimit", fmt.Sprintf("%d,%d", offset, *options.Limit))
+	}
+	u := strings.TrimRight(endpoint, "/") + "/" + path + ".json"
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	return u
+}
+
+// DoRequest implements session.TransportHandler.
+func (t *restTransport) DoRequest(sess *session.Session, service, method string, args []interface{}, options *sl.Options, pResult interface{}) error {
+	var body io.Reader
+	if len(args) > 0 {
+		b, err := json.Marshal(map[string]interface{}{"parameters": args})
+		if err != nil {
+			return sl.Error{Wrapped: err}
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(restMethod(method, args), restURL(sess.Endpoint, service, method, options), body)
+	if err != nil {
+		return sl.Error{Wrapped: err}
+	}
+	req.SetBasicAuth(sess.UserName, sess.APIKey)
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return sl.Error{Wrapped: err}
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return sl.Error{Wrapped: err}
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		e := sl.Error{StatusCode: resp.StatusCode}
+		if err := json.Unmarshal(data, &e); err != nil {
+			e.Wrapped = err
+			e.Message = err.Error()
+		}
+		return e
+	}
+
+	switch r := pResult.(type) {
+	case *datatypes.Void:
+	case *bool:
+		*r, err = strconv.ParseBool(string(data))
+	default:
+		// Methods returning a list leave out the [] when it has one item.
+		if strings.HasPrefix(reflect.TypeOf(pResult).String(), "*[]") && !bytes.HasPrefix(data, []byte("[")) {
+			data = append(append([]byte("["), data...), ']')
+		}
+		err = json.Unmarshal(data, pResult)
+	}
+	if err != nil {
+		return sl.Error{Message: err.Error(), Wrapped: err}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package softlayer
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/softlayer/softlayer-go/datatypes"
+	"github.com/softlayer/softlayer-go/services"
+	"github.com/softlayer/softlayer-go/session"
+	"github.com/softlayer/softlayer-go/sl"
+)
+
+func TestRestTransport(t *testing.T) {
+	requests := []string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		user, key, _ := r.BasicAuth()
+		requests = append(requests, r.Method+" "+r.URL.String()+" "+user+":"+key+" "+string(body))
+		switch r.URL.Path {
+		case "/SoftLayer_Account/getDomains.json":
+			// A list of one item comes without [].
+			w.Write([]byte(`{"id":1,"name":"example.com"}`))
+		case "/SoftLayer_Dns_Domain_ResourceRecord/7.json":
+			w.Write([]byte(`true`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"not found","code":"SoftLayer_Exception_NotFound"}`))
+		}
+	}))
+	defer srv.Close()
+
+	sess := &session.Session{UserName: "user", APIKey: "key", Endpoint: srv.URL + "/", TransportHandler: &restTransport{client: http.DefaultClient}}
+	domains, err := services.GetAccountService(sess).Mask("id;name").GetDomains()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(domains) != 1 || *domains[0].Name != "example.com" {
+		t.Errorf("unexpected domains %v", domains)
+	}
+
+	ok, err := services.GetDnsDomainResourceRecordService(sess).Id(7).EditObject(&datatypes.Dns_Domain_ResourceRecord{Data: sl.String("1.2.3.4")})
+	if err != nil || !ok {
+		t.Errorf("unexpected result %v, %v", ok, err)
+	}
+
+	_, err = services.GetDnsDomainResourceRecordService(sess).Id(8).DeleteObject()
+	if e, isSL := err.(sl.Error); !isSL || e.StatusCode != 404 || e.Exception != "SoftLayer_Exception_NotFound" {
+		t.Errorf("expected a not found error, got %#v", err)
+	}
+
+	expected := []string{
+		"GET /SoftLayer_Account/getDomains.json?objectMask=id%3Bname user:key ",
+		`PUT /SoftLayer_Dns_Domain_ResourceRecord/7.json user:key {"parameters":[{"data":"1.2.3.4"}]}`,
+		"DELETE /SoftLayer_Dns_Domain_ResourceRecord/8.json user:key ",
+	}
+	for i := range expected {
+		if i >= len(requests) || requests[i] != expected[i] {
+			t.Errorf("expected requests\n%q\ngot\n%q", expected, requests)
+			break
+		}
+	}
+}
@@ -60,10 +60,16 @@ func NewVultr(m map[string]string, metadata json.RawMessage) (providers.DNSServi
 		return nil, fmt.Errorf("Vultr API token is required")
 	}
 
-	api.client = vultr.NewClient(api.token, nil)
+	// Give the library its own client: by default it replaces the transport of
+	// http.DefaultClient, which every other provider shares.
+	hc, err := providers.NewHTTPClient("VULTR", m)
+	if err != nil {
+		return nil, err
+	}
+	api.client = vultr.NewClient(api.token, &vultr.Options{HTTPClient: hc})
 
 	// Validate token
-	_, err := api.client.GetAccountInfo()
+	_, err = api.client.GetAccountInfo()
 	if err != nil {
 		return nil, err
 	}